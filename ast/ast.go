@@ -109,7 +109,10 @@ func (mk *MultiKeyword) End() token.Pos        { return findTo(mk) }
 func (mk *MultiKeyword) GetKeywords() []Node   { return mk.Keywords }
 
 type MemberIdentifer struct {
-	Toks        []Node
+	Toks []Node
+	// Schema is set only for three part names such as schema.table.column
+	Schema      Node
+	SchemaTok   *SQLToken
 	Parent      Node
 	ParentTok   *SQLToken
 	ParentIdent *Identifer
@@ -140,6 +143,22 @@ func NewMemberIdentifer(nodes []Node, parent Node, child Node) *MemberIdentifer
 	return memberIdentifier
 }
 
+// NewMemberIdentiferSchema returns schema.parent.child, child is nil if the name ends with a period.
+func NewMemberIdentiferSchema(nodes []Node, schema Node, parent Node, child Node) *MemberIdentifer {
+	memberIdentifier := NewMemberIdentiferParent(nodes, parent)
+	memberIdentifier.Schema = schema
+	if tok, ok := schema.(Token); ok {
+		memberIdentifier.SchemaTok = tok.GetToken()
+	}
+	if child != nil {
+		memberIdentifier.setChild(child)
+		if v, ok := child.(*Identifer); ok {
+			memberIdentifier.ChildIdent = v
+		}
+	}
+	return memberIdentifier
+}
+
 func (mi *MemberIdentifer) String() string {
 	var strs []string
 	for _, t := range mi.Toks {
@@ -185,6 +204,14 @@ func (mi *MemberIdentifer) GetParentIdent() *Identifer {
 	}
 	return mi.ParentIdent
 }
+
+// SchemaName returns the unquoted schema of schema.table.column, it is empty for two part names.
+func (mi *MemberIdentifer) SchemaName() string {
+	if mi.SchemaTok == nil {
+		return ""
+	}
+	return mi.SchemaTok.NoQuateString()
+}
 func (mi *MemberIdentifer) GetChild() Node {
 	if mi.Child == nil {
 		return &Null{}
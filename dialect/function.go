@@ -0,0 +1,51 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Function struct {
+	Name        string
+	Params      []string
+	ReturnType  string
+	Description string
+	// Variadic is true if the last parameter can be repeated
+	Variadic bool
+}
+
+func (f *Function) Signature() string {
+	params := strings.Join(f.Params, ", ")
+	if f.Variadic {
+		params += ", ..."
+	}
+	sig := fmt.Sprintf("%s(%s)", f.Name, params)
+	if f.ReturnType != "" {
+		sig = fmt.Sprintf("%s RETURNS %s", sig, f.ReturnType)
+	}
+	return sig
+}
+
+func DataBaseFunctions(driver DatabaseDriver) []*Function {
+	switch driver {
+	case DatabaseDriverMySQL:
+		return mysqlFunctions
+	case DatabaseDriverPostgreSQL:
+		return postgresqlFunctions
+	case DatabaseDriverSQLite3:
+		return sqliteFunctions
+	default:
+		return sqliteFunctions
+	}
+}
+
+// LookupFunctions returns all overloads of the built-in function.
+func LookupFunctions(driver DatabaseDriver, name string) []*Function {
+	res := []*Function{}
+	for _, f := range DataBaseFunctions(driver) {
+		if strings.EqualFold(f.Name, name) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
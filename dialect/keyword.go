@@ -396,3 +396,71 @@ func DataBaseKeywords(driver DatabaseDriver) []string {
 		return sqliteKeywords
 	}
 }
+
+// DataBaseKeywordsByVersion returns the keywords supported by the server version.
+// The version is the one reported by the server such as "8.0.32" or "13.4 (Debian 13.4-1)", DataBaseKeywords is used if it is unknown.
+func DataBaseKeywordsByVersion(driver DatabaseDriver, version string) []string {
+	major, minor, ok := ParseVersion(version)
+	if !ok {
+		return DataBaseKeywords(driver)
+	}
+	switch driver {
+	case DatabaseDriverMySQL:
+		switch {
+		case major < 5 || (major == 5 && minor <= 6):
+			return mysql56Keyword
+		case major == 5:
+			return mysql57Keyword
+		default:
+			return mysql8Keyword
+		}
+	case DatabaseDriverPostgreSQL:
+		switch {
+		case major <= 11:
+			return postgresql11Keywords
+		case major == 12:
+			return postgresql12Keywords
+		case major < 15:
+			return postgresql13Keywords
+		default:
+			return postgresql15Keywords
+		}
+	default:
+		return DataBaseKeywords(driver)
+	}
+}
+
+// ParseVersion reads the leading "major.minor" of the version
+func ParseVersion(version string) (major, minor int, ok bool) {
+	nums := []int{}
+	cur, inNum := 0, false
+	for _, r := range version {
+		if '0' <= r && r <= '9' {
+			cur = cur*10 + int(r-'0')
+			inNum = true
+			continue
+		}
+		if !inNum {
+			if len(nums) > 0 {
+				break
+			}
+			continue
+		}
+		nums = append(nums, cur)
+		cur, inNum = 0, false
+		if r != '.' || len(nums) == 2 {
+			break
+		}
+	}
+	if inNum && len(nums) < 2 {
+		nums = append(nums, cur)
+	}
+	switch len(nums) {
+	case 0:
+		return 0, 0, false
+	case 1:
+		return nums[0], 0, true
+	default:
+		return nums[0], nums[1], true
+	}
+}
@@ -0,0 +1,74 @@
+package dialect
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantMajor int
+		wantMinor int
+		wantOK    bool
+	}{
+		{version: "8.0.32", wantMajor: 8, wantMinor: 0, wantOK: true},
+		{version: "5.7.41-log", wantMajor: 5, wantMinor: 7, wantOK: true},
+		{version: "13.4 (Debian 13.4-1.pgdg100+1)", wantMajor: 13, wantMinor: 4, wantOK: true},
+		{version: "PostgreSQL 15.2", wantMajor: 15, wantMinor: 2, wantOK: true},
+		{version: "16devel", wantMajor: 16, wantMinor: 0, wantOK: true},
+		{version: "15", wantMajor: 15, wantMinor: 0, wantOK: true},
+		{version: "", wantOK: false},
+		{version: "unknown", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, minor, ok := ParseVersion(tt.version)
+			if ok != tt.wantOK || major != tt.wantMajor || minor != tt.wantMinor {
+				t.Errorf("got %d.%d %v, want %d.%d %v", major, minor, ok, tt.wantMajor, tt.wantMinor, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDataBaseKeywordsByVersion(t *testing.T) {
+	tests := []struct {
+		driver  DatabaseDriver
+		version string
+		want    []string
+	}{
+		{driver: DatabaseDriverMySQL, version: "8.0.32", want: mysql8Keyword},
+		{driver: DatabaseDriverMySQL, version: "5.7.41-log", want: mysql57Keyword},
+		{driver: DatabaseDriverMySQL, version: "5.6.51", want: mysql56Keyword},
+		{driver: DatabaseDriverMySQL, version: "", want: mysql8Keyword},
+		{driver: DatabaseDriverPostgreSQL, version: "10.23", want: postgresql11Keywords},
+		{driver: DatabaseDriverPostgreSQL, version: "11.18", want: postgresql11Keywords},
+		{driver: DatabaseDriverPostgreSQL, version: "12.13", want: postgresql12Keywords},
+		{driver: DatabaseDriverPostgreSQL, version: "14.6", want: postgresql13Keywords},
+		{driver: DatabaseDriverPostgreSQL, version: "15.1", want: postgresql15Keywords},
+		{driver: DatabaseDriverPostgreSQL, version: "", want: postgresql13Keywords},
+		{driver: DatabaseDriverSQLite3, version: "3.39.4", want: sqliteKeywords},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.driver)+" "+tt.version, func(t *testing.T) {
+			got := DataBaseKeywordsByVersion(tt.driver, tt.version)
+			if len(got) != len(tt.want) || (len(got) > 0 && &got[0] != &tt.want[0]) {
+				t.Errorf("unexpected keyword set for %s %q", tt.driver, tt.version)
+			}
+		})
+	}
+}
+
+func TestPostgreSQLMergeKeyword(t *testing.T) {
+	contains := func(keywords []string, keyword string) bool {
+		for _, k := range keywords {
+			if k == keyword {
+				return true
+			}
+		}
+		return false
+	}
+	if contains(DataBaseKeywordsByVersion(DatabaseDriverPostgreSQL, "14.6"), "MERGE") {
+		t.Error("MERGE must not be suggested before PostgreSQL 15")
+	}
+	if !contains(DataBaseKeywordsByVersion(DatabaseDriverPostgreSQL, "15.1"), "MERGE") {
+		t.Error("MERGE must be suggested since PostgreSQL 15")
+	}
+}
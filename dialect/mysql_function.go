@@ -0,0 +1,321 @@
+package dialect
+
+var mysqlFunctions = []*Function{
+	// aggregate functions
+	{
+		Name:        "AVG",
+		Params:      []string{"expr"},
+		ReturnType:  "DOUBLE",
+		Description: "Returns the average value of expr.",
+	},
+	{
+		Name:        "COUNT",
+		Params:      []string{"expr"},
+		ReturnType:  "BIGINT",
+		Description: "Returns a count of the number of non-NULL values of expr in the rows retrieved by a SELECT statement. COUNT(*) counts all rows.",
+	},
+	{
+		Name:        "GROUP_CONCAT",
+		Params:      []string{"expr"},
+		ReturnType:  "TEXT",
+		Description: "Returns a string result with the concatenated non-NULL values from a group.",
+	},
+	{
+		Name:        "JSON_ARRAYAGG",
+		Params:      []string{"col_or_expr"},
+		ReturnType:  "JSON",
+		Description: "Aggregates a result set as a single JSON array whose elements consist of the rows.",
+	},
+	{
+		Name:        "JSON_OBJECTAGG",
+		Params:      []string{"key", "value"},
+		ReturnType:  "JSON",
+		Description: "Takes two column names or expressions as arguments and returns a JSON object containing key-value pairs.",
+	},
+	{
+		Name:        "MAX",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Returns the maximum value of expr.",
+	},
+	{
+		Name:        "MIN",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Returns the minimum value of expr.",
+	},
+	{
+		Name:        "SUM",
+		Params:      []string{"expr"},
+		ReturnType:  "DECIMAL",
+		Description: "Returns the sum of expr. If the return set has no rows, SUM() returns NULL.",
+	},
+	// window functions
+	{
+		Name:        "ROW_NUMBER",
+		ReturnType:  "BIGINT",
+		Description: "Returns the number of the current row within its partition. Rows numbers range from 1 to the number of partition rows.",
+	},
+	{
+		Name:        "RANK",
+		ReturnType:  "BIGINT",
+		Description: "Returns the rank of the current row within its partition, with gaps.",
+	},
+	{
+		Name:        "DENSE_RANK",
+		ReturnType:  "BIGINT",
+		Description: "Returns the rank of the current row within its partition, without gaps.",
+	},
+	{
+		Name:        "NTILE",
+		Params:      []string{"N"},
+		ReturnType:  "BIGINT",
+		Description: "Divides a partition into N groups (buckets), assigns each row in the partition its bucket number, and returns the bucket number of the current row within its partition.",
+	},
+	{
+		Name:        "LAG",
+		Params:      []string{"expr", "N", "default"},
+		ReturnType:  "same as input",
+		Description: "Returns the value of expr from the row that lags (precedes) the current row by N rows within its partition. If there is no such row, the return value is default.",
+	},
+	{
+		Name:        "LEAD",
+		Params:      []string{"expr", "N", "default"},
+		ReturnType:  "same as input",
+		Description: "Returns the value of expr from the row that leads (follows) the current row by N rows within its partition. If there is no such row, the return value is default.",
+	},
+	{
+		Name:        "FIRST_VALUE",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Returns the value of expr from the first row of the window frame.",
+	},
+	{
+		Name:        "LAST_VALUE",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Returns the value of expr from the last row of the window frame.",
+	},
+	// flow control functions
+	{
+		Name:        "COALESCE",
+		Params:      []string{"value"},
+		ReturnType:  "same as input",
+		Description: "Returns the first non-NULL value in the list, or NULL if there are no non-NULL values.",
+		Variadic:    true,
+	},
+	{
+		Name:        "IF",
+		Params:      []string{"expr1", "expr2", "expr3"},
+		ReturnType:  "same as input",
+		Description: "If expr1 is TRUE, IF() returns expr2. Otherwise, it returns expr3.",
+	},
+	{
+		Name:        "IFNULL",
+		Params:      []string{"expr1", "expr2"},
+		ReturnType:  "same as input",
+		Description: "If expr1 is not NULL, IFNULL() returns expr1; otherwise it returns expr2.",
+	},
+	{
+		Name:        "NULLIF",
+		Params:      []string{"expr1", "expr2"},
+		ReturnType:  "same as input",
+		Description: "Returns NULL if expr1 = expr2 is true, otherwise returns expr1.",
+	},
+	{
+		Name:        "GREATEST",
+		Params:      []string{"value1", "value2"},
+		ReturnType:  "same as input",
+		Description: "With two or more arguments, returns the largest (maximum-valued) argument.",
+		Variadic:    true,
+	},
+	{
+		Name:        "LEAST",
+		Params:      []string{"value1", "value2"},
+		ReturnType:  "same as input",
+		Description: "With two or more arguments, returns the smallest (minimum-valued) argument.",
+		Variadic:    true,
+	},
+	// numeric functions
+	{
+		Name:        "ABS",
+		Params:      []string{"X"},
+		ReturnType:  "same as input",
+		Description: "Returns the absolute value of X, or NULL if X is NULL.",
+	},
+	{
+		Name:        "CEIL",
+		Params:      []string{"X"},
+		ReturnType:  "BIGINT",
+		Description: "Returns the smallest integer value not less than X.",
+	},
+	{
+		Name:        "FLOOR",
+		Params:      []string{"X"},
+		ReturnType:  "BIGINT",
+		Description: "Returns the largest integer value not greater than X.",
+	},
+	{
+		Name:        "ROUND",
+		Params:      []string{"X", "D"},
+		ReturnType:  "same as input",
+		Description: "Rounds the argument X to D decimal places.",
+	},
+	{
+		Name:        "RAND",
+		Params:      []string{"N"},
+		ReturnType:  "DOUBLE",
+		Description: "Returns a random floating-point value v in the range 0 <= v < 1.0. If an integer argument N is specified, it is used as the seed value.",
+	},
+	// string functions
+	{
+		Name:        "CONCAT",
+		Params:      []string{"str1", "str2"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the string that results from concatenating the arguments. Returns NULL if any argument is NULL.",
+		Variadic:    true,
+	},
+	{
+		Name:        "CONCAT_WS",
+		Params:      []string{"separator", "str1", "str2"},
+		ReturnType:  "VARCHAR",
+		Description: "Concatenate with separator. The first argument is the separator for the rest of the arguments.",
+		Variadic:    true,
+	},
+	{
+		Name:        "LENGTH",
+		Params:      []string{"str"},
+		ReturnType:  "BIGINT",
+		Description: "Returns the length of the string str, measured in bytes.",
+	},
+	{
+		Name:        "CHAR_LENGTH",
+		Params:      []string{"str"},
+		ReturnType:  "BIGINT",
+		Description: "Returns the length of the string str, measured in characters.",
+	},
+	{
+		Name:        "LOWER",
+		Params:      []string{"str"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the string str with all characters changed to lowercase according to the current character set mapping.",
+	},
+	{
+		Name:        "UPPER",
+		Params:      []string{"str"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the string str with all characters changed to uppercase according to the current character set mapping.",
+	},
+	{
+		Name:        "REPLACE",
+		Params:      []string{"str", "from_str", "to_str"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the string str with all occurrences of the string from_str replaced by the string to_str.",
+	},
+	{
+		Name:        "SUBSTRING",
+		Params:      []string{"str", "pos", "len"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns a substring len characters long from string str, starting at position pos.",
+	},
+	{
+		Name:        "SUBSTRING_INDEX",
+		Params:      []string{"str", "delim", "count"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the substring from string str before count occurrences of the delimiter delim.",
+	},
+	{
+		Name:        "TRIM",
+		Params:      []string{"str"},
+		ReturnType:  "VARCHAR",
+		Description: "Returns the string str with leading and trailing spaces removed.",
+	},
+	// date and time functions
+	{
+		Name:        "NOW",
+		ReturnType:  "DATETIME",
+		Description: "Returns the current date and time as a value in 'YYYY-MM-DD hh:mm:ss' or YYYYMMDDhhmmss format.",
+	},
+	{
+		Name:        "CURDATE",
+		ReturnType:  "DATE",
+		Description: "Returns the current date as a value in 'YYYY-MM-DD' or YYYYMMDD format.",
+	},
+	{
+		Name:        "DATE_ADD",
+		Params:      []string{"date", "INTERVAL expr unit"},
+		ReturnType:  "DATETIME",
+		Description: "Performs date arithmetic. The date argument specifies the starting date or datetime value.",
+	},
+	{
+		Name:        "DATE_SUB",
+		Params:      []string{"date", "INTERVAL expr unit"},
+		ReturnType:  "DATETIME",
+		Description: "Performs date arithmetic. The date argument specifies the starting date or datetime value.",
+	},
+	{
+		Name:        "DATE_FORMAT",
+		Params:      []string{"date", "format"},
+		ReturnType:  "VARCHAR",
+		Description: "Formats the date value according to the format string.",
+	},
+	{
+		Name:        "DATEDIFF",
+		Params:      []string{"expr1", "expr2"},
+		ReturnType:  "BIGINT",
+		Description: "Returns expr1 - expr2 expressed as a value in days from one date to the other.",
+	},
+	{
+		Name:        "STR_TO_DATE",
+		Params:      []string{"str", "format"},
+		ReturnType:  "DATETIME",
+		Description: "This is the inverse of the DATE_FORMAT() function. It takes a string str and a format string format.",
+	},
+	{
+		Name:        "UNIX_TIMESTAMP",
+		Params:      []string{"date"},
+		ReturnType:  "BIGINT",
+		Description: "Returns a Unix timestamp representing seconds since '1970-01-01 00:00:00' UTC for the date argument.",
+	},
+	// json functions
+	{
+		Name:        "JSON_EXTRACT",
+		Params:      []string{"json_doc", "path"},
+		ReturnType:  "JSON",
+		Description: "Returns data from a JSON document, selected from the parts of the document matched by the path arguments.",
+		Variadic:    true,
+	},
+	{
+		Name:        "JSON_UNQUOTE",
+		Params:      []string{"json_val"},
+		ReturnType:  "LONGTEXT",
+		Description: "Unquotes JSON value and returns the result as a utf8mb4 string.",
+	},
+	{
+		Name:        "JSON_OBJECT",
+		Params:      []string{"key", "val"},
+		ReturnType:  "JSON",
+		Description: "Evaluates a (possibly empty) list of key-value pairs and returns a JSON object containing those pairs.",
+		Variadic:    true,
+	},
+	{
+		Name:        "JSON_ARRAY",
+		Params:      []string{"val"},
+		ReturnType:  "JSON",
+		Description: "Evaluates a (possibly empty) list of values and returns a JSON array containing those values.",
+		Variadic:    true,
+	},
+	{
+		Name:        "JSON_SET",
+		Params:      []string{"json_doc", "path", "val"},
+		ReturnType:  "JSON",
+		Description: "Inserts or updates data in a JSON document and returns the result.",
+		Variadic:    true,
+	},
+	{
+		Name:        "JSON_CONTAINS",
+		Params:      []string{"target", "candidate", "path"},
+		ReturnType:  "INT",
+		Description: "Indicates by returning 1 or 0 whether a given candidate JSON document is contained within a target JSON document.",
+	},
+}
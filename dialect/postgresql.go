@@ -1,5 +1,8 @@
 package dialect
 
+// postgresql15Keywords adds MERGE introduced in PostgreSQL 15
+var postgresql15Keywords = append(append([]string{}, postgresql13Keywords...), "MATCHED", "MERGE")
+
 var postgresql13Keywords = []string{
 	"ABORT",
 	"ABSOLUTE",
@@ -0,0 +1,312 @@
+package dialect
+
+var postgresqlFunctions = []*Function{
+	// aggregate functions
+	{
+		Name:        "array_agg",
+		Params:      []string{"expression anyelement"},
+		ReturnType:  "anyarray",
+		Description: "Collects all the input values, including nulls, into an array.",
+	},
+	{
+		Name:        "avg",
+		Params:      []string{"expression numeric"},
+		ReturnType:  "numeric",
+		Description: "Computes the average (arithmetic mean) of all the non-null input values.",
+	},
+	{
+		Name:        "bool_and",
+		Params:      []string{"expression boolean"},
+		ReturnType:  "boolean",
+		Description: "Returns true if all non-null input values are true, otherwise false.",
+	},
+	{
+		Name:        "bool_or",
+		Params:      []string{"expression boolean"},
+		ReturnType:  "boolean",
+		Description: "Returns true if any non-null input value is true, otherwise false.",
+	},
+	{
+		Name:        "count",
+		Params:      []string{"expression any"},
+		ReturnType:  "bigint",
+		Description: "Computes the number of input rows in which the input value is not null. count(*) computes the number of input rows.",
+	},
+	{
+		Name:        "json_agg",
+		Params:      []string{"expression anyelement"},
+		ReturnType:  "json",
+		Description: "Collects all the input values, including nulls, into a JSON array.",
+	},
+	{
+		Name:        "jsonb_agg",
+		Params:      []string{"expression anyelement"},
+		ReturnType:  "jsonb",
+		Description: "Collects all the input values, including nulls, into a JSON array.",
+	},
+	{
+		Name:        "max",
+		Params:      []string{"expression any"},
+		ReturnType:  "same as input",
+		Description: "Computes the maximum of the non-null input values.",
+	},
+	{
+		Name:        "min",
+		Params:      []string{"expression any"},
+		ReturnType:  "same as input",
+		Description: "Computes the minimum of the non-null input values.",
+	},
+	{
+		Name:        "string_agg",
+		Params:      []string{"value text", "delimiter text"},
+		ReturnType:  "text",
+		Description: "Concatenates the non-null input values into a string. Each value after the first is preceded by the corresponding delimiter.",
+	},
+	{
+		Name:        "sum",
+		Params:      []string{"expression numeric"},
+		ReturnType:  "same as input",
+		Description: "Computes the sum of the non-null input values.",
+	},
+	// window functions
+	{
+		Name:        "row_number",
+		ReturnType:  "bigint",
+		Description: "Returns the number of the current row within its partition, counting from 1.",
+	},
+	{
+		Name:        "rank",
+		ReturnType:  "bigint",
+		Description: "Returns the rank of the current row, with gaps; that is, the row_number of the first row in its peer group.",
+	},
+	{
+		Name:        "dense_rank",
+		ReturnType:  "bigint",
+		Description: "Returns the rank of the current row, without gaps; this function effectively counts peer groups.",
+	},
+	{
+		Name:        "ntile",
+		Params:      []string{"num_buckets integer"},
+		ReturnType:  "integer",
+		Description: "Returns an integer ranging from 1 to the argument value, dividing the partition as equally as possible.",
+	},
+	{
+		Name:        "lag",
+		Params:      []string{"value anyelement", "offset integer", "default anyelement"},
+		ReturnType:  "anyelement",
+		Description: "Returns value evaluated at the row that is offset rows before the current row within the partition; if there is no such row, instead returns default.",
+	},
+	{
+		Name:        "lead",
+		Params:      []string{"value anyelement", "offset integer", "default anyelement"},
+		ReturnType:  "anyelement",
+		Description: "Returns value evaluated at the row that is offset rows after the current row within the partition; if there is no such row, instead returns default.",
+	},
+	{
+		Name:        "first_value",
+		Params:      []string{"value anyelement"},
+		ReturnType:  "anyelement",
+		Description: "Returns value evaluated at the row that is the first row of the window frame.",
+	},
+	{
+		Name:        "last_value",
+		Params:      []string{"value anyelement"},
+		ReturnType:  "anyelement",
+		Description: "Returns value evaluated at the row that is the last row of the window frame.",
+	},
+	{
+		Name:        "nth_value",
+		Params:      []string{"value anyelement", "n integer"},
+		ReturnType:  "anyelement",
+		Description: "Returns value evaluated at the row that is the n'th row of the window frame (counting from 1); returns NULL if there is no such row.",
+	},
+	// conditional functions
+	{
+		Name:        "coalesce",
+		Params:      []string{"value any"},
+		ReturnType:  "same as input",
+		Description: "Returns the first of its arguments that is not null. Null is returned only if all arguments are null.",
+		Variadic:    true,
+	},
+	{
+		Name:        "nullif",
+		Params:      []string{"value1 any", "value2 any"},
+		ReturnType:  "same as input",
+		Description: "Returns a null value if value1 equals value2; otherwise it returns value1.",
+	},
+	{
+		Name:        "greatest",
+		Params:      []string{"value any"},
+		ReturnType:  "same as input",
+		Description: "Selects the largest value from a list of expressions, ignoring null values.",
+		Variadic:    true,
+	},
+	{
+		Name:        "least",
+		Params:      []string{"value any"},
+		ReturnType:  "same as input",
+		Description: "Selects the smallest value from a list of expressions, ignoring null values.",
+		Variadic:    true,
+	},
+	// math functions
+	{
+		Name:        "abs",
+		Params:      []string{"x numeric"},
+		ReturnType:  "same as input",
+		Description: "Returns the absolute value.",
+	},
+	{
+		Name:        "ceil",
+		Params:      []string{"x numeric"},
+		ReturnType:  "numeric",
+		Description: "Returns the nearest integer greater than or equal to the argument.",
+	},
+	{
+		Name:        "floor",
+		Params:      []string{"x numeric"},
+		ReturnType:  "numeric",
+		Description: "Returns the nearest integer less than or equal to the argument.",
+	},
+	{
+		Name:        "round",
+		Params:      []string{"v numeric", "s integer"},
+		ReturnType:  "numeric",
+		Description: "Rounds v to s decimal places.",
+	},
+	{
+		Name:        "random",
+		ReturnType:  "double precision",
+		Description: "Returns a random value in the range 0.0 <= x < 1.0.",
+	},
+	// string functions
+	{
+		Name:        "concat",
+		Params:      []string{"val any"},
+		ReturnType:  "text",
+		Description: "Concatenates the text representations of all the arguments. NULL arguments are ignored.",
+		Variadic:    true,
+	},
+	{
+		Name:        "length",
+		Params:      []string{"string text"},
+		ReturnType:  "integer",
+		Description: "Returns the number of characters in the string.",
+	},
+	{
+		Name:        "lower",
+		Params:      []string{"string text"},
+		ReturnType:  "text",
+		Description: "Converts the string to all lower case.",
+	},
+	{
+		Name:        "upper",
+		Params:      []string{"string text"},
+		ReturnType:  "text",
+		Description: "Converts the string to all upper case.",
+	},
+	{
+		Name:        "replace",
+		Params:      []string{"string text", "from text", "to text"},
+		ReturnType:  "text",
+		Description: "Replaces all occurrences in string of substring from with substring to.",
+	},
+	{
+		Name:        "split_part",
+		Params:      []string{"string text", "delimiter text", "n integer"},
+		ReturnType:  "text",
+		Description: "Splits string at occurrences of delimiter and returns the n'th field (counting from one).",
+	},
+	{
+		Name:        "substring",
+		Params:      []string{"string text", "start integer", "count integer"},
+		ReturnType:  "text",
+		Description: "Extracts the substring of string starting at the start'th character, and stopping after count characters.",
+	},
+	{
+		Name:        "trim",
+		Params:      []string{"string text"},
+		ReturnType:  "text",
+		Description: "Removes the longest string containing only spaces from the start and end of string.",
+	},
+	{
+		Name:        "regexp_replace",
+		Params:      []string{"string text", "pattern text", "replacement text", "flags text"},
+		ReturnType:  "text",
+		Description: "Replaces substrings resulting from the first match of a POSIX regular expression, or multiple substring matches if the g flag is used.",
+	},
+	{
+		Name:        "to_char",
+		Params:      []string{"value any", "format text"},
+		ReturnType:  "text",
+		Description: "Converts a time stamp, interval or number to string according to the given format.",
+	},
+	// date/time functions
+	{
+		Name:        "now",
+		ReturnType:  "timestamp with time zone",
+		Description: "Returns the current date and time (start of current transaction).",
+	},
+	{
+		Name:        "age",
+		Params:      []string{"timestamp1 timestamp", "timestamp2 timestamp"},
+		ReturnType:  "interval",
+		Description: "Subtracts arguments, producing a \"symbolic\" result that uses years and months, rather than just days.",
+	},
+	{
+		Name:        "date_part",
+		Params:      []string{"field text", "source timestamp"},
+		ReturnType:  "double precision",
+		Description: "Gets the subfield of the timestamp or interval; equivalent to extract.",
+	},
+	{
+		Name:        "date_trunc",
+		Params:      []string{"field text", "source timestamp"},
+		ReturnType:  "timestamp",
+		Description: "Truncates the timestamp or interval to the specified precision, such as year, month, day or hour.",
+	},
+	{
+		Name:        "to_timestamp",
+		Params:      []string{"text text", "format text"},
+		ReturnType:  "timestamp with time zone",
+		Description: "Converts a string to a time stamp according to the given format.",
+	},
+	{
+		Name:        "to_date",
+		Params:      []string{"text text", "format text"},
+		ReturnType:  "date",
+		Description: "Converts a string to a date according to the given format.",
+	},
+	{
+		Name:        "generate_series",
+		Params:      []string{"start integer", "stop integer", "step integer"},
+		ReturnType:  "setof integer",
+		Description: "Generates a series of values from start to stop, with a step size of step.",
+	},
+	// json functions
+	{
+		Name:        "json_build_object",
+		Params:      []string{"key text", "value any"},
+		ReturnType:  "json",
+		Description: "Builds a JSON object out of a variadic argument list. By convention, the argument list consists of alternating keys and values.",
+		Variadic:    true,
+	},
+	{
+		Name:        "jsonb_build_object",
+		Params:      []string{"key text", "value any"},
+		ReturnType:  "jsonb",
+		Description: "Builds a JSON object out of a variadic argument list. By convention, the argument list consists of alternating keys and values.",
+		Variadic:    true,
+	},
+	{
+		Name:        "jsonb_set",
+		Params:      []string{"target jsonb", "path text[]", "new_value jsonb", "create_if_missing boolean"},
+		ReturnType:  "jsonb",
+		Description: "Returns target with the item designated by path replaced by new_value, or with new_value added if create_if_missing is true and the item does not exist.",
+	},
+	{
+		Name:        "to_json",
+		Params:      []string{"value anyelement"},
+		ReturnType:  "json",
+		Description: "Converts any SQL value to json.",
+	},
+}
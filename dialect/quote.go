@@ -0,0 +1,72 @@
+package dialect
+
+import "strings"
+
+// reservedWords are the keywords which cannot be used as names without quotes, common to the databases
+var reservedWords = wordSet(
+	"ALL", "AND", "ANY", "AS", "ASC", "BETWEEN", "BY", "CASE", "CHECK", "COLLATE", "COLUMN", "CONSTRAINT", "CREATE", "CROSS",
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "EXCEPT",
+	"EXISTS", "FALSE", "FOR", "FOREIGN", "FROM", "GRANT", "GROUP", "HAVING", "IN", "INNER", "INSERT", "INTERSECT", "INTO",
+	"IS", "JOIN", "LEFT", "LIKE", "LIMIT", "NATURAL", "NOT", "NULL", "ON", "OR", "ORDER", "OUTER", "PRIMARY", "REFERENCES",
+	"RIGHT", "SELECT", "SET", "TABLE", "THEN", "TO", "TRUE", "UNION", "UNIQUE", "UPDATE", "USING", "VALUES", "WHEN", "WHERE",
+	"WITH",
+)
+
+var driverReservedWords = map[DatabaseDriver]map[string]bool{
+	DatabaseDriverMySQL: wordSet(
+		"ADD", "ALTER", "CHANGE", "CONDITION", "DATABASE", "DATABASES", "DIV", "DUAL", "FETCH", "FULLTEXT", "INDEX", "INTERVAL",
+		"KEY", "KEYS", "KILL", "LOAD", "LOCK", "MATCH", "MOD", "OPTION", "RANGE", "RANK", "READ", "RENAME", "REPLACE", "SCHEMA",
+		"SHOW", "SIGNAL", "SPATIAL", "USAGE", "USE", "WINDOW", "WRITE", "XOR",
+	),
+	DatabaseDriverPostgreSQL: wordSet(
+		"ANALYSE", "ANALYZE", "ARRAY", "ASYMMETRIC", "BOTH", "CAST", "CURRENT_ROLE", "CURRENT_USER", "DEFERRABLE", "DO", "END",
+		"FETCH", "INITIALLY", "LATERAL", "LEADING", "LOCALTIME", "LOCALTIMESTAMP", "OFFSET", "ONLY", "PLACING", "RETURNING",
+		"SESSION_USER", "SOME", "SYMMETRIC", "TRAILING", "USER", "VARIADIC", "WINDOW",
+	),
+	DatabaseDriverSQLite3: wordSet(
+		"ADD", "ALTER", "AUTOINCREMENT", "ESCAPE", "INDEX", "ISNULL", "NOTNULL", "OFFSET", "RAISE", "TRANSACTION",
+	),
+}
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// QuoteIdentifier quotes the name of a table or column when it cannot be written as is,
+// such as a reserved word, a name with spaces, or a name with upper case letters in PostgreSQL which folds unquoted names to lower case.
+func QuoteIdentifier(driver DatabaseDriver, name string) string {
+	if !needsQuote(driver, name) {
+		return name
+	}
+	if driver == DatabaseDriverMySQL {
+		return "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func needsQuote(driver DatabaseDriver, name string) bool {
+	if name == "" {
+		return true
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= 'A' && r <= 'Z':
+			if driver == DatabaseDriverPostgreSQL {
+				return true
+			}
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	upper := strings.ToUpper(name)
+	return reservedWords[upper] || driverReservedWords[driver][upper]
+}
@@ -0,0 +1,26 @@
+package dialect
+
+import "testing"
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driver DatabaseDriver
+		name   string
+		want   string
+	}{
+		{driver: DatabaseDriverMySQL, name: "CountryCode", want: "CountryCode"},
+		{driver: DatabaseDriverMySQL, name: "order", want: "`order`"},
+		{driver: DatabaseDriverMySQL, name: "first name", want: "`first name`"},
+		{driver: DatabaseDriverMySQL, name: "a`b", want: "`a``b`"},
+		{driver: DatabaseDriverPostgreSQL, name: "country_code", want: "country_code"},
+		{driver: DatabaseDriverPostgreSQL, name: "CountryCode", want: `"CountryCode"`},
+		{driver: DatabaseDriverPostgreSQL, name: "user", want: `"user"`},
+		{driver: DatabaseDriverSQLite3, name: "2nd", want: `"2nd"`},
+		{driver: DatabaseDriverSQLite3, name: `a"b`, want: `"a""b"`},
+	}
+	for _, tt := range tests {
+		if got := QuoteIdentifier(tt.driver, tt.name); got != tt.want {
+			t.Errorf("QuoteIdentifier(%s, %q) = %s, want %s", tt.driver, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package dialect
+
+var sqliteFunctions = []*Function{
+	// aggregate functions
+	{
+		Name:        "avg",
+		Params:      []string{"X"},
+		ReturnType:  "REAL",
+		Description: "Returns the average value of all non-NULL X within a group.",
+	},
+	{
+		Name:        "count",
+		Params:      []string{"X"},
+		ReturnType:  "INTEGER",
+		Description: "Returns a count of the number of times that X is not NULL in a group. count(*) returns the total number of rows in the group.",
+	},
+	{
+		Name:        "group_concat",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "TEXT",
+		Description: "Returns a string which is the concatenation of all non-NULL values of X. If parameter Y is present then it is used as the separator between instances of X.",
+	},
+	{
+		Name:        "max",
+		Params:      []string{"X"},
+		ReturnType:  "same as input",
+		Description: "Returns the maximum value of all values in the group.",
+	},
+	{
+		Name:        "min",
+		Params:      []string{"X"},
+		ReturnType:  "same as input",
+		Description: "Returns the minimum non-NULL value of all values in the group.",
+	},
+	{
+		Name:        "sum",
+		Params:      []string{"X"},
+		ReturnType:  "INTEGER or REAL",
+		Description: "Returns the sum of all non-NULL values in the group. If there are no non-NULL input rows then sum() returns NULL.",
+	},
+	{
+		Name:        "total",
+		Params:      []string{"X"},
+		ReturnType:  "REAL",
+		Description: "Returns the sum of all non-NULL values in the group as a floating point value. If there are no non-NULL input rows then total() returns 0.0.",
+	},
+	// window functions
+	{
+		Name:        "row_number",
+		ReturnType:  "INTEGER",
+		Description: "The number of the row within the current partition. Rows are numbered starting from 1.",
+	},
+	{
+		Name:        "rank",
+		ReturnType:  "INTEGER",
+		Description: "The row_number() of the first peer in each group - the rank of the current row with gaps.",
+	},
+	{
+		Name:        "dense_rank",
+		ReturnType:  "INTEGER",
+		Description: "The number of the current row's peer group within its partition - the rank of the current row without gaps.",
+	},
+	{
+		Name:        "ntile",
+		Params:      []string{"N"},
+		ReturnType:  "INTEGER",
+		Description: "Divides the partition into N groups as evenly as possible and assigns an integer between 1 and N to each group.",
+	},
+	{
+		Name:        "lag",
+		Params:      []string{"expr", "offset", "default"},
+		ReturnType:  "same as input",
+		Description: "Returns the result of evaluating expression expr against the row offset rows before the current row within the partition. If there is no such row, default is returned.",
+	},
+	{
+		Name:        "lead",
+		Params:      []string{"expr", "offset", "default"},
+		ReturnType:  "same as input",
+		Description: "Returns the result of evaluating expression expr against the row offset rows after the current row within the partition. If there is no such row, default is returned.",
+	},
+	{
+		Name:        "first_value",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Calculates the window frame for each row and returns the value of expr evaluated against the first row in the window frame.",
+	},
+	{
+		Name:        "last_value",
+		Params:      []string{"expr"},
+		ReturnType:  "same as input",
+		Description: "Calculates the window frame for each row and returns the value of expr evaluated against the last row in the window frame.",
+	},
+	// core functions
+	{
+		Name:        "abs",
+		Params:      []string{"X"},
+		ReturnType:  "same as input",
+		Description: "Returns the absolute value of the numeric argument X.",
+	},
+	{
+		Name:        "coalesce",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "same as input",
+		Description: "Returns a copy of its first non-NULL argument, or NULL if all arguments are NULL. Coalesce() must have at least 2 arguments.",
+		Variadic:    true,
+	},
+	{
+		Name:        "ifnull",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "same as input",
+		Description: "Returns a copy of its first non-NULL argument, or NULL if both arguments are NULL.",
+	},
+	{
+		Name:        "iif",
+		Params:      []string{"X", "Y", "Z"},
+		ReturnType:  "same as input",
+		Description: "Returns the value Y if X is true, and Z otherwise.",
+	},
+	{
+		Name:        "instr",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "INTEGER",
+		Description: "Finds the first occurrence of string Y within string X and returns the number of prior characters plus 1, or 0 if Y is nowhere found within X.",
+	},
+	{
+		Name:        "length",
+		Params:      []string{"X"},
+		ReturnType:  "INTEGER",
+		Description: "For a string value X, returns the number of characters (not bytes) in X prior to the first NUL character.",
+	},
+	{
+		Name:        "lower",
+		Params:      []string{"X"},
+		ReturnType:  "TEXT",
+		Description: "Returns a copy of string X with all ASCII characters converted to lower case.",
+	},
+	{
+		Name:        "upper",
+		Params:      []string{"X"},
+		ReturnType:  "TEXT",
+		Description: "Returns a copy of string X with all ASCII characters converted to upper case.",
+	},
+	{
+		Name:        "nullif",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "same as input",
+		Description: "Returns its first argument if the arguments are different and NULL if the arguments are the same.",
+	},
+	{
+		Name:        "printf",
+		Params:      []string{"FORMAT"},
+		ReturnType:  "TEXT",
+		Description: "Works like the printf() function from the standard C library. The first argument is a format string that specifies how to construct the output string.",
+		Variadic:    true,
+	},
+	{
+		Name:        "random",
+		ReturnType:  "INTEGER",
+		Description: "Returns a pseudo-random integer between -9223372036854775808 and +9223372036854775807.",
+	},
+	{
+		Name:        "replace",
+		Params:      []string{"X", "Y", "Z"},
+		ReturnType:  "TEXT",
+		Description: "Returns a string formed by substituting string Z for every occurrence of string Y in string X.",
+	},
+	{
+		Name:        "round",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "REAL",
+		Description: "Returns a floating-point value X rounded to Y digits to the right of the decimal point.",
+	},
+	{
+		Name:        "substr",
+		Params:      []string{"X", "Y", "Z"},
+		ReturnType:  "TEXT",
+		Description: "Returns a substring of input string X that begins with the Y-th character and which is Z characters long.",
+	},
+	{
+		Name:        "trim",
+		Params:      []string{"X", "Y"},
+		ReturnType:  "TEXT",
+		Description: "Returns a string formed by removing any and all characters that appear in Y from both ends of X. If the Y argument is omitted, spaces are removed.",
+	},
+	{
+		Name:        "typeof",
+		Params:      []string{"X"},
+		ReturnType:  "TEXT",
+		Description: "Returns a string that indicates the datatype of the expression X: \"null\", \"integer\", \"real\", \"text\", or \"blob\".",
+	},
+	// date and time functions
+	{
+		Name:        "date",
+		Params:      []string{"time-value", "modifier"},
+		ReturnType:  "TEXT",
+		Description: "Returns the date in this format: YYYY-MM-DD.",
+		Variadic:    true,
+	},
+	{
+		Name:        "time",
+		Params:      []string{"time-value", "modifier"},
+		ReturnType:  "TEXT",
+		Description: "Returns the time as HH:MM:SS.",
+		Variadic:    true,
+	},
+	{
+		Name:        "datetime",
+		Params:      []string{"time-value", "modifier"},
+		ReturnType:  "TEXT",
+		Description: "Returns the date and time as YYYY-MM-DD HH:MM:SS.",
+		Variadic:    true,
+	},
+	{
+		Name:        "julianday",
+		Params:      []string{"time-value", "modifier"},
+		ReturnType:  "REAL",
+		Description: "Returns the Julian day - the number of days since noon in Greenwich on November 24, 4714 B.C.",
+		Variadic:    true,
+	},
+	{
+		Name:        "strftime",
+		Params:      []string{"format", "time-value", "modifier"},
+		ReturnType:  "TEXT",
+		Description: "Returns the date formatted according to the format string specified as the first argument.",
+		Variadic:    true,
+	},
+	// json functions
+	{
+		Name:        "json",
+		Params:      []string{"json"},
+		ReturnType:  "TEXT",
+		Description: "Verifies that its argument is a valid JSON string and returns a minified version of that JSON string.",
+	},
+	{
+		Name:        "json_extract",
+		Params:      []string{"json", "path"},
+		ReturnType:  "same as selected value",
+		Description: "Extracts and returns one or more values from the well-formed JSON.",
+		Variadic:    true,
+	},
+	{
+		Name:        "json_object",
+		Params:      []string{"label", "value"},
+		ReturnType:  "TEXT",
+		Description: "Accepts zero or more pairs of arguments and returns a well-formed JSON object that is composed from those arguments.",
+		Variadic:    true,
+	},
+	{
+		Name:        "json_array",
+		Params:      []string{"value"},
+		ReturnType:  "TEXT",
+		Description: "Accepts zero or more arguments and returns a well-formed JSON array that is composed from those arguments.",
+		Variadic:    true,
+	},
+}
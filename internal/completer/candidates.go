@@ -3,6 +3,7 @@ package completer
 import (
 	"strings"
 
+	"github.com/lighttiger2505/sqls/dialect"
 	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/lsp"
 	"github.com/lighttiger2505/sqls/parser/parseutil"
@@ -46,11 +47,18 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 		}
 	case ParentTypeSchema:
 	case ParentTypeTable:
+		if parent.Schema != "" {
+			// schema.table.column
+			if columns, ok := c.DBCache.ColumnDatabase(parent.Schema, parent.Name); ok {
+				candidates = append(candidates, generateColumnCandidates(parent.Name, columns)...)
+			}
+			break
+		}
 		for _, table := range targetTables {
 			if table.Name != parent.Name && table.Alias != parent.Name {
 				continue
 			}
-			columns, ok := c.DBCache.ColumnDescs(table.Name)
+			columns, ok := c.DBCache.ColumnDescsBySchema(table.DatabaseSchema, table.Name)
 			if !ok {
 				continue
 			}
@@ -93,7 +101,11 @@ func (c *Completer) ReferencedTableCandidates(targetTables []*parseutil.TableInf
 
 	for _, targetTable := range targetTables {
 		includeTables := []*parseutil.TableInfo{}
-		for _, table := range c.DBCache.SortedTables() {
+		tables := c.DBCache.SortedTables()
+		if targetTable.DatabaseSchema != "" {
+			tables, _ = c.DBCache.SortedTablesByDBName(targetTable.DatabaseSchema)
+		}
+		for _, table := range tables {
 			if table == targetTable.Name {
 				includeTables = append(includeTables, targetTable)
 			}
@@ -122,21 +134,22 @@ func (c *Completer) TableCandidates(parent *completionParent, targetTables []*pa
 			}
 			excludeTables = append(excludeTables, table)
 		}
-		candidates = append(candidates, generateTableCandidates(excludeTables, c.DBCache)...)
+		candidates = append(candidates, generateTableCandidates("", excludeTables, c.DBCache)...)
 	case ParentTypeSchema:
 		tables, ok := c.DBCache.SortedTablesByDBName(parent.Name)
 		if ok {
-			candidates = append(candidates, generateTableCandidates(tables, c.DBCache)...)
+			candidates = append(candidates, generateTableCandidates(parent.Name, tables, c.DBCache)...)
 		} else {
 			tables := c.DBCache.SortedTables()
-			candidates = append(candidates, generateTableCandidates(tables, c.DBCache)...)
+			candidates = append(candidates, generateTableCandidates("", tables, c.DBCache)...)
 		}
 	case ParentTypeTable:
 	}
 	return candidates
 }
 
-func generateTableCandidates(tables []string, dbCache *database.DBCache) []lsp.CompletionItem {
+// generateTableCandidates generates the tables of the schema, the search path is used if schemaName is empty.
+func generateTableCandidates(schemaName string, tables []string, dbCache *database.DBCache) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, tableName := range tables {
 		candidate := lsp.CompletionItem{
@@ -144,11 +157,12 @@ func generateTableCandidates(tables []string, dbCache *database.DBCache) []lsp.C
 			Kind:   lsp.FieldCompletion,
 			Detail: "table",
 		}
-		cols, ok := dbCache.ColumnDescs(tableName)
+		table := &parseutil.TableInfo{DatabaseSchema: schemaName, Name: tableName}
+		cols, ok := dbCache.ColumnDescsBySchema(schemaName, tableName)
 		if ok {
 			candidate.Documentation = lsp.MarkupContent{
 				Kind:  lsp.Markdown,
-				Value: database.TableDoc(tableName, cols),
+				Value: database.TableDoc(tableName, tableComment(table, dbCache), cols),
 			}
 		}
 		candidates = append(candidates, candidate)
@@ -170,11 +184,11 @@ func generateTableCandidatesByInfos(tables []*parseutil.TableInfo, dbCache *data
 			Kind:   lsp.FieldCompletion,
 			Detail: detail,
 		}
-		cols, ok := dbCache.ColumnDescs(table.Name)
+		cols, ok := dbCache.ColumnDescsBySchema(table.DatabaseSchema, table.Name)
 		if ok {
 			candidate.Documentation = lsp.MarkupContent{
 				Kind:  lsp.Markdown,
-				Value: database.TableDoc(table.Name, cols),
+				Value: database.TableDoc(table.Name, tableComment(table, dbCache), cols),
 			}
 		}
 		candidates = append(candidates, candidate)
@@ -182,6 +196,13 @@ func generateTableCandidatesByInfos(tables []*parseutil.TableInfo, dbCache *data
 	return candidates
 }
 
+func tableComment(table *parseutil.TableInfo, dbCache *database.DBCache) string {
+	if table.DatabaseSchema != "" {
+		return dbCache.TableCommentDatabase(table.DatabaseSchema, table.Name)
+	}
+	return dbCache.TableComment(table.Name)
+}
+
 func (c *Completer) SubQueryCandidates(infos []*parseutil.SubQueryInfo) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, info := range infos {
@@ -205,7 +226,7 @@ func (c *Completer) SubQueryColumnCandidates(infos []*parseutil.SubQueryInfo) []
 		for _, view := range info.Views {
 			for _, col := range view.SubQueryColumns {
 				if col.ColumnName == "*" {
-					tableCols, ok := c.DBCache.ColumnDescs(col.ParentTable.Name)
+					tableCols, ok := c.DBCache.ColumnDescsBySchema(col.ParentTable.DatabaseSchema, col.ParentTable.Name)
 					if !ok {
 						continue
 					}
@@ -263,3 +284,45 @@ func (c *Completer) SchemaCandidates() []lsp.CompletionItem {
 	}
 	return candidates
 }
+
+func (c *Completer) FunctionCandidates(parent *completionParent) []lsp.CompletionItem {
+	var funcs []*database.FunctionDesc
+	switch parent.Type {
+	case ParentTypeNone:
+		funcs = c.DBCache.SortedFunctions()
+	case ParentTypeSchema, ParentTypeTable:
+		funcs, _ = c.DBCache.SortedFunctionsBySchema(parent.Name)
+	}
+
+	candidates := []lsp.CompletionItem{}
+	for _, fd := range funcs {
+		candidate := lsp.CompletionItem{
+			Label:  fd.Name,
+			Kind:   lsp.FunctionCompletion,
+			Detail: strings.ToLower(fd.Kind),
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: database.FunctionDoc(fd),
+			},
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func (c *Completer) builtinFunctionCandidates() []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, f := range dialect.DataBaseFunctions(c.Driver) {
+		candidate := lsp.CompletionItem{
+			Label:  f.Name,
+			Kind:   lsp.FunctionCompletion,
+			Detail: "built-in function",
+			Documentation: lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: database.BuiltinFunctionDoc(f),
+			},
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
@@ -62,6 +62,8 @@ func (ct completionType) String() string {
 type Completer struct {
 	DBCache *database.DBCache
 	Driver  dialect.DatabaseDriver
+	// Version is the server version, the newest keywords of the driver are used if empty
+	Version string
 }
 
 func NewCompleter(dbCache *database.DBCache) *Completer {
@@ -153,10 +155,17 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 			}
 			items = append(items, candidates...)
 		}
+		if completionTypeIs(ctx.types, CompletionTypeFunction) {
+			items = append(items, c.FunctionCandidates(ctx.parent)...)
+		}
+	}
+
+	if completionTypeIs(ctx.types, CompletionTypeFunction) && ctx.parent.Type == ParentTypeNone {
+		items = append(items, c.builtinFunctionCandidates()...)
 	}
 
 	if completionTypeIs(ctx.types, CompletionTypeKeyword) {
-		drivers := dialect.DataBaseKeywords(c.Driver)
+		drivers := dialect.DataBaseKeywordsByVersion(c.Driver, c.Version)
 		items = append(items, c.keywordCandidates(lowercaseKeywords, drivers)...)
 	}
 
@@ -178,6 +187,8 @@ const (
 type completionParent struct {
 	Type ParentType
 	Name string
+	// Schema is set for schema.table.column
+	Schema string
 }
 
 var noneParent = &completionParent{Type: ParentTypeNone}
@@ -207,8 +218,9 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeFunction,
 			}
 			p = &completionParent{
-				Type: ParentTypeTable,
-				Name: mi.Parent.String(),
+				Type:   ParentTypeTable,
+				Schema: mi.SchemaName(),
+				Name:   mi.Parent.String(),
 			}
 		} else {
 			t = []completionType{
@@ -236,8 +248,9 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeFunction,
 			}
 			p = &completionParent{
-				Type: ParentTypeTable,
-				Name: mi.ParentTok.NoQuateString(),
+				Type:   ParentTypeTable,
+				Schema: mi.SchemaName(),
+				Name:   mi.ParentTok.NoQuateString(),
 			}
 		} else {
 			t = []completionType{
@@ -286,8 +299,9 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				CompletionTypeFunction,
 			}
 			p = &completionParent{
-				Type: ParentTypeTable,
-				Name: mi.ParentTok.NoQuateString(),
+				Type:   ParentTypeTable,
+				Schema: mi.SchemaName(),
+				Name:   mi.ParentTok.NoQuateString(),
 			}
 		} else {
 			t = []completionType{
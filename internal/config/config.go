@@ -1,10 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/lighttiger2505/sqls/internal/database"
 	"golang.org/x/xerrors"
@@ -19,9 +21,179 @@ var (
 	ymlConfigPath = configFilePath("config.yml")
 )
 
+// ProjectConfigFileName is the project-local config, looked up from the document directory towards the workspace root.
+const ProjectConfigFileName = ".sqls.yml"
+
 type Config struct {
 	LowercaseKeywords bool                 `json:"lowercaseKeywords" yaml:"lowercaseKeywords"`
 	Connections       []*database.DBConfig `json:"connections" yaml:"connections"`
+	// TrustedProjects is the directories whose project configs may run passwdCommand and passPhraseCommand, read from the user config only
+	TrustedProjects []string `json:"trustedProjects" yaml:"trustedProjects"`
+
+	// lowercaseKeywordsSet tells an explicit false from a missing key when merging
+	lowercaseKeywordsSet bool
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	var keys map[string]interface{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	_, c.lowercaseKeywordsSet = keys["lowercaseKeywords"]
+	return nil
+}
+
+func (c *Config) UnmarshalJSON(b []byte) error {
+	type plain Config
+	if err := json.Unmarshal(b, (*plain)(c)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return err
+	}
+	_, c.lowercaseKeywordsSet = keys["lowercaseKeywords"]
+	return nil
+}
+
+// Merge merges the configs given in ascending order of precedence, nil configs are skipped.
+// Connections of a higher precedence come first, and hide the connections of the same alias.
+func Merge(cfgs ...*Config) *Config {
+	merged := NewConfig()
+	aliases := map[string]bool{}
+	for i := len(cfgs) - 1; i >= 0; i-- {
+		if cfgs[i] == nil {
+			continue
+		}
+		for _, conn := range cfgs[i].Connections {
+			if conn.Alias != "" {
+				if aliases[conn.Alias] {
+					continue
+				}
+				aliases[conn.Alias] = true
+			}
+			merged.Connections = append(merged.Connections, conn)
+		}
+	}
+	for _, cfg := range cfgs {
+		if cfg == nil {
+			continue
+		}
+		if cfg.lowercaseKeywordsSet || cfg.LowercaseKeywords {
+			merged.LowercaseKeywords = cfg.LowercaseKeywords
+		}
+	}
+	return merged
+}
+
+// FindProjectConfig looks for the project config from dir up to root, or up to the file system root if dir is outside of root.
+func FindProjectConfig(dir, root string) (string, bool) {
+	dir = filepath.Clean(dir)
+	if root != "" {
+		root = filepath.Clean(root)
+	}
+	for {
+		fp := filepath.Join(dir, ProjectConfigFileName)
+		if info, err := os.Stat(fp); err == nil && !info.IsDir() {
+			return fp, true
+		}
+		if dir == root {
+			return "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// IsTrustedProject reports whether the project config fp is in one of the trusted directories or their sub directories
+func (c *Config) IsTrustedProject(fp string) bool {
+	if c == nil {
+		return false
+	}
+	for _, trusted := range c.TrustedProjects {
+		trusted, err := expand(trusted)
+		if err != nil || !filepath.IsAbs(trusted) {
+			continue
+		}
+		if InDirectory(trusted, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// InDirectory reports whether the path is in dir or its sub directories
+func InDirectory(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// HasSecretCommands reports whether a connection runs passwdCommand or passPhraseCommand
+func (c *Config) HasSecretCommands() bool {
+	for _, conn := range c.Connections {
+		if conn != nil && (conn.PasswdCommand != "" || hasPassPhraseCommand(conn.SSHCfg)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPassPhraseCommand(cfg *database.SSHConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	if cfg.PassPhraseCommand != "" {
+		return true
+	}
+	for _, hop := range cfg.ProxyJump {
+		if hasPassPhraseCommand(hop) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutSecretCommands returns a copy of the config whose connections run neither passwdCommand nor passPhraseCommand.
+// The config itself is returned when it has no command.
+func (c *Config) WithoutSecretCommands() *Config {
+	if !c.HasSecretCommands() {
+		return c
+	}
+	stripped := *c
+	stripped.Connections = make([]*database.DBConfig, len(c.Connections))
+	for i, conn := range c.Connections {
+		if conn == nil {
+			continue
+		}
+		copied := *conn
+		copied.PasswdCommand = ""
+		copied.SSHCfg = withoutPassPhraseCommand(conn.SSHCfg)
+		stripped.Connections[i] = &copied
+	}
+	return &stripped
+}
+
+func withoutPassPhraseCommand(cfg *database.SSHConfig) *database.SSHConfig {
+	if cfg == nil {
+		return nil
+	}
+	copied := *cfg
+	copied.PassPhraseCommand = ""
+	copied.ProxyJump = make([]*database.SSHConfig, len(cfg.ProxyJump))
+	for i, hop := range cfg.ProxyJump {
+		copied.ProxyJump[i] = withoutPassPhraseCommand(hop)
+	}
+	return &copied
 }
 
 func NewConfig() *Config {
@@ -30,6 +202,11 @@ func NewConfig() *Config {
 	return cfg
 }
 
+// DefaultConfigPath is the path of the user config
+func DefaultConfigPath() string {
+	return ymlConfigPath
+}
+
 func GetDefaultConfig() (*Config, error) {
 	cfg := NewConfig()
 	if err := cfg.Load(ymlConfigPath); err != nil {
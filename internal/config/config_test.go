@@ -0,0 +1,227 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func aliases(cfg *Config) []string {
+	got := []string{}
+	for _, conn := range cfg.Connections {
+		got = append(got, conn.Alias)
+	}
+	return got
+}
+
+func TestMerge(t *testing.T) {
+	unmarshalYAML := func(s string) *Config {
+		cfg := NewConfig()
+		if err := yaml.Unmarshal([]byte(s), cfg); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	unmarshalJSON := func(s string) *Config {
+		cfg := NewConfig()
+		if err := json.Unmarshal([]byte(s), cfg); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name          string
+		cfgs          []*Config
+		wantAliases   []string
+		wantLowercase bool
+	}{
+		{
+			name:        "empty",
+			cfgs:        []*Config{nil, nil, nil},
+			wantAliases: []string{},
+		},
+		{
+			name: "higher precedence first",
+			cfgs: []*Config{
+				unmarshalYAML("connections:\n  - alias: user\n    driver: mysql\n"),
+				unmarshalYAML("connections:\n  - alias: project\n    driver: mysql\n"),
+				unmarshalJSON(`{"connections": [{"alias": "editor", "driver": "mysql"}]}`),
+			},
+			wantAliases: []string{"editor", "project", "user"},
+		},
+		{
+			name: "same alias is hidden",
+			cfgs: []*Config{
+				unmarshalYAML("connections:\n  - alias: dev\n    driver: mysql\n  - driver: sqlite3\n"),
+				unmarshalYAML("connections:\n  - alias: dev\n    driver: postgresql\n  - driver: sqlite3\n"),
+				nil,
+			},
+			wantAliases: []string{"dev", "", ""},
+		},
+		{
+			name: "lowercase keywords from user",
+			cfgs: []*Config{
+				unmarshalYAML("lowercaseKeywords: true\n"),
+				unmarshalYAML("connections: []\n"),
+				unmarshalJSON(`{}`),
+			},
+			wantAliases:   []string{},
+			wantLowercase: true,
+		},
+		{
+			name: "explicit false overrides",
+			cfgs: []*Config{
+				unmarshalYAML("lowercaseKeywords: true\n"),
+				unmarshalYAML("lowercaseKeywords: false\n"),
+				nil,
+			},
+			wantAliases:   []string{},
+			wantLowercase: false,
+		},
+		{
+			name: "explicit false in editor settings overrides",
+			cfgs: []*Config{
+				unmarshalYAML("lowercaseKeywords: true\n"),
+				nil,
+				unmarshalJSON(`{"lowercaseKeywords": false}`),
+			},
+			wantAliases:   []string{},
+			wantLowercase: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.cfgs...)
+			gotAliases := aliases(got)
+			if len(gotAliases) != len(tt.wantAliases) {
+				t.Fatalf("got %v, want %v", gotAliases, tt.wantAliases)
+			}
+			for i := range gotAliases {
+				if gotAliases[i] != tt.wantAliases[i] {
+					t.Errorf("got %v, want %v", gotAliases, tt.wantAliases)
+				}
+			}
+			if got.LowercaseKeywords != tt.wantLowercase {
+				t.Errorf("LowercaseKeywords got %v, want %v", got.LowercaseKeywords, tt.wantLowercase)
+			}
+		})
+	}
+
+	// the driver of the hidden connection is not used
+	merged := Merge(tests[2].cfgs...)
+	if merged.Connections[0].Driver != "postgresql" {
+		t.Errorf("got driver %s, want postgresql", merged.Connections[0].Driver)
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "repo")
+	sub := filepath.Join(root, "services", "billing")
+	if err := os.MkdirAll(filepath.Join(sub, "queries"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{dir, root, sub} {
+		if err := ioutil.WriteFile(filepath.Join(d, ProjectConfigFileName), []byte("connections: []\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		root   string
+		want   string
+		wantOK bool
+	}{
+		{name: "nearest", dir: filepath.Join(sub, "queries"), root: root, want: filepath.Join(sub, ProjectConfigFileName), wantOK: true},
+		{name: "workspace root", dir: filepath.Join(root, "empty"), root: root, want: filepath.Join(root, ProjectConfigFileName), wantOK: true},
+		{name: "not above root", dir: filepath.Join(root, "empty"), root: filepath.Join(root, "empty"), wantOK: false},
+		{name: "without root", dir: filepath.Join(dir, "repo", "empty"), root: "", want: filepath.Join(root, ProjectConfigFileName), wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FindProjectConfig(tt.dir, tt.root)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %q %v, want %q %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsTrustedProject(t *testing.T) {
+	cfg := &Config{TrustedProjects: []string{"/home/user/work", "relative"}}
+	tests := []struct {
+		name string
+		fp   string
+		want bool
+	}{
+		{name: "trusted directory", fp: "/home/user/work/.sqls.yml", want: true},
+		{name: "sub directory", fp: "/home/user/work/repo/.sqls.yml", want: true},
+		{name: "parent directory", fp: "/home/user/.sqls.yml", want: false},
+		{name: "same prefix", fp: "/home/user/workspace/.sqls.yml", want: false},
+		{name: "relative path is ignored", fp: "relative/.sqls.yml", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.IsTrustedProject(filepath.FromSlash(tt.fp)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	var nilCfg *Config
+	if nilCfg.IsTrustedProject("/home/user/work/.sqls.yml") {
+		t.Error("trusted without user config")
+	}
+}
+
+func TestWithoutSecretCommands(t *testing.T) {
+	b := []byte(`connections:
+  - alias: local
+    driver: mock
+    passwdCommand: pass show local
+  - alias: remote
+    driver: mock
+    sshConfig:
+      host: bastion
+      passPhraseCommand: pass show ssh
+      proxyJump:
+        - host: jump
+          passPhraseCommand: pass show jump
+`)
+	cfg := NewConfig()
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.HasSecretCommands() {
+		t.Fatal("commands are not found")
+	}
+	stripped := cfg.WithoutSecretCommands()
+	if stripped.HasSecretCommands() {
+		t.Error("commands are left")
+	}
+	if cfg.Connections[0].PasswdCommand == "" || cfg.Connections[1].SSHCfg.ProxyJump[0].PassPhraseCommand == "" {
+		t.Error("the original config is modified")
+	}
+	if stripped.Connections[1].SSHCfg.Host != "bastion" || stripped.Connections[1].SSHCfg.ProxyJump[0].Host != "jump" {
+		t.Errorf("the other settings are lost, %+v", stripped.Connections[1].SSHCfg)
+	}
+
+	plain := &Config{}
+	if plain.WithoutSecretCommands() != plain {
+		t.Error("a config without commands is copied")
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/lighttiger2505/sqls/dialect"
+	"github.com/lighttiger2505/sqls/internal/database"
+)
+
+// ValidationError is a problem found in a config file. Line and Column are 1-based, and 0 when the position is unknown.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidationErrors is the errors of a config file sorted by line
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var (
+	yamlSyntaxErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	yamlTypeErrorPattern   = regexp.MustCompile(`^line (\d+): (.*)$`)
+	yamlUnknownKeyPattern  = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// Check parses the YAML config strictly and validates it.
+// The config is returned unless the YAML is malformed, even if it has errors.
+func Check(b []byte) (*Config, ValidationErrors) {
+	cfg := NewConfig()
+	var errs ValidationErrors
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return nil, ValidationErrors{parseYAMLError(yamlSyntaxErrorPattern, err.Error())}
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, parseYAMLError(yamlTypeErrorPattern, msg))
+		}
+	}
+
+	// the positions are read from the nodes, the config decoded above has none
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(b, &root); err != nil {
+		root = yaml3.Node{}
+	}
+	for _, fe := range cfg.validate() {
+		line, column := locate(&root, fe.path)
+		errs = append(errs, &ValidationError{
+			Line:    line,
+			Column:  column,
+			Message: fe.message,
+		})
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	return cfg, errs
+}
+
+// CheckFile reads the config file and validates it
+func CheckFile(fp string) (*Config, ValidationErrors, error) {
+	expandPath, err := expand(fp)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadFile(expandPath)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("cannot read config, %+v", err)
+	}
+	cfg, errs := Check(b)
+	return cfg, errs, nil
+}
+
+func parseYAMLError(pattern *regexp.Regexp, msg string) *ValidationError {
+	m := pattern.FindStringSubmatch(msg)
+	if m == nil {
+		return &ValidationError{Message: msg}
+	}
+	line, _ := strconv.Atoi(m[1])
+	msg = m[2]
+	if m := yamlUnknownKeyPattern.FindStringSubmatch(msg); m != nil {
+		msg = fmt.Sprintf("unknown key %q", m[1])
+	}
+	return &ValidationError{Line: line, Message: msg}
+}
+
+// fieldError is a problem of the value at path, which is made of map keys and sequence indexes
+type fieldError struct {
+	path    []interface{}
+	message string
+}
+
+func (c *Config) validate() []*fieldError {
+	var errs []*fieldError
+	aliases := map[string]bool{}
+	for i, conn := range c.Connections {
+		path := []interface{}{"connections", i}
+		if conn == nil {
+			errs = append(errs, &fieldError{path, "empty connection"})
+			continue
+		}
+		if conn.Alias != "" {
+			if aliases[conn.Alias] {
+				errs = append(errs, &fieldError{appendPath(path, "alias"), fmt.Sprintf("duplicate alias %q", conn.Alias)})
+			}
+			aliases[conn.Alias] = true
+		}
+		errs = append(errs, validateDBConfig(path, conn)...)
+	}
+	return errs
+}
+
+func validateDBConfig(path []interface{}, cfg *database.DBConfig) []*fieldError {
+	var errs []*fieldError
+	add := func(key string, format string, args ...interface{}) {
+		p := path
+		if key != "" {
+			p = appendPath(path, key)
+		}
+		errs = append(errs, &fieldError{p, fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case cfg.Driver == "":
+		add("", "driver is required")
+	case !database.Registered(cfg.Driver):
+		add("driver", "unknown driver %q, available drivers are %s", cfg.Driver, driverNames())
+	}
+
+	if cfg.DataSourceName == "" {
+		switch cfg.Driver {
+		case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverPostgreSQL:
+			errs = append(errs, validateProto(path, cfg)...)
+		case dialect.DatabaseDriverSQLite3:
+			add("", "dataSourceName is required for %s", cfg.Driver)
+		}
+	} else if cfg.SSHCfg != nil && !database.TunnelsDSN(cfg.Driver) {
+		add("sshConfig", "sshConfig cannot be used with dataSourceName of %s", cfg.Driver)
+	}
+
+	if !validPort(cfg.Port) {
+		add("port", "port %d is out of range 0-65535", cfg.Port)
+	}
+	if cfg.ColumnCacheSize < 0 {
+		add("columnCacheSize", "columnCacheSize must not be negative")
+	}
+	if cfg.TLS != nil {
+		errs = append(errs, validateTLSConfig(appendPath(path, "tls"), cfg.TLS)...)
+	}
+	if cfg.SSHCfg != nil {
+		errs = append(errs, validateSSHConfig(appendPath(path, "sshConfig"), cfg.SSHCfg)...)
+	}
+	return errs
+}
+
+func validateProto(path []interface{}, cfg *database.DBConfig) []*fieldError {
+	protos := []database.Proto{database.ProtoTCP, database.ProtoUnix}
+	if cfg.Driver == dialect.DatabaseDriverMySQL {
+		protos = append(protos, database.ProtoUDP)
+	}
+	if cfg.Proto == "" {
+		return []*fieldError{{path, fmt.Sprintf("proto or dataSourceName is required for %s", cfg.Driver)}}
+	}
+	for _, proto := range protos {
+		if cfg.Proto != proto {
+			continue
+		}
+		if proto == database.ProtoUnix && cfg.Path == "" && cfg.Driver == dialect.DatabaseDriverPostgreSQL {
+			return []*fieldError{{path, "path of the socket directory is required for unix"}}
+		}
+		return nil
+	}
+	return []*fieldError{{appendPath(path, "proto"), fmt.Sprintf("unsupported proto %q for %s", cfg.Proto, cfg.Driver)}}
+}
+
+func validateTLSConfig(path []interface{}, cfg *database.TLSConfig) []*fieldError {
+	var errs []*fieldError
+	switch cfg.Mode {
+	case "", database.TLSModeDisable, database.TLSModePreferred, database.TLSModeRequire, database.TLSModeVerifyCA, database.TLSModeVerifyFull:
+	default:
+		errs = append(errs, &fieldError{appendPath(path, "mode"), fmt.Sprintf("unknown tls mode %q", cfg.Mode)})
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		errs = append(errs, &fieldError{path, "certFile and keyFile must be set together"})
+	}
+	if cfg.Mode == database.TLSModePreferred && (cfg.CAFile != "" || cfg.CertFile != "") {
+		errs = append(errs, &fieldError{appendPath(path, "mode"), "tls mode preferred cannot be used with caFile or certFile, use require or verify-ca"})
+	}
+	return errs
+}
+
+func validateSSHConfig(path []interface{}, cfg *database.SSHConfig) []*fieldError {
+	var errs []*fieldError
+	if cfg.Host == "" {
+		errs = append(errs, &fieldError{path, "host is required for sshConfig"})
+	}
+	if !validPort(cfg.Port) {
+		errs = append(errs, &fieldError{appendPath(path, "port"), fmt.Sprintf("port %d is out of range 0-65535", cfg.Port)})
+	}
+	if cfg.KeepAliveInterval < 0 {
+		errs = append(errs, &fieldError{appendPath(path, "keepAliveInterval"), "keepAliveInterval must not be negative"})
+	}
+	for i, hop := range cfg.ProxyJump {
+		if hop == nil {
+			continue
+		}
+		errs = append(errs, validateSSHConfig(appendPath(path, "proxyJump", i), hop)...)
+	}
+	return errs
+}
+
+func validPort(port int) bool {
+	return 0 <= port && port <= 65535
+}
+
+func driverNames() string {
+	names := []string{}
+	for _, d := range database.Drivers() {
+		names = append(names, string(d))
+	}
+	return strings.Join(names, ", ")
+}
+
+func appendPath(path []interface{}, elems ...interface{}) []interface{} {
+	p := make([]interface{}, 0, len(path)+len(elems))
+	p = append(p, path...)
+	return append(p, elems...)
+}
+
+// locate returns the position of the value at path in the YAML document, the key of a map value and the first line of a sequence item.
+// The position of the deepest value found is returned when the path does not exist, 0 if none is found.
+func locate(root *yaml3.Node, path []interface{}) (line, column int) {
+	node := root
+	if node.Kind == yaml3.DocumentNode {
+		if len(node.Content) == 0 {
+			return 0, 0
+		}
+		node = node.Content[0]
+	}
+	for _, elem := range path {
+		node = resolveAlias(node)
+		var next, at *yaml3.Node
+		switch e := elem.(type) {
+		case string:
+			if key, value := mappingValue(node, e); key != nil {
+				next, at = value, key
+			}
+		case int:
+			if node.Kind == yaml3.SequenceNode && e < len(node.Content) {
+				next = node.Content[e]
+				at = resolveAlias(next)
+			}
+		}
+		if next == nil {
+			break
+		}
+		line, column = at.Line, at.Column
+		node = next
+	}
+	return line, column
+}
+
+// mappingValue returns the key and the value of the map, including the ones merged by "<<"
+func mappingValue(node *yaml3.Node, key string) (*yaml3.Node, *yaml3.Node) {
+	if node.Kind != yaml3.MappingNode {
+		return nil, nil
+	}
+	var merged []*yaml3.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if k.Value == key {
+			return k, v
+		}
+		if k.Tag == "!!merge" {
+			if v = resolveAlias(v); v.Kind == yaml3.SequenceNode {
+				merged = append(merged, v.Content...)
+			} else {
+				merged = append(merged, v)
+			}
+		}
+	}
+	for _, m := range merged {
+		if k, v := mappingValue(resolveAlias(m), key); k != nil {
+			return k, v
+		}
+	}
+	return nil, nil
+}
+
+func resolveAlias(node *yaml3.Node) *yaml3.Node {
+	for node.Kind == yaml3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
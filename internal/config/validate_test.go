@@ -0,0 +1,222 @@
+package config
+
+import (
+	"testing"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "valid",
+			input: `lowercaseKeywords: true
+connections:
+  - alias: dsn
+    driver: mysql
+    dataSourceName: root:root@tcp(127.0.0.1:13306)/world
+    sshConfig:
+      host: bastion
+  - alias: individual
+    driver: postgresql
+    proto: tcp
+    host: 127.0.0.1
+    port: 15432
+    sshConfig:
+      host: bastion
+      proxyJump:
+        - host: jump
+`,
+		},
+		{
+			name: "unknown key",
+			input: `connections:
+  - alias: dev
+    driver: mysql
+    dataSoruceName: root:root@tcp(127.0.0.1:13306)/world
+`,
+			want: []string{
+				`line 2: proto or dataSourceName is required for mysql`,
+				`line 4: unknown key "dataSoruceName"`,
+			},
+		},
+		{
+			name: "unknown driver",
+			input: `connections:
+  - alias: dev
+    driver: oracle
+`,
+			want: []string{
+				`line 3: unknown driver "oracle", available drivers are mock, mysql, postgresql, sqlite3`,
+			},
+		},
+		{
+			name: "required fields per driver",
+			input: `connections:
+  - alias: sqlite
+    driver: sqlite3
+  - alias: sqlite_via_ssh
+    driver: sqlite3
+    dataSourceName: file:test.db
+    sshConfig:
+      host: bastion
+  - alias: pg
+    driver: postgresql
+    proto: unix
+  - alias: udp
+    driver: postgresql
+    proto: udp
+  - dbName: world
+`,
+			want: []string{
+				`line 2: dataSourceName is required for sqlite3`,
+				`line 7: sshConfig cannot be used with dataSourceName of sqlite3`,
+				`line 9: path of the socket directory is required for unix`,
+				`line 14: unsupported proto "udp" for postgresql`,
+				`line 15: driver is required`,
+			},
+		},
+		{
+			name: "port range",
+			input: `connections:
+  - alias: dev
+    driver: mysql
+    proto: tcp
+    port: 70000
+    sshConfig:
+      host: bastion
+      proxyJump:
+        - host: jump
+        - host: jump2
+
+          port: -1
+`,
+			want: []string{
+				`line 5: port 70000 is out of range 0-65535`,
+				`line 12: port -1 is out of range 0-65535`,
+			},
+		},
+		{
+			name: "duplicate alias and tls",
+			input: `connections:
+- alias: dev
+  driver: mysql
+  dataSourceName: root:root@tcp(127.0.0.1:13306)/world
+- alias: dev
+  driver: mysql
+  dataSourceName: root:root@tcp(127.0.0.1:13306)/world
+  tls:
+    mode: verify
+    certFile: client.pem
+`,
+			want: []string{
+				`line 5: duplicate alias "dev"`,
+				`line 8: certFile and keyFile must be set together`,
+				`line 9: unknown tls mode "verify"`,
+			},
+		},
+		{
+			name: "preferred with ca",
+			input: `connections:
+- alias: dev
+  driver: mysql
+  dataSourceName: root:root@tcp(127.0.0.1:13306)/world
+  tls:
+    mode: preferred
+    caFile: ca.pem
+`,
+			want: []string{
+				`line 6: tls mode preferred cannot be used with caFile or certFile, use require or verify-ca`,
+			},
+		},
+		{
+			name: "flow style",
+			input: `connections: [
+  {alias: dev, driver: mysql, dataSourceName: "root@tcp(127.0.0.1)/world"},
+  {alias: "dev", driver: oracle}
+]
+`,
+			want: []string{
+				`line 3: duplicate alias "dev"`,
+				`line 3: unknown driver "oracle", available drivers are mock, mysql, postgresql, sqlite3`,
+			},
+		},
+		{
+			name: "syntax error",
+			input: `connections:
+  - alias: dev
+    driver: [mysql
+`,
+			want: []string{
+				`line 3: did not find expected ',' or ']'`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Check([]byte(tt.input))
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", errs, tt.want)
+			}
+			for i := range errs {
+				if errs[i].Error() != tt.want[i] {
+					t.Errorf("got %q, want %q", errs[i].Error(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	doc := `# comment
+base: &base
+  driver: mysql
+  sshConfig: {host: h, port: 22}
+connections:
+- alias: a
+  <<: *base
+
+- "alias": b
+  params:
+    host: p
+  host: |
+    multi
+    line
+  port: 3306 # comment
+- {alias: c, driver: mysql}
+`
+	var root yaml3.Node
+	if err := yaml3.Unmarshal([]byte(doc), &root); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path       []interface{}
+		wantLine   int
+		wantColumn int
+	}{
+		{path: []interface{}{"connections"}, wantLine: 5, wantColumn: 1},
+		{path: []interface{}{"connections", 0, "sshConfig", "host"}, wantLine: 4, wantColumn: 15},
+		{path: []interface{}{"connections", 0, "driver"}, wantLine: 3, wantColumn: 3},
+		{path: []interface{}{"connections", 1}, wantLine: 9, wantColumn: 3},
+		{path: []interface{}{"connections", 1, "alias"}, wantLine: 9, wantColumn: 3},
+		{path: []interface{}{"connections", 1, "port"}, wantLine: 15, wantColumn: 3},
+		{path: []interface{}{"connections", 1, "dbName"}, wantLine: 9, wantColumn: 3},
+		{path: []interface{}{"connections", 2, "driver"}, wantLine: 16, wantColumn: 14},
+		{path: []interface{}{"connections", 3}, wantLine: 5, wantColumn: 1},
+		{path: []interface{}{"lowercaseKeywords"}, wantLine: 0, wantColumn: 0},
+	}
+	for _, tt := range tests {
+		line, column := locate(&root, tt.path)
+		if line != tt.wantLine || column != tt.wantColumn {
+			t.Errorf("locate(%v) got %d:%d, want %d:%d", tt.path, line, column, tt.wantLine, tt.wantColumn)
+		}
+	}
+
+	if line, _ := locate(&yaml3.Node{}, []interface{}{"connections"}); line != 0 {
+		t.Errorf("got line %d of an empty document", line)
+	}
+}
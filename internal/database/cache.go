@@ -2,12 +2,16 @@ package database
 
 import (
 	"context"
+	"log"
 	"sort"
 	"strings"
 )
 
 type DBCacheGenerator struct {
 	repo DBRepository
+
+	lazyColumns   bool
+	columnLRUSize int
 }
 
 func NewDBCacheUpdater(repo DBRepository) *DBCacheGenerator {
@@ -16,6 +20,20 @@ func NewDBCacheUpdater(repo DBRepository) *DBCacheGenerator {
 	}
 }
 
+// EnableLazyColumns loads only table names eagerly, columns are fetched on first use and kept for up to size tables.
+func (u *DBCacheGenerator) EnableLazyColumns(size int) {
+	u.lazyColumns = true
+	u.columnLRUSize = size
+}
+
+// AttachLazyColumns makes the cache fetch missing columns on first use, if lazy loading is enabled.
+func (u *DBCacheGenerator) AttachLazyColumns(dc *DBCache) {
+	if u.lazyColumns {
+		dc.lazy = newLazyColumns(u.repo, u.columnLRUSize)
+		dc.tables = &tableSet{}
+	}
+}
+
 func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache, error) {
 	var err error
 	dbCache := &DBCache{}
@@ -23,6 +41,13 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 	if err != nil {
 		return nil, err
 	}
+	dbCache.searchPath, err = u.repo.SearchPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbCache.searchPath) == 0 {
+		dbCache.searchPath = []string{dbCache.defaultSchema}
+	}
 	dbCache.Schemas, err = u.genSchmeaCache(ctx)
 	if err != nil {
 		return nil, err
@@ -31,10 +56,35 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 	if err != nil {
 		return nil, err
 	}
-	dbCache.ColumnsWithParent, err = u.genColumnCacheCurrent(ctx, dbCache.defaultSchema)
+	if u.lazyColumns {
+		dbCache.ColumnsWithParent = map[string][]*ColumnDesc{}
+		u.AttachLazyColumns(dbCache)
+	} else {
+		dbCache.ColumnsWithParent, err = u.genColumnCacheCurrent(ctx, dbCache.searchPath...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	dbCache.TablesWithParent, err = u.genTableCache(ctx, dbCache.searchPath...)
 	if err != nil {
 		return nil, err
 	}
+	// indexes, constraints and functions are optional, such as when the user cannot read the catalog of them
+	dbCache.IndexesWithParent, err = u.genIndexCache(ctx, dbCache.searchPath...)
+	if err != nil {
+		log.Println("cannot load indexes,", err)
+		dbCache.IndexesWithParent = map[string][]*IndexDesc{}
+	}
+	dbCache.ConstraintsWithParent, err = u.genConstraintCache(ctx, dbCache.searchPath...)
+	if err != nil {
+		log.Println("cannot load constraints,", err)
+		dbCache.ConstraintsWithParent = map[string][]*ConstraintDesc{}
+	}
+	dbCache.FunctionsWithParent, err = u.genFunctionCache(ctx, dbCache.searchPath...)
+	if err != nil {
+		log.Println("cannot load functions,", err)
+		dbCache.FunctionsWithParent = map[string][]*FunctionDesc{}
+	}
 	return dbCache, nil
 }
 
@@ -54,10 +104,14 @@ func (u *DBCacheGenerator) genSchmeaCache(ctx context.Context) (map[string]strin
 	return databaseMap, nil
 }
 
-func (u *DBCacheGenerator) genColumnCacheCurrent(ctx context.Context, schemaName string) (map[string][]*ColumnDesc, error) {
-	columnDescs, err := u.repo.DescribeDatabaseTableBySchema(ctx, schemaName)
-	if err != nil {
-		return nil, err
+func (u *DBCacheGenerator) genColumnCacheCurrent(ctx context.Context, schemaNames ...string) (map[string][]*ColumnDesc, error) {
+	columnDescs := []*ColumnDesc{}
+	for _, schemaName := range schemaNames {
+		descs, err := u.repo.DescribeDatabaseTableBySchema(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		columnDescs = append(columnDescs, descs...)
 	}
 	return genColumnMap(columnDescs), nil
 }
@@ -84,11 +138,212 @@ func genColumnMap(columnDescs []*ColumnDesc) map[string][]*ColumnDesc {
 	return columnMap
 }
 
+func (u *DBCacheGenerator) genTableCache(ctx context.Context, schemaNames ...string) (map[string]*TableDesc, error) {
+	tableMap := map[string]*TableDesc{}
+	for _, schemaName := range schemaNames {
+		tableDescs, err := u.repo.DescribeTablesBySchema(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range tableDescs {
+			tableMap[columnDatabaseKey(desc.Schema, desc.Name)] = desc
+		}
+	}
+	return tableMap, nil
+}
+
+func (u *DBCacheGenerator) genIndexCache(ctx context.Context, schemaNames ...string) (map[string][]*IndexDesc, error) {
+	indexDescs := []*IndexDesc{}
+	for _, schemaName := range schemaNames {
+		descs, err := u.repo.DescribeIndexesBySchema(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		indexDescs = append(indexDescs, descs...)
+	}
+	return genIndexMap(indexDescs), nil
+}
+
+func (u *DBCacheGenerator) genConstraintCache(ctx context.Context, schemaNames ...string) (map[string][]*ConstraintDesc, error) {
+	constraintDescs := []*ConstraintDesc{}
+	for _, schemaName := range schemaNames {
+		descs, err := u.repo.DescribeConstraintsBySchema(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		constraintDescs = append(constraintDescs, descs...)
+	}
+	return genConstraintMap(constraintDescs), nil
+}
+
+func (u *DBCacheGenerator) genFunctionCache(ctx context.Context, schemaNames ...string) (map[string][]*FunctionDesc, error) {
+	functionMap := map[string][]*FunctionDesc{}
+	for _, schemaName := range schemaNames {
+		functionDescs, err := u.repo.DescribeFunctionsBySchema(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range functionDescs {
+			functionMap[desc.Schema] = append(functionMap[desc.Schema], desc)
+		}
+	}
+	return functionMap, nil
+}
+
+func genIndexMap(indexDescs []*IndexDesc) map[string][]*IndexDesc {
+	indexMap := map[string][]*IndexDesc{}
+	for _, desc := range indexDescs {
+		key := columnDatabaseKey(desc.Schema, desc.Table)
+		indexMap[key] = append(indexMap[key], desc)
+	}
+	return indexMap
+}
+
+func genConstraintMap(constraintDescs []*ConstraintDesc) map[string][]*ConstraintDesc {
+	constraintMap := map[string][]*ConstraintDesc{}
+	for _, desc := range constraintDescs {
+		key := columnDatabaseKey(desc.Schema, desc.Table)
+		constraintMap[key] = append(constraintMap[key], desc)
+	}
+	return constraintMap
+}
+
+// RefreshTables returns a copy of the cache with the given tables of the schema reloaded.
+// Tables that no longer exist are removed from the copy.
+func (u *DBCacheGenerator) RefreshTables(ctx context.Context, dc *DBCache, schemaName string, tableNames []string) (*DBCache, error) {
+	newCache := dc.clone()
+	tableDescs, err := u.repo.DescribeTablesByNames(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	tables := map[string]*TableDesc{}
+	for _, desc := range tableDescs {
+		tables[columnDatabaseKey(desc.Schema, desc.Name)] = desc
+	}
+	indexDescs, err := u.repo.DescribeIndexesByTables(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	indexes := genIndexMap(indexDescs)
+	constraintDescs, err := u.repo.DescribeConstraintsByTables(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	constraints := genConstraintMap(constraintDescs)
+	newCache.SchemaTables[schemaName] = refreshTableNames(dc.SchemaTables[schemaName], tableNames, tableDescs)
+	for _, tableName := range tableNames {
+		key := columnDatabaseKey(schemaName, tableName)
+		if newCache.lazy != nil {
+			// fetched again on next use
+			newCache.lazy.forget(schemaName, tableName)
+		} else {
+			cols, err := u.repo.DescribeTableBySchema(ctx, schemaName, tableName)
+			if err != nil {
+				return nil, err
+			}
+			if len(cols) > 0 {
+				newCache.ColumnsWithParent[key] = cols
+			} else {
+				delete(newCache.ColumnsWithParent, key)
+			}
+		}
+		if desc, ok := tables[key]; ok {
+			newCache.TablesWithParent[key] = desc
+		} else {
+			delete(newCache.TablesWithParent, key)
+		}
+		if descs, ok := indexes[key]; ok {
+			newCache.IndexesWithParent[key] = descs
+		} else {
+			delete(newCache.IndexesWithParent, key)
+		}
+		if descs, ok := constraints[key]; ok {
+			newCache.ConstraintsWithParent[key] = descs
+		} else {
+			delete(newCache.ConstraintsWithParent, key)
+		}
+	}
+	return newCache, nil
+}
+
+// refreshTableNames replaces tableNames of the names with the existing tables of descs, keeping the names sorted
+func refreshTableNames(names, tableNames []string, descs []*TableDesc) []string {
+	refreshed := map[string]bool{}
+	for _, name := range tableNames {
+		refreshed[name] = true
+	}
+	for _, desc := range descs {
+		refreshed[desc.Name] = true
+	}
+	newNames := []string{}
+	for _, name := range names {
+		if !refreshed[name] {
+			newNames = append(newNames, name)
+		}
+	}
+	for _, desc := range descs {
+		newNames = append(newNames, desc.Name)
+	}
+	sort.Strings(newNames)
+	return newNames
+}
+
 type DBCache struct {
-	defaultSchema     string
-	Schemas           map[string]string
-	SchemaTables      map[string][]string
-	ColumnsWithParent map[string][]*ColumnDesc
+	defaultSchema         string
+	searchPath            []string
+	Schemas               map[string]string
+	SchemaTables          map[string][]string
+	ColumnsWithParent     map[string][]*ColumnDesc
+	TablesWithParent      map[string]*TableDesc
+	IndexesWithParent     map[string][]*IndexDesc
+	ConstraintsWithParent map[string][]*ConstraintDesc
+	FunctionsWithParent   map[string][]*FunctionDesc
+
+	lazy *lazyColumns
+	// tables is used to look up SchemaTables in lazy mode
+	tables *tableSet
+}
+
+// clone copies the maps so that the copy can be modified while the original is read concurrently.
+func (dc *DBCache) clone() *DBCache {
+	newCache := &DBCache{
+		defaultSchema:         dc.defaultSchema,
+		searchPath:            dc.searchPath,
+		Schemas:               map[string]string{},
+		SchemaTables:          map[string][]string{},
+		ColumnsWithParent:     map[string][]*ColumnDesc{},
+		TablesWithParent:      map[string]*TableDesc{},
+		IndexesWithParent:     map[string][]*IndexDesc{},
+		ConstraintsWithParent: map[string][]*ConstraintDesc{},
+		FunctionsWithParent:   map[string][]*FunctionDesc{},
+		lazy:                  dc.lazy,
+	}
+	if dc.lazy != nil {
+		// SchemaTables of the copy may be modified
+		newCache.tables = &tableSet{}
+	}
+	for k, v := range dc.Schemas {
+		newCache.Schemas[k] = v
+	}
+	for k, v := range dc.SchemaTables {
+		newCache.SchemaTables[k] = v
+	}
+	for k, v := range dc.ColumnsWithParent {
+		newCache.ColumnsWithParent[k] = v
+	}
+	for k, v := range dc.TablesWithParent {
+		newCache.TablesWithParent[k] = v
+	}
+	for k, v := range dc.IndexesWithParent {
+		newCache.IndexesWithParent[k] = v
+	}
+	for k, v := range dc.ConstraintsWithParent {
+		newCache.ConstraintsWithParent[k] = v
+	}
+	for k, v := range dc.FunctionsWithParent {
+		newCache.FunctionsWithParent[k] = v
+	}
+	return newCache
 }
 
 func (dc *DBCache) Database(dbName string) (db string, ok bool) {
@@ -111,23 +366,67 @@ func (dc *DBCache) SortedTablesByDBName(dbName string) (tbls []string, ok bool)
 	return
 }
 
+// SortedTables returns the tables visible without schema, a table hidden by the same name in an earlier schema of the search path is listed once.
 func (dc *DBCache) SortedTables() []string {
-	tbls, _ := dc.SortedTablesByDBName(dc.defaultSchema)
+	seen := map[string]bool{}
+	tbls := []string{}
+	for _, schemaName := range dc.SearchPath() {
+		for _, table := range dc.SchemaTables[schemaName] {
+			if seen[table] {
+				continue
+			}
+			seen[table] = true
+			tbls = append(tbls, table)
+		}
+	}
+	sort.Strings(tbls)
 	return tbls
 }
 
+// SearchPath returns the schemas searched for unqualified names in order.
+func (dc *DBCache) SearchPath() []string {
+	if len(dc.searchPath) == 0 {
+		return []string{dc.defaultSchema}
+	}
+	return dc.searchPath
+}
+
+// TableSchema returns the first schema of the search path that has the table, or the default schema.
+func (dc *DBCache) TableSchema(tableName string) string {
+	for _, schemaName := range dc.SearchPath() {
+		if dc.hasTable(schemaName, tableName) {
+			return schemaName
+		}
+		if _, ok := dc.ColumnsWithParent[columnDatabaseKey(schemaName, tableName)]; ok {
+			return schemaName
+		}
+	}
+	return dc.defaultSchema
+}
+
 func (dc *DBCache) ColumnDescs(tableName string) (cols []*ColumnDesc, ok bool) {
-	cols, ok = dc.ColumnsWithParent[columnDatabaseKey(dc.defaultSchema, tableName)]
-	return
+	return dc.columns(dc.TableSchema(tableName), tableName)
 }
 
 func (dc *DBCache) ColumnDatabase(dbName, tableName string) (cols []*ColumnDesc, ok bool) {
-	cols, ok = dc.ColumnsWithParent[columnDatabaseKey(dbName, tableName)]
-	return
+	return dc.columns(dbName, tableName)
 }
 
 func (dc *DBCache) Column(tableName, colName string) (*ColumnDesc, bool) {
-	cols, ok := dc.ColumnsWithParent[columnDatabaseKey(dc.defaultSchema, tableName)]
+	return dc.ColumnBySchema("", tableName, colName)
+}
+
+// ColumnDescsBySchema returns the columns of the table in the schema, the search path is used if schemaName is empty.
+func (dc *DBCache) ColumnDescsBySchema(schemaName, tableName string) ([]*ColumnDesc, bool) {
+	if schemaName == "" {
+		return dc.ColumnDescs(tableName)
+	}
+	return dc.ColumnDatabase(schemaName, tableName)
+}
+
+// ColumnBySchema is Column for the table in the schema, the search path is used if schemaName is empty.
+func (dc *DBCache) ColumnBySchema(schemaName, tableName, colName string) (*ColumnDesc, bool) {
+	cols, ok := dc.ColumnDescsBySchema(schemaName, tableName)
 	if !ok {
 		return nil, false
 	}
@@ -139,6 +438,114 @@ func (dc *DBCache) Column(tableName, colName string) (*ColumnDesc, bool) {
 	return nil, false
 }
 
+func (dc *DBCache) columns(dbName, tableName string) ([]*ColumnDesc, bool) {
+	if cols, ok := dc.ColumnsWithParent[columnDatabaseKey(dbName, tableName)]; ok {
+		return cols, true
+	}
+	if dc.lazy == nil || !dc.hasTable(dbName, tableName) {
+		return nil, false
+	}
+	return dc.lazy.columns(dbName, tableName)
+}
+
+// CachedColumnDescsBySchema is ColumnDescsBySchema which does not fetch the columns in lazy mode, for the frequent checks such as diagnostics.
+func (dc *DBCache) CachedColumnDescsBySchema(schemaName, tableName string) ([]*ColumnDesc, bool) {
+	if schemaName == "" {
+		schemaName = dc.TableSchema(tableName)
+	}
+	if cols, ok := dc.ColumnsWithParent[columnDatabaseKey(schemaName, tableName)]; ok {
+		return cols, true
+	}
+	if dc.lazy == nil {
+		return nil, false
+	}
+	return dc.lazy.cached(schemaName, tableName)
+}
+
+func (dc *DBCache) hasTable(dbName, tableName string) bool {
+	if dc.tables != nil {
+		return dc.tables.has(dc.SchemaTables, dbName, tableName)
+	}
+	for _, table := range dc.SchemaTables[dbName] {
+		if table == tableName {
+			return true
+		}
+	}
+	return false
+}
+
+func (dc *DBCache) TableComment(tableName string) string {
+	return dc.TableCommentDatabase(dc.TableSchema(tableName), tableName)
+}
+
+func (dc *DBCache) TableCommentDatabase(dbName, tableName string) string {
+	desc, ok := dc.TablesWithParent[columnDatabaseKey(dbName, tableName)]
+	if !ok {
+		return ""
+	}
+	return desc.Comment
+}
+
+// TableDesc returns the table of the schema, only the tables of the search path are cached.
+func (dc *DBCache) TableDesc(schemaName, tableName string) (*TableDesc, bool) {
+	desc, ok := dc.TablesWithParent[columnDatabaseKey(schemaName, tableName)]
+	return desc, ok
+}
+
+func (dc *DBCache) Indexes(tableName string) []*IndexDesc {
+	return dc.IndexesWithParent[columnDatabaseKey(dc.TableSchema(tableName), tableName)]
+}
+
+func (dc *DBCache) IndexesDatabase(dbName, tableName string) []*IndexDesc {
+	return dc.IndexesWithParent[columnDatabaseKey(dbName, tableName)]
+}
+
+func (dc *DBCache) Constraints(tableName string) []*ConstraintDesc {
+	return dc.ConstraintsWithParent[columnDatabaseKey(dc.TableSchema(tableName), tableName)]
+}
+
+func (dc *DBCache) ConstraintsDatabase(dbName, tableName string) []*ConstraintDesc {
+	return dc.ConstraintsWithParent[columnDatabaseKey(dbName, tableName)]
+}
+
+func (dc *DBCache) SortedFunctions() []*FunctionDesc {
+	funcs := []*FunctionDesc{}
+	for _, schemaName := range dc.SearchPath() {
+		funcs = append(funcs, dc.FunctionsWithParent[schemaName]...)
+	}
+	sort.SliceStable(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+// SortedFunctionsBySchema returns a sorted copy of the functions of the schema, the cache is shared by the readers.
+func (dc *DBCache) SortedFunctionsBySchema(schemaName string) ([]*FunctionDesc, bool) {
+	cached, ok := dc.FunctionsWithParent[schemaName]
+	funcs := append([]*FunctionDesc{}, cached...)
+	sort.SliceStable(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs, ok
+}
+
+// Functions returns all overloads of the function, the search path is used if schemaName is empty.
+func (dc *DBCache) Functions(schemaName, funcName string) []*FunctionDesc {
+	schemaNames := []string{schemaName}
+	if schemaName == "" {
+		schemaNames = dc.SearchPath()
+	}
+	res := []*FunctionDesc{}
+	for _, schemaName := range schemaNames {
+		for _, fd := range dc.FunctionsWithParent[schemaName] {
+			if strings.EqualFold(fd.Name, funcName) {
+				res = append(res, fd)
+			}
+		}
+	}
+	return res
+}
+
+func (dc *DBCache) DefaultSchema() string {
+	return dc.defaultSchema
+}
+
 func columnDatabaseKey(dbName, tableName string) string {
 	return dbName + "\t" + tableName
 }
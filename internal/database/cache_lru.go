@@ -0,0 +1,160 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultColumnCacheSize is the number of tables whose columns are kept in lazy mode
+const DefaultColumnCacheSize = 1000
+
+const lazyColumnTimeout = 10 * time.Second
+
+// lazyColumnRetryInterval is how long a table whose columns cannot be loaded is not queried again
+const lazyColumnRetryInterval = time.Minute
+
+type columnLRUEntry struct {
+	key  string
+	cols []*ColumnDesc
+}
+
+// columnLRU keeps the columns of the recently used tables
+type columnLRU struct {
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	lock  sync.Mutex
+}
+
+func newColumnLRU(size int) *columnLRU {
+	if size <= 0 {
+		size = DefaultColumnCacheSize
+	}
+	return &columnLRU{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+func (c *columnLRU) get(key string) ([]*ColumnDesc, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*columnLRUEntry).cols, true
+}
+
+func (c *columnLRU) add(key string, cols []*ColumnDesc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*columnLRUEntry).cols = cols
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&columnLRUEntry{key: key, cols: cols})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*columnLRUEntry).key)
+	}
+}
+
+func (c *columnLRU) remove(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+func (c *columnLRU) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ll.Len()
+}
+
+// lazyColumns fetches the columns of a table on first use
+type lazyColumns struct {
+	repo DBRepository
+	lru  *columnLRU
+
+	lock sync.Mutex
+	// missing are the tables whose columns could not be loaded and the time to retry them
+	missing map[string]time.Time
+}
+
+func newLazyColumns(repo DBRepository, size int) *lazyColumns {
+	return &lazyColumns{
+		repo:    repo,
+		lru:     newColumnLRU(size),
+		missing: map[string]time.Time{},
+	}
+}
+
+func (l *lazyColumns) cached(schemaName, tableName string) ([]*ColumnDesc, bool) {
+	return l.lru.get(columnDatabaseKey(schemaName, tableName))
+}
+
+func (l *lazyColumns) columns(schemaName, tableName string) ([]*ColumnDesc, bool) {
+	key := columnDatabaseKey(schemaName, tableName)
+	if cols, ok := l.lru.get(key); ok {
+		return cols, true
+	}
+	l.lock.Lock()
+	retry, ok := l.missing[key]
+	l.lock.Unlock()
+	if ok && time.Now().Before(retry) {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lazyColumnTimeout)
+	defer cancel()
+	cols, err := l.repo.DescribeTableBySchema(ctx, schemaName, tableName)
+	if err != nil || len(cols) == 0 {
+		if err != nil {
+			log.Println("cannot load columns,", err)
+		}
+		l.lock.Lock()
+		l.missing[key] = time.Now().Add(lazyColumnRetryInterval)
+		l.lock.Unlock()
+		return nil, false
+	}
+	l.lru.add(key, cols)
+	return cols, true
+}
+
+// forget drops the columns of the table, which are fetched again on next use
+func (l *lazyColumns) forget(schemaName, tableName string) {
+	key := columnDatabaseKey(schemaName, tableName)
+	l.lru.remove(key)
+	l.lock.Lock()
+	delete(l.missing, key)
+	l.lock.Unlock()
+}
+
+// tableSet is the set of SchemaTables to look up tables in lazy mode, built on first use
+type tableSet struct {
+	once   sync.Once
+	tables map[string]bool
+}
+
+func (s *tableSet) has(schemaTables map[string][]string, schemaName, tableName string) bool {
+	s.once.Do(func() {
+		s.tables = map[string]bool{}
+		for schema, tables := range schemaTables {
+			for _, table := range tables {
+				s.tables[columnDatabaseKey(schema, table)] = true
+			}
+		}
+	})
+	return s.tables[columnDatabaseKey(schemaName, tableName)]
+}
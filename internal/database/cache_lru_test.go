@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestColumnLRU(t *testing.T) {
+	lru := newColumnLRU(2)
+	lru.add("a", []*ColumnDesc{{Name: "a"}})
+	lru.add("b", []*ColumnDesc{{Name: "b"}})
+	// a becomes the most recently used, so b is evicted
+	if _, ok := lru.get("a"); !ok {
+		t.Fatal("a not found")
+	}
+	lru.add("c", []*ColumnDesc{{Name: "c"}})
+
+	if _, ok := lru.get("b"); ok {
+		t.Error("b must be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := lru.get(key); !ok {
+			t.Errorf("%s not found", key)
+		}
+	}
+	lru.remove("a")
+	if _, ok := lru.get("a"); ok {
+		t.Error("a must be removed")
+	}
+	if got := lru.len(); got != 1 {
+		t.Errorf("len got %d, want 1", got)
+	}
+}
+
+func TestLazyColumns(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	loaded := map[string]int{}
+	describe := repo.MockDescribeTableBySchema
+	repo.MockDescribeTableBySchema = func(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+		loaded[tableName]++
+		return describe(ctx, schemaName, tableName)
+	}
+
+	generator := NewDBCacheUpdater(repo)
+	generator.EnableLazyColumns(1)
+	cache, err := generator.GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.ColumnsWithParent) != 0 {
+		t.Fatalf("columns must not be loaded eagerly, got %d tables", len(cache.ColumnsWithParent))
+	}
+	if got := cache.SortedTables(); len(got) != 3 {
+		t.Fatalf("tables must be loaded eagerly, got %v", got)
+	}
+
+	if _, ok := cache.ColumnDescs("city"); !ok {
+		t.Fatal("city columns not found")
+	}
+	if _, ok := cache.Column("city", "Name"); !ok {
+		t.Fatal("city.Name not found")
+	}
+	if loaded["city"] != 1 {
+		t.Errorf("city loaded %d times, want 1", loaded["city"])
+	}
+
+	// country evicts city
+	if _, ok := cache.ColumnDatabase("world", "country"); !ok {
+		t.Fatal("country columns not found")
+	}
+	if _, ok := cache.ColumnDescs("city"); !ok {
+		t.Fatal("city columns not found")
+	}
+	if loaded["city"] != 2 {
+		t.Errorf("city loaded %d times, want 2", loaded["city"])
+	}
+
+	// unknown tables never reach the database
+	if _, ok := cache.ColumnDescs("notfound"); ok {
+		t.Error("notfound columns must not be found")
+	}
+	if loaded["notfound"] != 0 {
+		t.Errorf("notfound loaded %d times, want 0", loaded["notfound"])
+	}
+}
+
+func TestLazyColumnsMissing(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	loaded := map[string]int{}
+	describe := repo.MockDescribeTableBySchema
+	repo.MockDescribeTableBySchema = func(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+		loaded[tableName]++
+		if tableName == "country" {
+			return nil, errors.New("permission denied")
+		}
+		return describe(ctx, schemaName, tableName)
+	}
+
+	generator := NewDBCacheUpdater(repo)
+	generator.EnableLazyColumns(10)
+	cache, err := generator.GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached lookup never reaches the database
+	if _, ok := cache.CachedColumnDescsBySchema("", "city"); ok {
+		t.Error("city columns must not be cached yet")
+	}
+	if loaded["city"] != 0 {
+		t.Errorf("city loaded %d times, want 0", loaded["city"])
+	}
+	if _, ok := cache.ColumnDescs("city"); !ok {
+		t.Fatal("city columns not found")
+	}
+	if _, ok := cache.CachedColumnDescsBySchema("", "city"); !ok {
+		t.Error("city columns must be cached")
+	}
+
+	// a failed table is not queried again until the retry interval passes
+	for i := 0; i < 3; i++ {
+		if _, ok := cache.ColumnDescs("country"); ok {
+			t.Fatal("country columns must not be found")
+		}
+	}
+	if loaded["country"] != 1 {
+		t.Errorf("country loaded %d times, want 1", loaded["country"])
+	}
+
+	// forgetting the table retries it
+	cache.lazy.forget("world", "country")
+	cache.ColumnDescs("country")
+	if loaded["country"] != 2 {
+		t.Errorf("country loaded %d times, want 2", loaded["country"])
+	}
+}
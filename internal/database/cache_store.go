@@ -0,0 +1,134 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+// cacheFormatVersion must be incremented when the stored cache layout changes
+const cacheFormatVersion = 2
+
+type storedDBCache struct {
+	Version               int                          `json:"version"`
+	DefaultSchema         string                       `json:"defaultSchema"`
+	SearchPath            []string                     `json:"searchPath"`
+	Schemas               map[string]string            `json:"schemas"`
+	SchemaTables          map[string][]string          `json:"schemaTables"`
+	ColumnsWithParent     map[string][]*ColumnDesc     `json:"columns"`
+	TablesWithParent      map[string]*TableDesc        `json:"tables"`
+	IndexesWithParent     map[string][]*IndexDesc      `json:"indexes"`
+	ConstraintsWithParent map[string][]*ConstraintDesc `json:"constraints"`
+	FunctionsWithParent   map[string][]*FunctionDesc   `json:"functions"`
+}
+
+// CacheStore persists DBCache to a directory, one file per connection config.
+type CacheStore struct {
+	dir string
+}
+
+func NewCacheStore(dir string) *CacheStore {
+	return &CacheStore{dir: dir}
+}
+
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "sqls", "schema"), nil
+}
+
+func (s *CacheStore) Load(cfg *DBConfig) (*DBCache, error) {
+	b, err := ioutil.ReadFile(s.path(cfg))
+	if err != nil {
+		return nil, err
+	}
+	var stored storedDBCache
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, xerrors.Errorf("cannot unmarshal schema cache, %+v", err)
+	}
+	if stored.Version != cacheFormatVersion {
+		return nil, xerrors.Errorf("unsupported schema cache version, %d", stored.Version)
+	}
+	return &DBCache{
+		defaultSchema:         stored.DefaultSchema,
+		searchPath:            stored.SearchPath,
+		Schemas:               stored.Schemas,
+		SchemaTables:          stored.SchemaTables,
+		ColumnsWithParent:     stored.ColumnsWithParent,
+		TablesWithParent:      stored.TablesWithParent,
+		IndexesWithParent:     stored.IndexesWithParent,
+		ConstraintsWithParent: stored.ConstraintsWithParent,
+		FunctionsWithParent:   stored.FunctionsWithParent,
+	}, nil
+}
+
+func (s *CacheStore) Save(cfg *DBConfig, cache *DBCache) error {
+	stored := &storedDBCache{
+		Version:               cacheFormatVersion,
+		DefaultSchema:         cache.defaultSchema,
+		SearchPath:            cache.searchPath,
+		Schemas:               cache.Schemas,
+		SchemaTables:          cache.SchemaTables,
+		ColumnsWithParent:     cache.ColumnsWithParent,
+		TablesWithParent:      cache.TablesWithParent,
+		IndexesWithParent:     cache.IndexesWithParent,
+		ConstraintsWithParent: cache.ConstraintsWithParent,
+		FunctionsWithParent:   cache.FunctionsWithParent,
+	}
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return xerrors.Errorf("cannot marshal schema cache, %+v", err)
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return xerrors.Errorf("cannot create schema cache directory, %+v", err)
+	}
+
+	// write to a temporary file first so that a crash never leaves a broken cache
+	tmp, err := ioutil.TempFile(s.dir, "cache-*.tmp")
+	if err != nil {
+		return xerrors.Errorf("cannot create schema cache file, %+v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return xerrors.Errorf("cannot write schema cache file, %+v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return xerrors.Errorf("cannot write schema cache file, %+v", err)
+	}
+	return os.Rename(tmp.Name(), s.path(cfg))
+}
+
+func (s *CacheStore) path(cfg *DBConfig) string {
+	return filepath.Join(s.dir, cacheKey(cfg)+".json")
+}
+
+// cacheKey identifies the connection config, secrets are excluded so that they never reach the file name.
+func cacheKey(cfg *DBConfig) string {
+	keyCfg := *cfg
+	keyCfg.Passwd = ""
+	if cfg.SSHCfg != nil {
+		keyCfg.SSHCfg = sshCacheKeyConfig(cfg.SSHCfg)
+	}
+	b, _ := json.Marshal(&keyCfg)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func sshCacheKeyConfig(cfg *SSHConfig) *SSHConfig {
+	keyCfg := *cfg
+	keyCfg.PassPhrase = ""
+	keyCfg.Password = ""
+	keyCfg.ProxyJump = make([]*SSHConfig, len(cfg.ProxyJump))
+	for i, hop := range cfg.ProxyJump {
+		keyCfg.ProxyJump[i] = sshCacheKeyConfig(hop)
+	}
+	return &keyCfg
+}
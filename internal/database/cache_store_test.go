@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCacheStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	generator := NewDBCacheUpdater(NewMockDBRepository(nil))
+	want, err := generator.GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewCacheStore(dir)
+	cfg := &DBConfig{Driver: "mock", Host: "127.0.0.1", Port: 3306, Passwd: "secret"}
+	if _, err := store.Load(cfg); !os.IsNotExist(err) {
+		t.Fatalf("expected no stored cache, got %+v", err)
+	}
+	if err := store.Save(cfg, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// secrets are not part of the key
+	got, err := store.Load(&DBConfig{Driver: "mock", Host: "127.0.0.1", Port: 3306, Passwd: "changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// go-cmp cannot read the unexported fields under -race
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unmatch\nwant: %+v\ngot:  %+v", want, got)
+	}
+
+	if _, err := store.Load(&DBConfig{Driver: "mock", Host: "127.0.0.1", Port: 3307}); !os.IsNotExist(err) {
+		t.Errorf("expected no stored cache for other connection, got %+v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDBCacheSearchPath(t *testing.T) {
+	sharedColumns := []*ColumnDesc{
+		{Schema: "shared", Table: "users", Name: "id", Type: "integer"},
+		{Schema: "shared", Table: "city", Name: "shadowed", Type: "text"},
+	}
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockSearchPath = func(ctx context.Context) ([]string, error) {
+		return []string{"world", "shared"}, nil
+	}
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		return map[string][]string{
+			"world":  {"city", "country", "countrylanguage"},
+			"shared": {"city", "users"},
+			"other":  {"hidden"},
+		}, nil
+	}
+	describe := repo.MockDescribeDatabaseTableBySchema
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
+		if schemaName == "shared" {
+			return sharedColumns, nil
+		}
+		return describe(ctx, schemaName)
+	}
+
+	cache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"city", "country", "countrylanguage", "users"}
+	if diff := cmp.Diff(want, cache.SortedTables()); diff != "" {
+		t.Errorf("SortedTables unmatch (- want, + got):\n%s", diff)
+	}
+
+	// the second schema is searched
+	if _, ok := cache.Column("users", "id"); !ok {
+		t.Error("users.id not found")
+	}
+	if got := cache.TableSchema("users"); got != "shared" {
+		t.Errorf("TableSchema got %q, want %q", got, "shared")
+	}
+
+	// the first schema hides the table of the same name
+	cols, ok := cache.ColumnDescs("city")
+	if !ok {
+		t.Fatal("city not found")
+	}
+	if cols[0].Schema != "world" {
+		t.Errorf("city resolved to %q, want %q", cols[0].Schema, "world")
+	}
+	if _, ok := cache.Column("city", "shadowed"); ok {
+		t.Error("shared.city must be hidden by world.city")
+	}
+
+	// schemas outside the search path are not searched
+	if _, ok := cache.ColumnDescs("hidden"); ok {
+		t.Error("hidden must not be found")
+	}
+}
+
+func TestDBCacheRefreshTables(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	cache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// city is dropped and users is created
+	repo.MockDatabaseTables = func(ctx context.Context) (map[string][]string, error) {
+		t.Error("all tables are loaded to refresh some tables")
+		return nil, nil
+	}
+	repo.MockDescribeTablesBySchema = func(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+		return []*TableDesc{
+			{Schema: "world", Name: "country"},
+			{Schema: "world", Name: "countrylanguage"},
+			{Schema: "world", Name: "users"},
+		}, nil
+	}
+	describe := repo.MockDescribeTableBySchema
+	repo.MockDescribeTableBySchema = func(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+		switch tableName {
+		case "city":
+			return nil, nil
+		case "users":
+			return []*ColumnDesc{{Schema: "world", Table: "users", Name: "id"}}, nil
+		}
+		return describe(ctx, schemaName, tableName)
+	}
+
+	refreshed, err := NewDBCacheUpdater(repo).RefreshTables(ctx, cache, "world", []string{"city", "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"country", "countrylanguage", "users"}, refreshed.SchemaTables["world"]); diff != "" {
+		t.Errorf("SchemaTables unmatch (- want, + got):\n%s", diff)
+	}
+	if _, ok := refreshed.ColumnDescs("city"); ok {
+		t.Error("columns of the dropped table are kept")
+	}
+	if _, ok := refreshed.Column("users", "id"); !ok {
+		t.Error("users.id not found")
+	}
+	if _, ok := refreshed.TablesWithParent[columnDatabaseKey("world", "users")]; !ok {
+		t.Error("table of users not found")
+	}
+	// the original cache is not modified
+	if _, ok := cache.ColumnDescs("city"); !ok {
+		t.Error("city is removed from the original cache")
+	}
+}
+
+func TestDBCacheWithoutIndexes(t *testing.T) {
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDescribeIndexesBySchema = func(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+		return nil, errors.New("permission denied")
+	}
+	repo.MockDescribeConstraintsBySchema = func(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+		return nil, errors.New("permission denied")
+	}
+	repo.MockDescribeFunctionsBySchema = func(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+		return nil, errors.New(`column p.prokind does not exist`)
+	}
+
+	// the cache is available without indexes, constraints and functions
+	cache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Column("city", "Name"); !ok {
+		t.Error("city.Name not found")
+	}
+	if got := cache.Indexes("city"); len(got) != 0 {
+		t.Errorf("unexpected indexes %v", got)
+	}
+	if got := cache.FunctionsWithParent; len(got) != 0 {
+		t.Errorf("unexpected functions %v", got)
+	}
+}
+
+func TestDBCacheSortedFunctionsBySchema(t *testing.T) {
+	cached := []*FunctionDesc{{Schema: "world", Name: "upper"}, {Schema: "world", Name: "lower"}}
+	cache := &DBCache{FunctionsWithParent: map[string][]*FunctionDesc{"world": cached}}
+
+	funcs, ok := cache.SortedFunctionsBySchema("world")
+	if !ok || len(funcs) != 2 || funcs[0].Name != "lower" {
+		t.Fatalf("got %v %v, want sorted functions", funcs, ok)
+	}
+	// the cached slice is shared with the other readers
+	if cached[0].Name != "upper" {
+		t.Error("the cached functions are sorted in place")
+	}
+	if _, ok := cache.SortedFunctionsBySchema("unknown"); ok {
+		t.Error("unknown schema found")
+	}
+}
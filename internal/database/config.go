@@ -2,11 +2,8 @@ package database
 
 import (
 	"fmt"
-	"io/ioutil"
 
 	"github.com/lighttiger2505/sqls/dialect"
-	"golang.org/x/crypto/ssh"
-	"golang.org/x/xerrors"
 )
 
 type Proto string
@@ -24,49 +21,44 @@ type DBConfig struct {
 	Proto          Proto                  `json:"proto" yaml:"proto"`
 	User           string                 `json:"user" yaml:"user"`
 	Passwd         string                 `json:"passwd" yaml:"passwd"`
+	PasswdEnv      string                 `json:"passwdEnv" yaml:"passwdEnv"`
+	PasswdFile     string                 `json:"passwdFile" yaml:"passwdFile"`
+	PasswdCommand  string                 `json:"passwdCommand" yaml:"passwdCommand"`
 	Host           string                 `json:"host" yaml:"host"`
 	Port           int                    `json:"port" yaml:"port"`
 	Path           string                 `json:"path" yaml:"path"`
 	DBName         string                 `json:"dbName" yaml:"dbName"`
 	Params         map[string]string      `json:"params" yaml:"params"`
 	SSHCfg         *SSHConfig             `json:"sshConfig" yaml:"sshConfig"`
+	TLS            *TLSConfig             `json:"tls" yaml:"tls"`
+
+	LazyColumns     bool `json:"lazyColumns" yaml:"lazyColumns"`
+	ColumnCacheSize int  `json:"columnCacheSize" yaml:"columnCacheSize"`
+
+	// ReadOnly rejects statements other than queries and opens read-only sessions
+	ReadOnly bool `json:"readOnly" yaml:"readOnly"`
+	// Confirm requires confirmExecuteQuery to run statements other than queries
+	Confirm bool `json:"confirm" yaml:"confirm"`
 }
 
 type SSHConfig struct {
-	Host       string `json:"host" yaml:"host"`
-	Port       int    `json:"port" yaml:"port"`
-	User       string `json:"user" yaml:"user"`
-	PassPhrase string `json:"passPhrase" yaml:"passPhrase"`
-	PrivateKey string `json:"privateKey" yaml:"privateKey"`
+	Host              string `json:"host" yaml:"host"`
+	Port              int    `json:"port" yaml:"port"`
+	User              string `json:"user" yaml:"user"`
+	PassPhrase        string `json:"passPhrase" yaml:"passPhrase"`
+	PassPhraseEnv     string `json:"passPhraseEnv" yaml:"passPhraseEnv"`
+	PassPhraseFile    string `json:"passPhraseFile" yaml:"passPhraseFile"`
+	PassPhraseCommand string `json:"passPhraseCommand" yaml:"passPhraseCommand"`
+	PrivateKey        string `json:"privateKey" yaml:"privateKey"`
+	Password          string `json:"password" yaml:"password"`
+
+	KnownHostsFile        string `json:"knownHostsFile" yaml:"knownHostsFile"`
+	InsecureIgnoreHostKey bool   `json:"insecureIgnoreHostKey" yaml:"insecureIgnoreHostKey"`
+
+	ProxyJump         []*SSHConfig `json:"proxyJump" yaml:"proxyJump"`
+	KeepAliveInterval int          `json:"keepAliveInterval" yaml:"keepAliveInterval"`
 }
 
 func (s *SSHConfig) Endpoint() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
-
-func (s *SSHConfig) ClientConfig() (*ssh.ClientConfig, error) {
-	buffer, err := ioutil.ReadFile(s.PrivateKey)
-	if err != nil {
-		return nil, xerrors.Errorf("cannot read SSH private key file, PrivateKey=%s, %+v", s.PrivateKey, err)
-	}
-
-	var key ssh.Signer
-	if s.PassPhrase != "" {
-		key, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(s.PassPhrase))
-		if err != nil {
-			return nil, xerrors.Errorf("cannot parse SSH private key file with passphrase, PrivateKey=%s, %+v", s.PrivateKey, err)
-		}
-	} else {
-		key, err = ssh.ParsePrivateKey(buffer)
-		if err != nil {
-			return nil, xerrors.Errorf("cannot parse SSH private key file, PrivateKey=%s, %+v", s.PrivateKey, err)
-		}
-	}
-
-	sshConfig := &ssh.ClientConfig{
-		User:            s.User,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
-	return sshConfig, nil
-}
@@ -24,12 +24,22 @@ const (
 type DBRepository interface {
 	Driver() dialect.DatabaseDriver
 	CurrentDatabase(ctx context.Context) (string, error)
+	ServerVersion(ctx context.Context) (string, error)
 	Databases(ctx context.Context) ([]string, error)
 	CurrentSchema(ctx context.Context) (string, error)
+	SearchPath(ctx context.Context) ([]string, error)
 	Schemas(ctx context.Context) ([]string, error)
 	SchemaTables(ctx context.Context) (map[string][]string, error)
 	DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error)
 	DescribeDatabaseTableBySchema(ctx context.Context, schemaName string) ([]*ColumnDesc, error)
+	DescribeTableBySchema(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error)
+	DescribeTablesBySchema(ctx context.Context, schemaName string) ([]*TableDesc, error)
+	DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error)
+	DescribeConstraintsBySchema(ctx context.Context, schemaName string) ([]*ConstraintDesc, error)
+	DescribeTablesByNames(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error)
+	DescribeIndexesByTables(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error)
+	DescribeConstraintsByTables(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error)
+	DescribeFunctionsBySchema(ctx context.Context, schemaName string) ([]*FunctionDesc, error)
 	Exec(ctx context.Context, query string) (sql.Result, error)
 	Query(ctx context.Context, query string) (*sql.Rows, error)
 }
@@ -48,6 +58,7 @@ type ColumnDesc struct {
 	Key     string
 	Default sql.NullString
 	Extra   string
+	Comment string
 }
 
 func (cd *ColumnDesc) OnelineDesc() string {
@@ -68,27 +79,218 @@ func (cd *ColumnDesc) OnelineDescWithName() string {
 	return fmt.Sprintf("%s: %s", cd.Name, cd.OnelineDesc())
 }
 
+type TableDesc struct {
+	Schema  string
+	Name    string
+	Comment string
+}
+
+type IndexDesc struct {
+	Schema  string
+	Table   string
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool
+	Method  string
+}
+
+func (id *IndexDesc) OnelineDesc() string {
+	items := []string{fmt.Sprintf("(%s)", strings.Join(id.Columns, ", "))}
+	if id.Primary {
+		items = append(items, "PRIMARY")
+	} else if id.Unique {
+		items = append(items, "UNIQUE")
+	}
+	if id.Method != "" {
+		items = append(items, id.Method)
+	}
+	return strings.Join(items, " ")
+}
+
+const (
+	ConstraintTypePrimaryKey = "PRIMARY KEY"
+	ConstraintTypeUnique     = "UNIQUE"
+	ConstraintTypeCheck      = "CHECK"
+)
+
+type ConstraintDesc struct {
+	Schema     string
+	Table      string
+	Name       string
+	Type       string
+	Columns    []string
+	Definition string
+}
+
+func (cd *ConstraintDesc) OnelineDesc() string {
+	if cd.Definition != "" {
+		return cd.Definition
+	}
+	if len(cd.Columns) == 0 {
+		return cd.Type
+	}
+	return fmt.Sprintf("%s (%s)", cd.Type, strings.Join(cd.Columns, ", "))
+}
+
+const (
+	FunctionKindFunction  = "FUNCTION"
+	FunctionKindProcedure = "PROCEDURE"
+)
+
+type FunctionArg struct {
+	Name string
+	Type string
+	Mode string
+}
+
+func (fa *FunctionArg) IsInput() bool {
+	switch fa.Mode {
+	case "", "IN", "INOUT", "VARIADIC":
+		return true
+	}
+	return false
+}
+
+func (fa *FunctionArg) Label() string {
+	items := []string{}
+	if fa.Mode != "" && fa.Mode != "IN" {
+		items = append(items, fa.Mode)
+	}
+	if fa.Name != "" {
+		items = append(items, fa.Name)
+	}
+	if fa.Type != "" {
+		items = append(items, fa.Type)
+	}
+	return strings.Join(items, " ")
+}
+
+type FunctionDesc struct {
+	Schema     string
+	Name       string
+	Kind       string
+	Args       []*FunctionArg
+	ReturnType string
+}
+
+func (fd *FunctionDesc) InputArgs() []*FunctionArg {
+	args := []*FunctionArg{}
+	for _, arg := range fd.Args {
+		if arg.IsInput() {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+func (fd *FunctionDesc) Signature() string {
+	args := []string{}
+	for _, arg := range fd.InputArgs() {
+		args = append(args, arg.Label())
+	}
+	sig := fmt.Sprintf("%s(%s)", fd.Name, strings.Join(args, ", "))
+	if fd.ReturnType != "" {
+		sig = fmt.Sprintf("%s RETURNS %s", sig, fd.ReturnType)
+	}
+	return sig
+}
+
+func FunctionDoc(fd *FunctionDesc) string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%s.%s %s", fd.Schema, fd.Name, strings.ToLower(fd.Kind))
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, fd.Signature())
+	return buf.String()
+}
+
+func BuiltinFunctionDoc(f *dialect.Function) string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%s built-in function", f.Name)
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, f.Signature())
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, f.Description)
+	return buf.String()
+}
+
 func ColumnDoc(tableName string, colDesc *ColumnDesc) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "%s.%s column", tableName, colDesc.Name)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf, colDesc.OnelineDesc())
+	if colDesc.Comment != "" {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, colDesc.Comment)
+	}
 	return buf.String()
 }
 
-func TableDoc(tableName string, cols []*ColumnDesc) string {
+func ColumnDetailDoc(tableName string, colDesc *ColumnDesc, indexes []*IndexDesc) string {
+	buf := bytes.NewBufferString(ColumnDoc(tableName, colDesc))
+	colIndexes := []*IndexDesc{}
+	for _, index := range indexes {
+		for _, col := range index.Columns {
+			if strings.EqualFold(col, colDesc.Name) {
+				colIndexes = append(colIndexes, index)
+				break
+			}
+		}
+	}
+	writeIndexDoc(buf, colIndexes)
+	return buf.String()
+}
+
+func TableDoc(tableName, tableComment string, cols []*ColumnDesc) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "%s table", tableName)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
+	if tableComment != "" {
+		fmt.Fprintln(buf, tableComment)
+		fmt.Fprintln(buf)
+	}
 	for _, col := range cols {
 		fmt.Fprintf(buf, "- %s", col.OnelineDescWithName())
+		if col.Comment != "" {
+			fmt.Fprintf(buf, " -- %s", col.Comment)
+		}
 		fmt.Fprintln(buf)
 	}
 	return buf.String()
 }
 
+func TableDetailDoc(tableName, tableComment string, cols []*ColumnDesc, indexes []*IndexDesc, constraints []*ConstraintDesc) string {
+	buf := bytes.NewBufferString(TableDoc(tableName, tableComment, cols))
+	writeIndexDoc(buf, indexes)
+	if len(constraints) > 0 {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "constraints")
+		fmt.Fprintln(buf)
+		for _, constraint := range constraints {
+			fmt.Fprintf(buf, "- %s: %s", constraint.Name, constraint.OnelineDesc())
+			fmt.Fprintln(buf)
+		}
+	}
+	return buf.String()
+}
+
+func writeIndexDoc(buf *bytes.Buffer, indexes []*IndexDesc) {
+	if len(indexes) == 0 {
+		return
+	}
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "indexes")
+	fmt.Fprintln(buf)
+	for _, index := range indexes {
+		fmt.Fprintf(buf, "- %s: %s", index.Name, index.OnelineDesc())
+		fmt.Fprintln(buf)
+	}
+}
+
 func SubqueryDoc(name string, views []*parseutil.SubQueryView, dbCache *DBCache) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "%s subquery", name)
@@ -9,12 +9,19 @@ import (
 
 type MockDBRepository struct {
 	MockDatabase                      func(context.Context) (string, error)
+	MockServerVersion                 func(context.Context) (string, error)
 	MockDatabases                     func(context.Context) ([]string, error)
+	MockSearchPath                    func(context.Context) ([]string, error)
 	MockDatabaseTables                func(context.Context) (map[string][]string, error)
 	MockTables                        func(context.Context) ([]string, error)
 	MockDescribeTable                 func(context.Context, string) ([]*ColumnDesc, error)
 	MockDescribeDatabaseTable         func(context.Context) ([]*ColumnDesc, error)
 	MockDescribeDatabaseTableBySchema func(context.Context, string) ([]*ColumnDesc, error)
+	MockDescribeTableBySchema         func(context.Context, string, string) ([]*ColumnDesc, error)
+	MockDescribeTablesBySchema        func(context.Context, string) ([]*TableDesc, error)
+	MockDescribeIndexesBySchema       func(context.Context, string) ([]*IndexDesc, error)
+	MockDescribeConstraintsBySchema   func(context.Context, string) ([]*ConstraintDesc, error)
+	MockDescribeFunctionsBySchema     func(context.Context, string) ([]*FunctionDesc, error)
 	MockExec                          func(context.Context, string) (sql.Result, error)
 	MockQuery                         func(context.Context, string) (*sql.Rows, error)
 }
@@ -22,7 +29,9 @@ type MockDBRepository struct {
 func NewMockDBRepository(conn *sql.DB) DBRepository {
 	return &MockDBRepository{
 		MockDatabase:       func(ctx context.Context) (string, error) { return "world", nil },
+		MockServerVersion:  func(ctx context.Context) (string, error) { return "8.0.23", nil },
 		MockDatabases:      func(ctx context.Context) ([]string, error) { return dummyDatabases, nil },
+		MockSearchPath:     func(ctx context.Context) ([]string, error) { return []string{"world"}, nil },
 		MockDatabaseTables: func(ctx context.Context) (map[string][]string, error) { return dummyDatabaseTables, nil },
 		MockTables:         func(ctx context.Context) ([]string, error) { return dummyTables, nil },
 		MockDescribeTable: func(ctx context.Context, tableName string) ([]*ColumnDesc, error) {
@@ -52,6 +61,29 @@ func NewMockDBRepository(conn *sql.DB) DBRepository {
 			return res, nil
 
 		},
+		MockDescribeTableBySchema: func(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+			switch tableName {
+			case "city":
+				return dummyCityColumns, nil
+			case "country":
+				return dummyCountryColumns, nil
+			case "countrylanguage":
+				return dummyCountryLanguageColumns, nil
+			}
+			return nil, nil
+		},
+		MockDescribeTablesBySchema: func(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+			return dummyTableDescs, nil
+		},
+		MockDescribeIndexesBySchema: func(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+			return dummyIndexes, nil
+		},
+		MockDescribeConstraintsBySchema: func(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+			return dummyConstraints, nil
+		},
+		MockDescribeFunctionsBySchema: func(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+			return dummyFunctions, nil
+		},
 		MockExec: func(ctx context.Context, query string) (sql.Result, error) {
 			return &MockResult{
 				MockLastInsertID: func() (int64, error) { return 11, nil },
@@ -76,10 +108,18 @@ func (m *MockDBRepository) Databases(ctx context.Context) ([]string, error) {
 	return m.MockDatabases(ctx)
 }
 
+func (m *MockDBRepository) ServerVersion(ctx context.Context) (string, error) {
+	return m.MockServerVersion(ctx)
+}
+
 func (m *MockDBRepository) CurrentSchema(ctx context.Context) (string, error) {
 	return m.MockDatabase(ctx)
 }
 
+func (m *MockDBRepository) SearchPath(ctx context.Context) ([]string, error) {
+	return m.MockSearchPath(ctx)
+}
+
 func (m *MockDBRepository) Schemas(ctx context.Context) ([]string, error) {
 	return m.MockDatabases(ctx)
 }
@@ -100,6 +140,80 @@ func (m *MockDBRepository) DescribeDatabaseTableBySchema(ctx context.Context, sc
 	return m.MockDescribeDatabaseTableBySchema(ctx, schemaName)
 }
 
+func (m *MockDBRepository) DescribeTableBySchema(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+	return m.MockDescribeTableBySchema(ctx, schemaName, tableName)
+}
+
+func (m *MockDBRepository) DescribeTablesBySchema(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+	return m.MockDescribeTablesBySchema(ctx, schemaName)
+}
+
+func (m *MockDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	return m.MockDescribeIndexesBySchema(ctx, schemaName)
+}
+
+func (m *MockDBRepository) DescribeConstraintsBySchema(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+	return m.MockDescribeConstraintsBySchema(ctx, schemaName)
+}
+
+// DescribeTablesByNames filters the tables of MockDescribeTablesBySchema
+func (m *MockDBRepository) DescribeTablesByNames(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	descs, err := m.MockDescribeTablesBySchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []*TableDesc{}
+	for _, desc := range descs {
+		if containsName(tableNames, desc.Name) {
+			filtered = append(filtered, desc)
+		}
+	}
+	return filtered, nil
+}
+
+// DescribeIndexesByTables filters the indexes of MockDescribeIndexesBySchema
+func (m *MockDBRepository) DescribeIndexesByTables(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	descs, err := m.MockDescribeIndexesBySchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []*IndexDesc{}
+	for _, desc := range descs {
+		if containsName(tableNames, desc.Table) {
+			filtered = append(filtered, desc)
+		}
+	}
+	return filtered, nil
+}
+
+// DescribeConstraintsByTables filters the constraints of MockDescribeConstraintsBySchema
+func (m *MockDBRepository) DescribeConstraintsByTables(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	descs, err := m.MockDescribeConstraintsBySchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	filtered := []*ConstraintDesc{}
+	for _, desc := range descs {
+		if containsName(tableNames, desc.Table) {
+			filtered = append(filtered, desc)
+		}
+	}
+	return filtered, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockDBRepository) DescribeFunctionsBySchema(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+	return m.MockDescribeFunctionsBySchema(ctx, schemaName)
+}
+
 func (m *MockDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
 	return m.MockExec(ctx, query)
 }
@@ -206,7 +320,8 @@ var dummyCountryColumns = []*ColumnDesc{
 			String: "<null>",
 			Valid:  false,
 		},
-		Extra: "auto_increment",
+		Extra:   "auto_increment",
+		Comment: "ISO 3166-1 alpha-3 code",
 	},
 	{
 		Schema: "world",
@@ -446,6 +561,81 @@ var dummyCountryLanguageColumns = []*ColumnDesc{
 	},
 }
 
+var dummyTableDescs = []*TableDesc{
+	{
+		Schema: "world",
+		Name:   "city",
+	},
+	{
+		Schema:  "world",
+		Name:    "country",
+		Comment: "Countries of the world",
+	},
+	{
+		Schema: "world",
+		Name:   "countrylanguage",
+	},
+}
+
+var dummyIndexes = []*IndexDesc{
+	{
+		Schema:  "world",
+		Table:   "countrylanguage",
+		Name:    "PRIMARY",
+		Columns: []string{"CountryCode", "Language"},
+		Unique:  true,
+		Primary: true,
+		Method:  "BTREE",
+	},
+	{
+		Schema:  "world",
+		Table:   "countrylanguage",
+		Name:    "CountryCode",
+		Columns: []string{"CountryCode"},
+		Unique:  false,
+		Primary: false,
+		Method:  "BTREE",
+	},
+}
+var dummyConstraints = []*ConstraintDesc{
+	{
+		Schema:  "world",
+		Table:   "countrylanguage",
+		Name:    "PRIMARY",
+		Type:    ConstraintTypePrimaryKey,
+		Columns: []string{"CountryCode", "Language"},
+	},
+	{
+		Schema:     "world",
+		Table:      "countrylanguage",
+		Name:       "countrylanguage_chk_1",
+		Type:       ConstraintTypeCheck,
+		Definition: "CHECK (`Percentage` >= 0)",
+	},
+}
+
+var dummyFunctions = []*FunctionDesc{
+	{
+		Schema: "world",
+		Name:   "city_population",
+		Kind:   FunctionKindFunction,
+		Args: []*FunctionArg{
+			{Name: "city_id", Type: "int", Mode: "IN"},
+			{Name: "year", Type: "int", Mode: "IN"},
+		},
+		ReturnType: "bigint",
+	},
+	{
+		Schema: "world",
+		Name:   "refresh_country",
+		Kind:   FunctionKindProcedure,
+		Args: []*FunctionArg{
+			{Name: "country_code", Type: "char(3)", Mode: "IN"},
+			{Name: "updated", Type: "int", Mode: "OUT"},
+		},
+	},
+}
+
 type MockResult struct {
 	MockLastInsertID func() (int64, error)
 	MockRowsAffected func() (int64, error)
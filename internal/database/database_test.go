@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTableDoc(t *testing.T) {
+	cols := []*ColumnDesc{
+		{
+			Name:    "ID",
+			Type:    "int(11)",
+			Key:     "PRI",
+			Comment: "primary key",
+		},
+		{
+			Name: "Name",
+			Type: "char(35)",
+		},
+	}
+	tests := []struct {
+		name         string
+		tableComment string
+		want         string
+	}{
+		{
+			name: "without table comment",
+			want: "city table\n\n- ID: int(11) PRI -- primary key\n- Name: char(35)\n",
+		},
+		{
+			name:         "with table comment",
+			tableComment: "cities of the world",
+			want:         "city table\n\ncities of the world\n\n- ID: int(11) PRI -- primary key\n- Name: char(35)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TableDoc("city", tt.tableComment, cols)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
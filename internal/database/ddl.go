@@ -0,0 +1,161 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/lighttiger2505/sqls/dialect"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+)
+
+// tableObjectExecTypes are the DDL exec types that take table names after the TABLE or VIEW keyword.
+var tableObjectExecTypes = map[string]bool{
+	"CREATE TABLE":             true,
+	"CREATE TABLE AS":          true,
+	"ALTER TABLE":              true,
+	"DROP TABLE":               true,
+	"CREATE VIEW":              true,
+	"ALTER VIEW":               true,
+	"DROP VIEW":                true,
+	"CREATE MATERIALIZED VIEW": true,
+	"ALTER MATERIALIZED VIEW":  true,
+	"DROP MATERIALIZED VIEW":   true,
+	"CREATE FOREIGN TABLE":     true,
+	"ALTER FOREIGN TABLE":      true,
+	"DROP FOREIGN TABLE":       true,
+}
+
+// DDLTables returns the tables changed by the statement.
+// ok is false if the statement does not change the schema, tables is empty if the affected tables are unknown.
+// Unquoted names are folded to lower case for PostgreSQL, as the server does.
+func DDLTables(query string, driver dialect.DatabaseDriver) (tables []*parseutil.TableInfo, ok bool) {
+	foldLower := driver == dialect.DatabaseDriverPostgreSQL
+	words := ddlWords(query)
+	if len(words) == 0 {
+		return nil, false
+	}
+	upperWords := make([]string, len(words))
+	for i, w := range words {
+		upperWords[i] = strings.ToUpper(w)
+	}
+
+	execType, isQuery := QueryExecType(strings.Join(upperWords, " "), "")
+	if isQuery {
+		return nil, false
+	}
+	switch {
+	case tableObjectExecTypes[execType]:
+		return objectTables(words, upperWords, foldLower), true
+	case execType == "CREATE INDEX" || execType == "DROP INDEX":
+		// CREATE INDEX name ON table, DROP INDEX name ON table (mysql)
+		for i, w := range upperWords {
+			if w == "ON" {
+				return objectNames(words[i+1:], upperWords[i+1:], foldLower), true
+			}
+		}
+		return nil, true
+	case execType == "COMMENT":
+		// COMMENT ON TABLE table, COMMENT ON COLUMN table.column
+		if len(words) >= 4 && upperWords[1] == "ON" {
+			switch upperWords[2] {
+			case "TABLE", "VIEW":
+				return []*parseutil.TableInfo{ddlTableInfo(words[3], foldLower)}, true
+			case "COLUMN":
+				if i := strings.LastIndex(words[3], "."); i > 0 {
+					return []*parseutil.TableInfo{ddlTableInfo(words[3][:i], foldLower)}, true
+				}
+			}
+		}
+		return nil, true
+	case execType == "RENAME":
+		// RENAME TABLE old TO new, old2 TO new2 (mysql)
+		tables := []*parseutil.TableInfo{}
+		for i := 2; i < len(words); i++ {
+			switch upperWords[i] {
+			case "TO", ",", ";":
+			default:
+				tables = append(tables, ddlTableInfo(words[i], foldLower))
+			}
+		}
+		return tables, true
+	}
+
+	switch upperWords[0] {
+	case "CREATE", "ALTER", "DROP":
+		return nil, true
+	case "ATTACH", "DETACH":
+		// sqlite3 adds or removes a whole schema
+		return nil, true
+	}
+	return nil, false
+}
+
+func ddlWords(query string) []string {
+	r := strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ", ";", " ; ")
+	return strings.Fields(r.Replace(query))
+}
+
+func objectTables(words, upperWords []string, foldLower bool) []*parseutil.TableInfo {
+	for i, w := range upperWords {
+		if w != "TABLE" && w != "VIEW" {
+			continue
+		}
+		tables := objectNames(words[i+1:], upperWords[i+1:], foldLower)
+		// ALTER TABLE old RENAME TO new
+		for j := i + 1; j+1 < len(upperWords); j++ {
+			if upperWords[j] != "RENAME" {
+				continue
+			}
+			next := j + 1
+			switch upperWords[next] {
+			case "COLUMN", "INDEX", "KEY", "CONSTRAINT":
+				continue
+			case "TO", "AS":
+				next++
+			}
+			if next < len(words) {
+				tables = append(tables, ddlTableInfo(words[next], foldLower))
+			}
+		}
+		return tables
+	}
+	return nil
+}
+
+// objectNames reads the comma separated names, example "IF EXISTS a, b CASCADE"
+func objectNames(words, upperWords []string, foldLower bool) []*parseutil.TableInfo {
+	tables := []*parseutil.TableInfo{}
+	expectName := true
+	for i, w := range upperWords {
+		switch {
+		case w == "IF" || w == "NOT" || w == "EXISTS" || w == "ONLY":
+		case w == ",":
+			expectName = true
+		case expectName:
+			tables = append(tables, ddlTableInfo(words[i], foldLower))
+			expectName = false
+		default:
+			return tables
+		}
+	}
+	return tables
+}
+
+func ddlTableInfo(name string, foldLower bool) *parseutil.TableInfo {
+	unquote := func(s string) string {
+		if strings.HasPrefix(s, "\"") {
+			return strings.Trim(s, "\"")
+		}
+		if foldLower {
+			s = strings.ToLower(s)
+		}
+		return strings.Trim(s, "`[]")
+	}
+	info := &parseutil.TableInfo{}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		info.DatabaseSchema = unquote(name[:i])
+		info.Name = unquote(name[i+1:])
+	} else {
+		info.Name = unquote(name)
+	}
+	return info
+}
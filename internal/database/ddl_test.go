@@ -0,0 +1,128 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/lighttiger2505/sqls/dialect"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+)
+
+func TestDDLTables(t *testing.T) {
+	tests := []struct {
+		query  string
+		driver dialect.DatabaseDriver
+		want   []*parseutil.TableInfo
+		wantOK bool
+	}{
+		{
+			query:  "SELECT * FROM city",
+			want:   nil,
+			wantOK: false,
+		},
+		{
+			query:  "INSERT INTO city (ID) VALUES (1)",
+			want:   nil,
+			wantOK: false,
+		},
+		{
+			query:  "CREATE TABLE users (id int)",
+			want:   []*parseutil.TableInfo{{Name: "users"}},
+			wantOK: true,
+		},
+		{
+			query:  "create table if not exists app.users(id int)",
+			want:   []*parseutil.TableInfo{{DatabaseSchema: "app", Name: "users"}},
+			wantOK: true,
+		},
+		{
+			query:  "CREATE TEMPORARY TABLE `tmp_users` AS SELECT * FROM users",
+			want:   []*parseutil.TableInfo{{Name: "tmp_users"}},
+			wantOK: true,
+		},
+		{
+			query:  "ALTER TABLE city ADD COLUMN Area int",
+			want:   []*parseutil.TableInfo{{Name: "city"}},
+			wantOK: true,
+		},
+		{
+			query:  "ALTER TABLE city RENAME COLUMN Name TO CityName",
+			want:   []*parseutil.TableInfo{{Name: "city"}},
+			wantOK: true,
+		},
+		{
+			query:  "ALTER TABLE city RENAME TO town",
+			want:   []*parseutil.TableInfo{{Name: "city"}, {Name: "town"}},
+			wantOK: true,
+		},
+		{
+			query:  "DROP TABLE IF EXISTS city, \"world\".\"country\" CASCADE",
+			want:   []*parseutil.TableInfo{{Name: "city"}, {DatabaseSchema: "world", Name: "country"}},
+			wantOK: true,
+		},
+		{
+			query:  "CREATE OR REPLACE VIEW city_view AS SELECT * FROM city",
+			want:   []*parseutil.TableInfo{{Name: "city_view"}},
+			wantOK: true,
+		},
+		{
+			query:  "CREATE UNIQUE INDEX city_name ON city (Name)",
+			want:   []*parseutil.TableInfo{{Name: "city"}},
+			wantOK: true,
+		},
+		{
+			query:  "COMMENT ON COLUMN city.Name IS 'name of the city'",
+			want:   []*parseutil.TableInfo{{Name: "city"}},
+			wantOK: true,
+		},
+		{
+			query:  "RENAME TABLE city TO town, country TO nation",
+			want:   []*parseutil.TableInfo{{Name: "city"}, {Name: "town"}, {Name: "country"}, {Name: "nation"}},
+			wantOK: true,
+		},
+		{
+			query:  "DROP INDEX city_name",
+			want:   nil,
+			wantOK: true,
+		},
+		{
+			query:  "CREATE FUNCTION add(a int, b int) RETURNS int AS 'select a + b' LANGUAGE SQL",
+			want:   nil,
+			wantOK: true,
+		},
+		{
+			query:  "ATTACH DATABASE 'analytics.db' AS analytics",
+			want:   nil,
+			wantOK: true,
+		},
+		{
+			query:  "CREATE TABLE App.Users (id int)",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want:   []*parseutil.TableInfo{{DatabaseSchema: "app", Name: "users"}},
+			wantOK: true,
+		},
+		{
+			query:  "ALTER TABLE \"App\".\"Users\" ADD COLUMN Area int",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want:   []*parseutil.TableInfo{{DatabaseSchema: "App", Name: "Users"}},
+			wantOK: true,
+		},
+		{
+			query:  "CREATE TABLE Users (id int)",
+			driver: dialect.DatabaseDriverMySQL,
+			want:   []*parseutil.TableInfo{{Name: "Users"}},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, ok := DDLTables(tt.query, tt.driver)
+			if ok != tt.wantOK {
+				t.Errorf("ok: want %v, got %v", tt.wantOK, ok)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,40 +1,62 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
 
 	"github.com/lighttiger2505/sqls/dialect"
-	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 )
 
 var driverOpeners = make(map[dialect.DatabaseDriver]Opener)
 var driverFactories = make(map[dialect.DatabaseDriver]Factory)
+var driverDefaultPorts = make(map[dialect.DatabaseDriver]int)
+var driverDSNAddrs = make(map[dialect.DatabaseDriver]DSNAddr)
 
 type Opener func(*DBConfig) (*DBConnection, error)
 type Factory func(*sql.DB) DBRepository
 
+// DSNAddr reads and replaces the address of the database in a dataSourceName, which is needed to tunnel it through SSH.
+type DSNAddr interface {
+	Addr(dsn string) (host string, port int, err error)
+	Replace(dsn, host string, port int) (string, error)
+}
+
 type DBConnection struct {
-	Conn    *sql.DB
-	SSHConn *ssh.Client
+	Conn      *sql.DB
+	SSHTunnel *Tunnel
 }
 
 func (db *DBConnection) Close() error {
 	if db == nil {
 		return nil
 	}
-	if err := db.Conn.Close(); err != nil {
-		return err
+	if db.Conn != nil {
+		if err := db.Conn.Close(); err != nil {
+			return err
+		}
 	}
-	if db.SSHConn != nil {
-		if err := db.SSHConn.Close(); err != nil {
+	if db.SSHTunnel != nil {
+		if err := db.SSHTunnel.Close(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// Ping checks the connection is alive. A connection without *sql.DB like the mock one is always alive.
+func (db *DBConnection) Ping(ctx context.Context) error {
+	if db.Conn == nil {
+		return nil
+	}
+	return db.Conn.PingContext(ctx)
+}
+
 func RegisterOpen(name dialect.DatabaseDriver, opener Opener) {
 	if _, ok := driverOpeners[name]; ok {
 		panic(fmt.Sprintf("driver open %s method is already registered", name))
@@ -49,18 +71,142 @@ func RegisterFactory(name dialect.DatabaseDriver, factory Factory) {
 	driverFactories[name] = factory
 }
 
+// RegisterDefaultPort registers the port used when the config has none, which is needed to tunnel the driver through SSH.
+func RegisterDefaultPort(name dialect.DatabaseDriver, port int) {
+	if _, ok := driverDefaultPorts[name]; ok {
+		panic(fmt.Sprintf("driver default port %s already registered", name))
+	}
+	driverDefaultPorts[name] = port
+}
+
+// RegisterDSNAddr registers the way to tunnel the dataSourceName of the driver through SSH.
+func RegisterDSNAddr(name dialect.DatabaseDriver, addr DSNAddr) {
+	if _, ok := driverDSNAddrs[name]; ok {
+		panic(fmt.Sprintf("driver dsn addr %s already registered", name))
+	}
+	driverDSNAddrs[name] = addr
+}
+
+// TunnelsDSN reports whether the dataSourceName of the driver can be used with sshConfig.
+func TunnelsDSN(name dialect.DatabaseDriver) bool {
+	_, ok := driverDSNAddrs[name]
+	return ok
+}
+
 func Registered(name dialect.DatabaseDriver) bool {
 	_, ok1 := driverOpeners[name]
 	_, ok2 := driverFactories[name]
 	return ok1 && ok2
 }
 
+// Drivers returns the registered drivers in name order
+func Drivers() []dialect.DatabaseDriver {
+	drivers := []dialect.DatabaseDriver{}
+	for name := range driverOpeners {
+		if Registered(name) {
+			drivers = append(drivers, name)
+		}
+	}
+	sort.Slice(drivers, func(i, j int) bool {
+		return drivers[i] < drivers[j]
+	})
+	return drivers
+}
+
 func Open(cfg *DBConfig) (*DBConnection, error) {
 	OpenFn, ok := driverOpeners[cfg.Driver]
 	if !ok {
 		return nil, xerrors.Errorf("driver not found, %s", cfg.Driver)
 	}
-	return OpenFn(cfg)
+	resolved, err := resolveSecrets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.SSHCfg != nil {
+		return openViaSSH(OpenFn, resolved)
+	}
+	return OpenFn(resolved)
+}
+
+// openViaSSH opens a tunnel to the database server and connects the driver to the local end of it.
+func openViaSSH(openFn Opener, cfg *DBConfig) (*DBConnection, error) {
+	host, port, err := tunnelTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tunnel, err := OpenTunnel(cfg.SSHCfg, net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	local := tunnel.LocalAddr()
+	viaTunnel := *cfg
+	viaTunnel.SSHCfg = nil
+	if cfg.DataSourceName != "" {
+		viaTunnel.DataSourceName, err = driverDSNAddrs[cfg.Driver].Replace(cfg.DataSourceName, local.IP.String(), local.Port)
+		if err != nil {
+			tunnel.Close()
+			return nil, err
+		}
+	} else {
+		viaTunnel.Proto = ProtoTCP
+		viaTunnel.Host = local.IP.String()
+		viaTunnel.Port = local.Port
+		if cfg.TLS != nil {
+			tlsCfg := *cfg.TLS
+			if tlsCfg.ServerName == "" {
+				// the certificate is issued for the database host, not for the local end of the tunnel
+				tlsCfg.ServerName = host
+			}
+			if cfg.Driver == dialect.DatabaseDriverPostgreSQL && tlsCfg.effectiveMode() == TLSModeVerifyFull {
+				log.Println(postgresTunnelVerifyCAMessage)
+				tlsCfg.Mode = TLSModeVerifyCA
+			}
+			viaTunnel.TLS = &tlsCfg
+		}
+	}
+	if cfg.Driver == dialect.DatabaseDriverPostgreSQL {
+		// lib/pq looks up ~/.pgpass by the address it connects to, which is the local end of the tunnel
+		if err := setPgpassPassword(&viaTunnel, host, port); err != nil {
+			tunnel.Close()
+			return nil, err
+		}
+	}
+	conn, err := openFn(&viaTunnel)
+	if err != nil {
+		tunnel.Close()
+		return nil, err
+	}
+	conn.SSHTunnel = tunnel
+	return conn, nil
+}
+
+// tunnelTarget returns the address of the database which the tunnel forwards to
+func tunnelTarget(cfg *DBConfig) (string, int, error) {
+	if cfg.DataSourceName != "" {
+		addr, ok := driverDSNAddrs[cfg.Driver]
+		if !ok {
+			return "", 0, xerrors.Errorf("dataSourceName of %s cannot be used with sshConfig, set proto, host, port and the other fields instead", cfg.Driver)
+		}
+		host, port, err := addr.Addr(cfg.DataSourceName)
+		if err != nil {
+			return "", 0, xerrors.Errorf("cannot tunnel dataSourceName through ssh, %+v", err)
+		}
+		return host, port, nil
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = driverDefaultPorts[cfg.Driver]
+	}
+	if port == 0 {
+		return "", 0, xerrors.Errorf("driver %s cannot be used with sshConfig", cfg.Driver)
+	}
+	host := cfg.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return host, port, nil
 }
 
 func CreateRepository(driver dialect.DatabaseDriver, db *sql.DB) (DBRepository, error) {
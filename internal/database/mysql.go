@@ -6,41 +6,36 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/lighttiger2505/sqls/dialect"
-	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 )
 
 func init() {
 	RegisterOpen("mysql", mysqlOpen)
+	RegisterDefaultPort("mysql", 3306)
+	RegisterDSNAddr("mysql", mysqlDSNAddr{})
 	RegisterFactory("mysql", NewMySQLDBRepository)
 }
 
 func mysqlOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
-	var (
-		conn    *sql.DB
-		sshConn *ssh.Client
-	)
 	cfg, err := genMysqlConfig(dbConnCfg)
 	if err != nil {
 		return nil, err
 	}
-
-	if dbConnCfg.SSHCfg != nil {
-		dbConn, dbSSHConn, err := openMySQLViaSSH(cfg.FormatDSN(), dbConnCfg.SSHCfg)
+	var conn *sql.DB
+	if dbConnCfg.ReadOnly {
+		connector, err := newMySQLReadOnlyConnector(cfg)
 		if err != nil {
 			return nil, err
 		}
-		conn = dbConn
-		sshConn = dbSSHConn
+		conn = sql.OpenDB(connector)
 	} else {
-		dbConn, err := sql.Open("mysql", cfg.FormatDSN())
-		if err != nil {
+		if conn, err = sql.Open("mysql", cfg.FormatDSN()); err != nil {
 			return nil, err
 		}
-		conn = dbConn
 	}
 	if err := conn.Ping(); err != nil {
 		return nil, xerrors.Errorf("cannot ping to database, %+v", err)
@@ -50,41 +45,60 @@ func mysqlOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	conn.SetMaxOpenConns(DefaultMaxOpenConns)
 
 	return &DBConnection{
-		Conn:    conn,
-		SSHConn: sshConn,
+		Conn: conn,
 	}, nil
 }
 
-type MySQLViaSSHDialer struct {
-	client *ssh.Client
-}
-
-func (d *MySQLViaSSHDialer) Dial(ctx context.Context, addr string) (net.Conn, error) {
-	return d.client.Dial("tcp", addr)
-}
+// mysqlDSNAddr tunnels the dataSourceName of the tcp protocol
+type mysqlDSNAddr struct{}
 
-func openMySQLViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client, error) {
-	sshConfig, err := sshCfg.ClientConfig()
+func (mysqlDSNAddr) Addr(dsn string) (string, int, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", 0, err
+	}
+	if cfg.Net != "tcp" {
+		return "", 0, xerrors.Errorf("protocol %s cannot be tunneled", cfg.Net)
+	}
+	host, p, err := net.SplitHostPort(cfg.Addr)
 	if err != nil {
-		return nil, nil, err
+		return "", 0, err
 	}
-	sshConn, err := ssh.Dial("tcp", sshCfg.Endpoint(), sshConfig)
+	port, err := strconv.Atoi(p)
 	if err != nil {
-		return nil, nil, xerrors.Errorf("cannot ssh dial, %+v", err)
+		return "", 0, xerrors.Errorf("invalid port %q", p)
 	}
-	mysql.RegisterDialContext("mysql+tcp", (&MySQLViaSSHDialer{sshConn}).Dial)
-	conn, err := sql.Open("mysql", dsn)
+	return host, port, nil
+}
+
+func (mysqlDSNAddr) Replace(dsn, host string, port int) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
 	if err != nil {
-		return nil, nil, xerrors.Errorf("cannot connect database, %+v", err)
+		return "", err
+	}
+	if cfg.TLSConfig == "true" {
+		// the certificate is issued for the database host, not for the local end of the tunnel
+		dbHost, _, err := net.SplitHostPort(cfg.Addr)
+		if err != nil {
+			return "", err
+		}
+		if cfg.TLSConfig, err = mysqlTLSConfigName(&TLSConfig{Mode: TLSModeVerifyFull}, dbHost); err != nil {
+			return "", err
+		}
 	}
-	return conn, sshConn, nil
+	cfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	return cfg.FormatDSN(), nil
 }
 
 func genMysqlConfig(connCfg *DBConfig) (*mysql.Config, error) {
 	cfg := mysql.NewConfig()
 
 	if connCfg.DataSourceName != "" {
-		return mysql.ParseDSN(connCfg.DataSourceName)
+		cfg, err := mysql.ParseDSN(connCfg.DataSourceName)
+		if err != nil {
+			return nil, err
+		}
+		return cfg, nil
 	}
 
 	cfg.User = connCfg.User
@@ -113,6 +127,15 @@ func genMysqlConfig(connCfg *DBConfig) (*mysql.Config, error) {
 		return nil, fmt.Errorf("default addr for network %s unknown", connCfg.Proto)
 	}
 
+	if connCfg.TLS != nil {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		tlsName, err := mysqlTLSConfigName(connCfg.TLS, host)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLSConfig = tlsName
+	}
+
 	cfg.Params = connCfg.Params
 
 	return cfg, nil
@@ -130,6 +153,15 @@ func (db *MySQLDBRepository) Driver() dialect.DatabaseDriver {
 	return dialect.DatabaseDriverMySQL
 }
 
+func (db *MySQLDBRepository) ServerVersion(ctx context.Context) (string, error) {
+	row := db.Conn.QueryRowContext(ctx, "SELECT VERSION()")
+	var version string
+	if err := row.Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
 func (db *MySQLDBRepository) CurrentDatabase(ctx context.Context) (string, error) {
 	row := db.Conn.QueryRowContext(ctx, "SELECT DATABASE()")
 	var database string
@@ -159,6 +191,14 @@ func (db *MySQLDBRepository) CurrentSchema(ctx context.Context) (string, error)
 	return db.CurrentDatabase(ctx)
 }
 
+func (db *MySQLDBRepository) SearchPath(ctx context.Context) ([]string, error) {
+	schema, err := db.CurrentSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []string{schema}, nil
+}
+
 func (db *MySQLDBRepository) Schemas(ctx context.Context) ([]string, error) {
 	return db.Databases(ctx)
 }
@@ -223,7 +263,8 @@ SELECT
 	IS_NULLABLE,
 	COLUMN_KEY,
 	COLUMN_DEFAULT,
-	EXTRA
+	EXTRA,
+	COLUMN_COMMENT
 FROM information_schema.COLUMNS
 `)
 	if err != nil {
@@ -241,6 +282,7 @@ FROM information_schema.COLUMNS
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
@@ -262,7 +304,8 @@ SELECT
 	IS_NULLABLE,
 	COLUMN_KEY,
 	COLUMN_DEFAULT,
-	EXTRA
+	EXTRA,
+	COLUMN_COMMENT
 FROM information_schema.COLUMNS
 WHERE information_schema.COLUMNS.TABLE_SCHEMA = ?
 `, schemaName)
@@ -281,6 +324,7 @@ WHERE information_schema.COLUMNS.TABLE_SCHEMA = ?
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
@@ -290,6 +334,363 @@ WHERE information_schema.COLUMNS.TABLE_SCHEMA = ?
 	return tableInfos, nil
 }
 
+func (db *MySQLDBRepository) DescribeTableBySchema(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+SELECT
+	TABLE_SCHEMA,
+	TABLE_NAME,
+	COLUMN_NAME,
+	COLUMN_TYPE,
+	IS_NULLABLE,
+	COLUMN_KEY,
+	COLUMN_DEFAULT,
+	EXTRA,
+	COLUMN_COMMENT
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ?
+	AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION
+`, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tableInfos := []*ColumnDesc{}
+	for rows.Next() {
+		var tableInfo ColumnDesc
+		err := rows.Scan(
+			&tableInfo.Schema,
+			&tableInfo.Table,
+			&tableInfo.Name,
+			&tableInfo.Type,
+			&tableInfo.Null,
+			&tableInfo.Key,
+			&tableInfo.Default,
+			&tableInfo.Extra,
+			&tableInfo.Comment,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tableInfos = append(tableInfos, &tableInfo)
+	}
+	return tableInfos, nil
+}
+
+func (db *MySQLDBRepository) DescribeTablesBySchema(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+	return db.describeTables(ctx, schemaName, nil)
+}
+
+func (db *MySQLDBRepository) DescribeTablesByNames(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	if len(tableNames) == 0 {
+		return []*TableDesc{}, nil
+	}
+	return db.describeTables(ctx, schemaName, tableNames)
+}
+
+// describeTables describes the tables of the schema, only the given tables unless tableNames is nil
+func (db *MySQLDBRepository) describeTables(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	filter, args := mysqlTableFilter("TABLE_NAME", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+SELECT
+	TABLE_SCHEMA,
+	TABLE_NAME,
+	TABLE_COMMENT
+FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = ?`+filter+`
+ORDER BY TABLE_NAME
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tables := []*TableDesc{}
+	for rows.Next() {
+		var table TableDesc
+		if err := rows.Scan(&table.Schema, &table.Name, &table.Comment); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &table)
+	}
+	return tables, nil
+}
+
+func (db *MySQLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	return db.describeIndexes(ctx, schemaName, nil)
+}
+
+func (db *MySQLDBRepository) DescribeIndexesByTables(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	if len(tableNames) == 0 {
+		return []*IndexDesc{}, nil
+	}
+	return db.describeIndexes(ctx, schemaName, tableNames)
+}
+
+// mysqlIndexesQuery returns the query of the indexes with a row per column, the functional key parts of MySQL 8.0.13 have an expression instead
+func mysqlIndexesQuery(version, filter string) string {
+	column := `IFNULL(COLUMN_NAME, '')`
+	if !strings.Contains(version, "MariaDB") && mysqlVersionAtLeast(version, 8, 0, 13) {
+		column = `COALESCE(COLUMN_NAME, EXPRESSION, '')`
+	}
+	return `
+SELECT
+	TABLE_SCHEMA,
+	TABLE_NAME,
+	INDEX_NAME,
+	` + column + `,
+	NON_UNIQUE,
+	INDEX_TYPE
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = ?` + filter + `
+ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+`
+}
+
+func (db *MySQLDBRepository) describeIndexes(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	version, err := db.ServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter, args := mysqlTableFilter("TABLE_NAME", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(ctx, mysqlIndexesQuery(version, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	indexes := []*IndexDesc{}
+	var cur *IndexDesc
+	for rows.Next() {
+		var index IndexDesc
+		var column string
+		var nonUnique int
+		err := rows.Scan(
+			&index.Schema,
+			&index.Table,
+			&index.Name,
+			&column,
+			&nonUnique,
+			&index.Method,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// the columns are read one row each, the expressions may have commas
+		if cur == nil || cur.Table != index.Table || cur.Name != index.Name {
+			index.Unique = nonUnique == 0
+			index.Primary = index.Name == "PRIMARY"
+			cur = &index
+			indexes = append(indexes, cur)
+		}
+		cur.Columns = append(cur.Columns, column)
+	}
+	return indexes, nil
+}
+
+func (db *MySQLDBRepository) DescribeConstraintsBySchema(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+	return db.describeConstraints(ctx, schemaName, nil)
+}
+
+func (db *MySQLDBRepository) DescribeConstraintsByTables(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	if len(tableNames) == 0 {
+		return []*ConstraintDesc{}, nil
+	}
+	return db.describeConstraints(ctx, schemaName, tableNames)
+}
+
+// mysqlHasCheckConstraints reports whether the server has information_schema.CHECK_CONSTRAINTS,
+// which MySQL 8.0.16 and MariaDB 10.2.22 added. Older MySQL servers parse CHECK constraints but never store them.
+func mysqlHasCheckConstraints(version string) bool {
+	if strings.Contains(version, "MariaDB") {
+		return mysqlVersionAtLeast(version, 10, 2, 22)
+	}
+	return mysqlVersionAtLeast(version, 8, 0, 16)
+}
+
+// mysqlVersionAtLeast compares the leading "major.minor.patch" of the version like "8.0.32-0ubuntu0.22.04.2"
+func mysqlVersionAtLeast(version string, want ...int) bool {
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	nums := strings.Split(version, ".")
+	for i, w := range want {
+		if i >= len(nums) {
+			return false
+		}
+		n, err := strconv.Atoi(nums[i])
+		if err != nil {
+			return false
+		}
+		if n != w {
+			return n > w
+		}
+	}
+	return true
+}
+
+// mysqlConstraintsQuery returns the query of the constraints, with the clauses of CHECK constraints if the server has them
+func mysqlConstraintsQuery(version, filter string) string {
+	checkClause, checkJoin := `''`, ""
+	if mysqlHasCheckConstraints(version) {
+		checkClause = `IFNULL(cc.CHECK_CLAUSE, '')`
+		checkJoin = `
+LEFT JOIN information_schema.CHECK_CONSTRAINTS cc
+	ON cc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+	AND cc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+	AND tc.CONSTRAINT_TYPE = 'CHECK'`
+		if strings.Contains(version, "MariaDB") {
+			// the names of CHECK constraints are unique per table on MariaDB
+			checkJoin += `
+	AND cc.TABLE_NAME = tc.TABLE_NAME`
+		}
+	}
+	return `
+SELECT
+	tc.TABLE_SCHEMA,
+	tc.TABLE_NAME,
+	tc.CONSTRAINT_NAME,
+	tc.CONSTRAINT_TYPE,
+	IFNULL(kcu.COLUMN_NAME, ''),
+	` + checkClause + `
+FROM information_schema.TABLE_CONSTRAINTS tc
+LEFT JOIN information_schema.KEY_COLUMN_USAGE kcu
+	ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+	AND kcu.TABLE_NAME = tc.TABLE_NAME
+	AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME` + checkJoin + `
+WHERE tc.TABLE_SCHEMA = ?
+	AND tc.CONSTRAINT_TYPE IN ('PRIMARY KEY', 'UNIQUE', 'CHECK')` + filter + `
+ORDER BY tc.TABLE_NAME, tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+`
+}
+
+func (db *MySQLDBRepository) describeConstraints(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	version, err := db.ServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter, args := mysqlTableFilter("tc.TABLE_NAME", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(ctx, mysqlConstraintsQuery(version, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	constraints := []*ConstraintDesc{}
+	var cur *ConstraintDesc
+	for rows.Next() {
+		var constraint ConstraintDesc
+		var column, checkClause string
+		err := rows.Scan(
+			&constraint.Schema,
+			&constraint.Table,
+			&constraint.Name,
+			&constraint.Type,
+			&column,
+			&checkClause,
+		)
+		if err != nil {
+			return nil, err
+		}
+		// the columns are read one row each like the indexes
+		if cur == nil || cur.Table != constraint.Table || cur.Name != constraint.Name {
+			if checkClause != "" {
+				constraint.Definition = mysqlCheckDefinition(checkClause)
+			}
+			cur = &constraint
+			constraints = append(constraints, cur)
+		}
+		if column != "" {
+			cur.Columns = append(cur.Columns, column)
+		}
+	}
+	return constraints, nil
+}
+
+// mysqlCheckDefinition returns the definition of the CHECK constraint like PostgreSQL shows it,
+// CHECK_CLAUSE has the parentheses on MySQL like "(`Percentage` >= 0)" but not on MariaDB
+func mysqlCheckDefinition(clause string) string {
+	if !enclosedInParens(clause) {
+		clause = "(" + clause + ")"
+	}
+	return "CHECK " + clause
+}
+
+// enclosedInParens reports whether the whole expression is in a pair of parentheses, unlike "(a > 0) AND (b > 0)"
+func enclosedInParens(expr string) bool {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func (db *MySQLDBRepository) DescribeFunctionsBySchema(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+SELECT
+	r.ROUTINE_SCHEMA,
+	r.ROUTINE_NAME,
+	r.ROUTINE_TYPE,
+	IFNULL(r.DTD_IDENTIFIER, ''),
+	IFNULL(p.PARAMETER_MODE, ''),
+	IFNULL(p.PARAMETER_NAME, ''),
+	IFNULL(p.DTD_IDENTIFIER, '')
+FROM information_schema.ROUTINES r
+LEFT JOIN information_schema.PARAMETERS p
+	ON p.SPECIFIC_SCHEMA = r.ROUTINE_SCHEMA
+	AND p.SPECIFIC_NAME = r.SPECIFIC_NAME
+	AND p.ROUTINE_TYPE = r.ROUTINE_TYPE
+	AND p.ORDINAL_POSITION > 0
+WHERE r.ROUTINE_SCHEMA = ?
+ORDER BY r.ROUTINE_NAME, r.ROUTINE_TYPE, p.ORDINAL_POSITION
+`, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	funcs := []*FunctionDesc{}
+	var cur *FunctionDesc
+	for rows.Next() {
+		var fd FunctionDesc
+		var arg FunctionArg
+		err := rows.Scan(
+			&fd.Schema,
+			&fd.Name,
+			&fd.Kind,
+			&fd.ReturnType,
+			&arg.Mode,
+			&arg.Name,
+			&arg.Type,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if cur == nil || cur.Name != fd.Name || cur.Kind != fd.Kind {
+			fd.Args = []*FunctionArg{}
+			cur = &fd
+			funcs = append(funcs, cur)
+		}
+		if arg.Type != "" {
+			cur.Args = append(cur.Args, &arg)
+		}
+	}
+	return funcs, nil
+}
+
 func (db *MySQLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
 	return db.Conn.ExecContext(ctx, query)
 }
@@ -297,3 +698,17 @@ func (db *MySQLDBRepository) Exec(ctx context.Context, query string) (sql.Result
 func (db *MySQLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
 	return db.Conn.QueryContext(ctx, query)
 }
+
+// mysqlTableFilter returns the condition limiting column to tableNames and the query arguments, the condition is empty for nil tableNames
+func mysqlTableFilter(column, schemaName string, tableNames []string) (string, []interface{}) {
+	args := []interface{}{schemaName}
+	if tableNames == nil {
+		return "", args
+	}
+	placeholders := make([]string, len(tableNames))
+	for i, name := range tableNames {
+		placeholders[i] = "?"
+		args = append(args, name)
+	}
+	return "\n\tAND " + column + " IN (" + strings.Join(placeholders, ", ") + ")", args
+}
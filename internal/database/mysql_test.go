@@ -0,0 +1,132 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMySQLDSNAddr(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsn         string
+		wantHost    string
+		wantPort    int
+		wantReplace string
+		wantErr     bool
+	}{
+		{
+			name:        "tcp",
+			dsn:         "root:root@tcp(db.example.com:13306)/world",
+			wantHost:    "db.example.com",
+			wantPort:    13306,
+			wantReplace: "root:root@tcp(127.0.0.1:40000)/world",
+		},
+		{
+			name:        "default address",
+			dsn:         "root:root@/world",
+			wantHost:    "127.0.0.1",
+			wantPort:    3306,
+			wantReplace: "root:root@tcp(127.0.0.1:40000)/world",
+		},
+		{
+			name:    "unix socket",
+			dsn:     "root:root@unix(/var/run/mysqld/mysqld.sock)/world",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := mysqlDSNAddr{}
+			host, port, err := addr.Addr(tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Addr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("Addr() = %s:%d, want %s:%d", host, port, tt.wantHost, tt.wantPort)
+			}
+			got, err := addr.Replace(tt.dsn, "127.0.0.1", 40000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.wantReplace {
+				t.Errorf("Replace() = %s, want %s", got, tt.wantReplace)
+			}
+		})
+	}
+
+	// the certificate is verified against the database host through the tunnel
+	got, err := mysqlDSNAddr{}.Replace("root:root@tcp(db.example.com:3306)/world?tls=true", "127.0.0.1", 40000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "tls=sqls-") {
+		t.Errorf("TLS config is not registered, %s", got)
+	}
+}
+
+func TestMySQLConstraintsQuery(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantCheck bool
+	}{
+		{version: "5.7.44-log", wantCheck: false},
+		{version: "8.0.15", wantCheck: false},
+		{version: "8.0.16", wantCheck: true},
+		{version: "8.0.32-0ubuntu0.22.04.2", wantCheck: true},
+		{version: "10.2.21-MariaDB", wantCheck: false},
+		{version: "10.11.6-MariaDB-0+deb12u1", wantCheck: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got := strings.Contains(mysqlConstraintsQuery(tt.version, ""), "CHECK_CONSTRAINTS")
+			if got != tt.wantCheck {
+				t.Errorf("CHECK_CONSTRAINTS is queried %v, want %v", got, tt.wantCheck)
+			}
+		})
+	}
+}
+
+func TestMySQLCheckDefinition(t *testing.T) {
+	tests := []struct {
+		name   string
+		clause string
+		want   string
+	}{
+		{name: "mysql", clause: "(`Percentage` >= 0)", want: "CHECK (`Percentage` >= 0)"},
+		{name: "mariadb", clause: "`Percentage` >= 0", want: "CHECK (`Percentage` >= 0)"},
+		{name: "mariadb and", clause: "(`a` > 0) and (`b` > 0)", want: "CHECK ((`a` > 0) and (`b` > 0))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mysqlCheckDefinition(tt.clause); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLIndexesQuery(t *testing.T) {
+	tests := []struct {
+		version        string
+		wantExpression bool
+	}{
+		{version: "5.7.44-log", wantExpression: false},
+		{version: "8.0.12", wantExpression: false},
+		{version: "8.0.13", wantExpression: true},
+		{version: "10.11.6-MariaDB", wantExpression: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			query := mysqlIndexesQuery(tt.version, "")
+			if got := strings.Contains(query, "EXPRESSION"); got != tt.wantExpression {
+				t.Errorf("EXPRESSION is queried %v, want %v", got, tt.wantExpression)
+			}
+			if strings.Contains(query, "GROUP_CONCAT") {
+				t.Error("the columns are concatenated")
+			}
+		})
+	}
+}
@@ -3,50 +3,34 @@ package database
 import (
 	"context"
 	"database/sql"
-	"database/sql/driver"
 	"log"
-	"net"
 	"net/url"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 	"unicode"
 
-	pq "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/lighttiger2505/sqls/dialect"
-	"golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 )
 
 func init() {
 	RegisterOpen("postgresql", postgreSQLOpen)
+	RegisterDefaultPort("postgresql", 5432)
+	RegisterDSNAddr("postgresql", postgresDSNAddr{})
 	RegisterFactory("postgresql", NewPostgreSQLDBRepository)
 }
 
 func postgreSQLOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
-	var (
-		conn    *sql.DB
-		sshConn *ssh.Client
-	)
 	dsn, err := genPostgresConfig(dbConnCfg)
 	if err != nil {
 		return nil, err
 	}
-
-	if dbConnCfg.SSHCfg != nil {
-		dbConn, dbSSHConn, err := openPostgreSQLViaSSH(dsn, dbConnCfg.SSHCfg)
-		if err != nil {
-			return nil, err
-		}
-		conn = dbConn
-		sshConn = dbSSHConn
-	} else {
-		dbConn, err := sql.Open("postgres", dsn)
-		if err != nil {
-			return nil, err
-		}
-		conn = dbConn
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
 	}
 	if err := conn.Ping(); err != nil {
 		return nil, err
@@ -56,55 +40,10 @@ func postgreSQLOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	conn.SetMaxOpenConns(DefaultMaxOpenConns)
 
 	return &DBConnection{
-		Conn:    conn,
-		SSHConn: sshConn,
+		Conn: conn,
 	}, nil
 }
 
-type PostgreSQLViaSSHDialer struct {
-	client *ssh.Client
-}
-
-func (d *PostgreSQLViaSSHDialer) Open(s string) (_ driver.Conn, err error) {
-	return pq.DialOpen(d, s)
-}
-
-func (d *PostgreSQLViaSSHDialer) Dial(network, address string) (net.Conn, error) {
-	return d.client.Dial(network, address)
-}
-
-func (d *PostgreSQLViaSSHDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
-	return d.client.Dial(network, address)
-}
-
-var (
-	sshConnCount int = 1
-)
-
-func openPostgreSQLViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client, error) {
-	sshConfig, err := sshCfg.ClientConfig()
-	if err != nil {
-		return nil, nil, err
-	}
-	sshConn, err := ssh.Dial("tcp", sshCfg.Endpoint(), sshConfig)
-	if err != nil {
-		return nil, nil, xerrors.Errorf("cannot ssh dial, %+v", err)
-	}
-
-	// NOTE: This is a workaround to avoid the panic that occurs in the specifications of sql.driver
-	// See https://pkg.go.dev/database/sql#Register
-	// > If Register is called twice with the same name or if driver is nil, it panics
-	viaSSHDriver := "postgres+ssh" + strconv.Itoa(sshConnCount)
-	sql.Register(viaSSHDriver, &PostgreSQLViaSSHDialer{sshConn})
-	sshConnCount++
-
-	conn, err := sql.Open(viaSSHDriver, dsn)
-	if err != nil {
-		return nil, nil, xerrors.Errorf("cannot connect database, %+v", err)
-	}
-	return conn, sshConn, nil
-}
-
 type PostgreSQLDBRepository struct {
 	Conn *sql.DB
 }
@@ -117,6 +56,15 @@ func (db *PostgreSQLDBRepository) Driver() dialect.DatabaseDriver {
 	return dialect.DatabaseDriverPostgreSQL
 }
 
+func (db *PostgreSQLDBRepository) ServerVersion(ctx context.Context) (string, error) {
+	row := db.Conn.QueryRowContext(ctx, "SHOW server_version")
+	var version string
+	if err := row.Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
 func (db *PostgreSQLDBRepository) CurrentDatabase(ctx context.Context) (string, error) {
 	row := db.Conn.QueryRowContext(ctx, "SELECT current_database()")
 	var database string
@@ -155,6 +103,30 @@ func (db *PostgreSQLDBRepository) CurrentSchema(ctx context.Context) (string, er
 	return database, nil
 }
 
+// SearchPath returns the existing schemas of the effective search_path in order, implicit schemas such as pg_catalog are excluded.
+func (db *PostgreSQLDBRepository) SearchPath(ctx context.Context) ([]string, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT s.schema_name
+	FROM unnest(current_schemas(false)) WITH ORDINALITY AS s(schema_name, pos)
+	ORDER BY s.pos
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schemas := []string{}
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
 func (db *PostgreSQLDBRepository) Schemas(ctx context.Context) ([]string, error) {
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -250,7 +222,8 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 			ELSE 'NO'
 		END,
 		c.column_default,
-		''
+		'',
+		COALESCE(pgd.description, '')
 	FROM
 		information_schema.columns c
 	LEFT JOIN
@@ -262,6 +235,12 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 		AND tc.table_schema = c.table_schema
 		AND tc.table_name = c.table_name
 		AND tc.constraint_name = ccu.constraint_name
+	LEFT JOIN pg_catalog.pg_statio_all_tables st ON
+		st.schemaname = c.table_schema
+		AND st.relname = c.table_name
+	LEFT JOIN pg_catalog.pg_description pgd ON
+		pgd.objoid = st.relid
+		AND pgd.objsubid = c.ordinal_position
 	ORDER BY
 		c.table_name,
 		c.ordinal_position
@@ -281,6 +260,7 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
@@ -305,7 +285,8 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 			ELSE 'NO'
 		END,
 		c.column_default,
-		''
+		'',
+		COALESCE(pgd.description, '')
 	FROM
 		information_schema.columns c
 	LEFT JOIN
@@ -317,6 +298,12 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 		AND tc.table_schema = c.table_schema
 		AND tc.table_name = c.table_name
 		AND tc.constraint_name = ccu.constraint_name
+	LEFT JOIN pg_catalog.pg_statio_all_tables st ON
+		st.schemaname = c.table_schema
+		AND st.relname = c.table_name
+	LEFT JOIN pg_catalog.pg_description pgd ON
+		pgd.objoid = st.relid
+		AND pgd.objsubid = c.ordinal_position
 	WHERE
 		c.table_schema = $1
 	ORDER BY
@@ -338,6 +325,7 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
@@ -347,6 +335,360 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 	return tableInfos, nil
 }
 
+func (db *PostgreSQLDBRepository) DescribeTableBySchema(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+		c.table_schema,
+		c.table_name,
+		c.column_name,
+		c.data_type,
+		c.is_nullable,
+		CASE tc.constraint_type
+			WHEN 'PRIMARY KEY' THEN 'YES'
+			ELSE 'NO'
+		END,
+		c.column_default,
+		'',
+		COALESCE(pgd.description, '')
+	FROM
+		information_schema.columns c
+	LEFT JOIN
+		information_schema.constraint_column_usage ccu
+		ON c.table_name = ccu.table_name
+		AND c.column_name = ccu.column_name
+	LEFT JOIN information_schema.table_constraints tc ON
+		tc.table_catalog = c.table_catalog
+		AND tc.table_schema = c.table_schema
+		AND tc.table_name = c.table_name
+		AND tc.constraint_name = ccu.constraint_name
+	LEFT JOIN pg_catalog.pg_statio_all_tables st ON
+		st.schemaname = c.table_schema
+		AND st.relname = c.table_name
+	LEFT JOIN pg_catalog.pg_description pgd ON
+		pgd.objoid = st.relid
+		AND pgd.objsubid = c.ordinal_position
+	WHERE
+		c.table_schema = $1
+		AND c.table_name = $2
+	ORDER BY
+		c.ordinal_position
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tableInfos := []*ColumnDesc{}
+	for rows.Next() {
+		var tableInfo ColumnDesc
+		err := rows.Scan(
+			&tableInfo.Schema,
+			&tableInfo.Table,
+			&tableInfo.Name,
+			&tableInfo.Type,
+			&tableInfo.Null,
+			&tableInfo.Key,
+			&tableInfo.Default,
+			&tableInfo.Extra,
+			&tableInfo.Comment,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tableInfos = append(tableInfos, &tableInfo)
+	}
+	return tableInfos, nil
+}
+
+func (db *PostgreSQLDBRepository) DescribeTablesBySchema(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+	return db.describeTables(ctx, schemaName, nil)
+}
+
+func (db *PostgreSQLDBRepository) DescribeTablesByNames(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	if len(tableNames) == 0 {
+		return []*TableDesc{}, nil
+	}
+	return db.describeTables(ctx, schemaName, tableNames)
+}
+
+// describeTables describes the tables of the schema, only the given tables unless tableNames is nil
+func (db *PostgreSQLDBRepository) describeTables(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	filter, args := postgresTableFilter("c.relname", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+		n.nspname,
+		c.relname,
+		COALESCE(obj_description(c.oid, 'pg_class'), '')
+	FROM
+		pg_class c
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE
+		c.relkind IN ('r', 'v', 'm', 'p', 'f')
+		AND n.nspname = $1`+filter+`
+	ORDER BY
+		c.relname
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tables := []*TableDesc{}
+	for rows.Next() {
+		var table TableDesc
+		if err := rows.Scan(&table.Schema, &table.Name, &table.Comment); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &table)
+	}
+	return tables, nil
+}
+
+func (db *PostgreSQLDBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	return db.describeIndexes(ctx, schemaName, nil)
+}
+
+func (db *PostgreSQLDBRepository) DescribeIndexesByTables(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	if len(tableNames) == 0 {
+		return []*IndexDesc{}, nil
+	}
+	return db.describeIndexes(ctx, schemaName, tableNames)
+}
+
+func (db *PostgreSQLDBRepository) describeIndexes(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	filter, args := postgresTableFilter("t.relname", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+		n.nspname,
+		t.relname,
+		i.relname,
+		ARRAY(
+			SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+			FROM generate_subscripts(ix.indkey, 1) AS k
+			ORDER BY k
+		),
+		ix.indisunique,
+		ix.indisprimary,
+		am.amname
+	FROM
+		pg_index ix
+	JOIN pg_class t ON t.oid = ix.indrelid
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	JOIN pg_am am ON am.oid = i.relam
+	WHERE
+		n.nspname = $1`+filter+`
+	ORDER BY
+		t.relname,
+		i.relname
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	indexes := []*IndexDesc{}
+	for rows.Next() {
+		var index IndexDesc
+		// scanned as an array, the expressions like "lower((a || ',' || b))" may have commas
+		err := rows.Scan(
+			&index.Schema,
+			&index.Table,
+			&index.Name,
+			pq.Array(&index.Columns),
+			&index.Unique,
+			&index.Primary,
+			&index.Method,
+		)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, &index)
+	}
+	return indexes, nil
+}
+
+func (db *PostgreSQLDBRepository) DescribeConstraintsBySchema(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+	return db.describeConstraints(ctx, schemaName, nil)
+}
+
+func (db *PostgreSQLDBRepository) DescribeConstraintsByTables(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	if len(tableNames) == 0 {
+		return []*ConstraintDesc{}, nil
+	}
+	return db.describeConstraints(ctx, schemaName, tableNames)
+}
+
+func (db *PostgreSQLDBRepository) describeConstraints(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	filter, args := postgresTableFilter("t.relname", schemaName, tableNames)
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+		n.nspname,
+		t.relname,
+		c.conname,
+		CASE c.contype
+			WHEN 'p' THEN 'PRIMARY KEY'
+			WHEN 'u' THEN 'UNIQUE'
+			ELSE 'CHECK'
+		END,
+		ARRAY(
+			SELECT a.attname::text
+			FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+			ORDER BY k.ord
+		),
+		pg_get_constraintdef(c.oid, true)
+	FROM
+		pg_constraint c
+	JOIN pg_class t ON t.oid = c.conrelid
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	WHERE
+		c.contype IN ('p', 'u', 'c')
+		AND n.nspname = $1`+filter+`
+	ORDER BY
+		t.relname,
+		c.conname
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	constraints := []*ConstraintDesc{}
+	for rows.Next() {
+		var constraint ConstraintDesc
+		var columns []string
+		err := rows.Scan(
+			&constraint.Schema,
+			&constraint.Table,
+			&constraint.Name,
+			&constraint.Type,
+			pq.Array(&columns),
+			&constraint.Definition,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) > 0 {
+			constraint.Columns = columns
+		}
+		constraints = append(constraints, &constraint)
+	}
+	return constraints, nil
+}
+
+var postgresqlArgModes = map[string]string{
+	"i": "IN",
+	"o": "OUT",
+	"b": "INOUT",
+	"v": "VARIADIC",
+	"t": "TABLE",
+}
+
+// postgresProkindVersion is the server_version_num of PostgreSQL 11, which added procedures and pg_proc.prokind
+const postgresProkindVersion = 110000
+
+func (db *PostgreSQLDBRepository) serverVersionNum(ctx context.Context) (int, error) {
+	row := db.Conn.QueryRowContext(ctx, "SHOW server_version_num")
+	var version int
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// postgresFunctionsQuery returns the query of the functions for the server version, older servers have no procedures
+func postgresFunctionsQuery(versionNum int) string {
+	kind := `'FUNCTION'`
+	if versionNum >= postgresProkindVersion {
+		kind = `CASE p.prokind
+			WHEN 'p' THEN 'PROCEDURE'
+			ELSE 'FUNCTION'
+		END`
+	}
+	return `
+	SELECT
+		n.nspname,
+		p.proname,
+		` + kind + `,
+		COALESCE(array_to_string(p.proargnames, ',', ''), ''),
+		COALESCE(array_to_string(p.proargmodes, ','), ''),
+		array_to_string(
+			ARRAY(
+				SELECT format_type(a.t, NULL)
+				FROM unnest(COALESCE(p.proallargtypes, p.proargtypes::oid[])) WITH ORDINALITY AS a(t, ord)
+				ORDER BY a.ord
+			),
+			','
+		),
+		COALESCE(pg_get_function_result(p.oid), '')
+	FROM
+		pg_proc p
+	JOIN pg_namespace n ON n.oid = p.pronamespace
+	WHERE
+		n.nspname = $1
+	ORDER BY
+		p.proname
+	`
+}
+
+func (db *PostgreSQLDBRepository) DescribeFunctionsBySchema(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+	versionNum, err := db.serverVersionNum(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Conn.QueryContext(ctx, postgresFunctionsQuery(versionNum), schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	funcs := []*FunctionDesc{}
+	for rows.Next() {
+		var fd FunctionDesc
+		var names, modes, types string
+		err := rows.Scan(
+			&fd.Schema,
+			&fd.Name,
+			&fd.Kind,
+			&names,
+			&modes,
+			&types,
+			&fd.ReturnType,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fd.Args = genPostgresqlFunctionArgs(names, modes, types)
+		funcs = append(funcs, &fd)
+	}
+	return funcs, nil
+}
+
+func genPostgresqlFunctionArgs(names, modes, types string) []*FunctionArg {
+	if types == "" {
+		return []*FunctionArg{}
+	}
+	typeList := strings.Split(types, ",")
+	nameList := strings.Split(names, ",")
+	modeList := strings.Split(modes, ",")
+	args := make([]*FunctionArg, len(typeList))
+	for i, typ := range typeList {
+		arg := &FunctionArg{Type: typ}
+		if names != "" && i < len(nameList) {
+			arg.Name = nameList[i]
+		}
+		if modes != "" && i < len(modeList) {
+			arg.Mode = postgresqlArgModes[modeList[i]]
+		}
+		args[i] = arg
+	}
+	return args
+}
+
 func (db *PostgreSQLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
 	return db.Conn.ExecContext(ctx, query)
 }
@@ -357,6 +699,9 @@ func (db *PostgreSQLDBRepository) Query(ctx context.Context, query string) (*sql
 
 func genPostgresConfig(connCfg *DBConfig) (string, error) {
 	if connCfg.DataSourceName != "" {
+		if connCfg.ReadOnly {
+			return postgresReadOnlyDSN(connCfg.DataSourceName)
+		}
 		return connCfg.DataSourceName, nil
 	}
 
@@ -382,13 +727,156 @@ func genPostgresConfig(connCfg *DBConfig) (string, error) {
 		return "", xerrors.Errorf("default addr for network %s unknown", connCfg.Proto)
 	}
 
+	if connCfg.TLS != nil {
+		if err := setPostgresTLSOptions(q, connCfg.TLS, q.Get("host")); err != nil {
+			return "", err
+		}
+	}
+
 	for k, v := range connCfg.Params {
 		q.Set(k, v)
 	}
+	if connCfg.ReadOnly {
+		q.Set("default_transaction_read_only", "on")
+	}
 
 	return genOptions(q, "", "=", " ", ",", true), nil
 }
 
+// postgresTableFilter returns the condition limiting column to tableNames and the query arguments, the condition is empty for nil tableNames
+func postgresTableFilter(column, schemaName string, tableNames []string) (string, []interface{}) {
+	if tableNames == nil {
+		return "", []interface{}{schemaName}
+	}
+	return "\n\t\tAND " + column + " = ANY($2)", []interface{}{schemaName, pq.Array(tableNames)}
+}
+
+// postgresDSNAddr tunnels both the URL and the key/value dataSourceName, the URL is converted to key/value form
+type postgresDSNAddr struct{}
+
+func (postgresDSNAddr) Addr(dsn string) (string, int, error) {
+	opts, err := parsePostgresDSN(dsn)
+	if err != nil {
+		return "", 0, err
+	}
+	// the defaults of lib/pq
+	host := postgresOption(opts, "host", "PGHOST", "localhost")
+	if strings.HasPrefix(host, "/") {
+		return "", 0, xerrors.Errorf("unix socket %s cannot be tunneled", host)
+	}
+	p := postgresOption(opts, "port", "PGPORT", "5432")
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return "", 0, xerrors.Errorf("invalid port %q", p)
+	}
+	return host, port, nil
+}
+
+// postgresTunnelVerifyCAMessage tells that verify-full is replaced through an SSH tunnel,
+// since lib/pq verifies the host name of the certificate against the host it connects to.
+const postgresTunnelVerifyCAMessage = "tls mode verify-full of postgresql cannot verify the host name through the SSH tunnel, only the certificate chain is verified as verify-ca"
+
+func (postgresDSNAddr) Replace(dsn, host string, port int) (string, error) {
+	opts, err := parsePostgresDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	opts["host"] = host
+	opts["port"] = strconv.Itoa(port)
+	if postgresOption(opts, "sslmode", "PGSSLMODE", "") == string(TLSModeVerifyFull) {
+		log.Println(postgresTunnelVerifyCAMessage)
+		opts["sslmode"] = string(TLSModeVerifyCA)
+	}
+	return formatPostgresOptions(opts), nil
+}
+
+// postgresOption returns the option, or the environment variable lib/pq reads instead, or the default
+func postgresOption(opts map[string]string, key, env, def string) string {
+	if v := opts[key]; v != "" {
+		return v
+	}
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return def
+}
+
+// parsePostgresDSN parses the key/value options like "host=localhost port=5432", or the URL
+func parsePostgresDSN(dsn string) (map[string]string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		var err error
+		if dsn, err = pq.ParseURL(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := map[string]string{}
+	r := []rune(dsn)
+	i := 0
+	skipSpaces := func() {
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+	}
+	for {
+		skipSpaces()
+		if i >= len(r) {
+			return opts, nil
+		}
+		start := i
+		for i < len(r) && r[i] != '=' && !unicode.IsSpace(r[i]) {
+			i++
+		}
+		key := string(r[start:i])
+		skipSpaces()
+		if i >= len(r) || r[i] != '=' {
+			return nil, xerrors.Errorf("missing \"=\" after %q in dataSourceName", key)
+		}
+		i++
+		skipSpaces()
+
+		var val []rune
+		quoted := i < len(r) && r[i] == '\''
+		if quoted {
+			i++
+		}
+		for ; i < len(r); i++ {
+			if quoted && r[i] == '\'' {
+				break
+			}
+			if !quoted && unicode.IsSpace(r[i]) {
+				break
+			}
+			if r[i] == '\\' && i+1 < len(r) {
+				i++
+			}
+			val = append(val, r[i])
+		}
+		if quoted {
+			if i >= len(r) {
+				return nil, xerrors.Errorf("unterminated quoted value of %q in dataSourceName", key)
+			}
+			i++
+		}
+		opts[key] = string(val)
+	}
+}
+
+// formatPostgresOptions generates the key/value options, quoting every value
+func formatPostgresOptions(opts map[string]string) string {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	options := make([]string, len(keys))
+	for i, k := range keys {
+		options[i] = k + "='" + r.Replace(opts[k]) + "'"
+	}
+	return strings.Join(options, " ")
+}
+
 // genOptions takes URL values and generates options, joining together with
 // joiner, and separated by sep, with any multi URL values joined by valSep,
 // ignoring any values with keys in ignore.
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/xerrors"
+)
+
+// mysqlReadOnlyConnector makes every connection of the pool read-only by SET SESSION TRANSACTION READ ONLY,
+// which MariaDB and MySQL before 5.7.20 accept unlike the transaction_read_only variable.
+type mysqlReadOnlyConnector struct {
+	driver.Connector
+}
+
+func newMySQLReadOnlyConnector(cfg *mysql.Config) (driver.Connector, error) {
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlReadOnlyConnector{connector}, nil
+}
+
+func (c *mysqlReadOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, xerrors.New("cannot make the connection read-only")
+	}
+	if _, err := execer.ExecContext(ctx, "SET SESSION TRANSACTION READ ONLY", nil); err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("cannot make the connection read-only, %+v", err)
+	}
+	return conn, nil
+}
+
+// postgresReadOnlyDSN adds default_transaction_read_only to the run-time parameters of the URL or key/value connection string.
+func postgresReadOnlyDSN(dsn string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("default_transaction_read_only", "on")
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	return strings.TrimSpace(dsn + " default_transaction_read_only=on"), nil
+}
+
+// sqlite3ReadOnlyDSN enables PRAGMA query_only on the connection
+func sqlite3ReadOnlyDSN(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_query_only=1"
+	}
+	return dsn + "?_query_only=1"
+}
@@ -0,0 +1,346 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lighttiger2505/sqls/dialect"
+	"golang.org/x/xerrors"
+)
+
+// secretCommandTimeout bounds passwdCommand and passPhraseCommand
+const secretCommandTimeout = 30 * time.Second
+
+var envRefPattern = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveSecrets returns a copy of the config whose password and passphrase are read from their env, file or command fields.
+// The original config is left untouched, so that resolved secrets are never written back or cached.
+func resolveSecrets(cfg *DBConfig) (*DBConfig, error) {
+	resolved := *cfg
+	var err error
+
+	resolved.DataSourceName, err = expandEnvRefs(cfg.DataSourceName)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve dataSourceName, %+v", err)
+	}
+	resolved.Passwd, err = resolveSecret(cfg.Passwd, cfg.PasswdEnv, cfg.PasswdFile, cfg.PasswdCommand)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve passwd, %+v", err)
+	}
+	if resolved.Passwd == "" && resolved.DataSourceName == "" && cfg.Driver == dialect.DatabaseDriverMySQL {
+		user, passwd := readMySQLOptionFiles(mySQLOptionFiles())
+		if resolved.User == "" {
+			resolved.User = user
+		}
+		resolved.Passwd = passwd
+	}
+	// PostgreSQL reads ~/.pgpass (or PGPASSFILE) by itself when no password is given, except through SSH (see openViaSSH)
+
+	if cfg.SSHCfg != nil {
+		resolved.SSHCfg, err = resolveSSHSecrets(cfg.SSHCfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &resolved, nil
+}
+
+func resolveSSHSecrets(cfg *SSHConfig) (*SSHConfig, error) {
+	resolved := *cfg
+	var err error
+	resolved.PassPhrase, err = resolveSecret(cfg.PassPhrase, cfg.PassPhraseEnv, cfg.PassPhraseFile, cfg.PassPhraseCommand)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot resolve ssh passPhrase of %s, %+v", cfg.Host, err)
+	}
+	resolved.ProxyJump = make([]*SSHConfig, len(cfg.ProxyJump))
+	for i, hop := range cfg.ProxyJump {
+		resolved.ProxyJump[i], err = resolveSSHSecrets(hop)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &resolved, nil
+}
+
+// resolveSecret returns the plain value as it is. When the value is empty,
+// the environment variable env, the content of file or the stdout of command is used, in this order.
+func resolveSecret(value, env, file, command string) (string, error) {
+	switch {
+	case value != "":
+		return value, nil
+	case env != "":
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			return "", xerrors.Errorf("environment variable %s is not set", env)
+		}
+		return v, nil
+	case file != "":
+		path := expandHome(file)
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", xerrors.Errorf("cannot read secret file, %s, %+v", path, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case command != "":
+		return runSecretCommand(command)
+	}
+	return "", nil
+}
+
+func expandEnvRefs(value string) (string, error) {
+	var err error
+	expanded := envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = xerrors.Errorf("environment variable %s is not set", name)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func runSecretCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", xerrors.Errorf("command %q failed, %s, %+v", command, strings.TrimSpace(stderr.String()), err)
+	}
+	// only the first line is used, like pass(1) stores the password
+	out := stdout.String()
+	if i := strings.IndexAny(out, "\r\n"); i >= 0 {
+		out = out[:i]
+	}
+	return out, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// setPgpassPassword sets the password of ~/.pgpass (or PGPASSFILE) matching the address of the database, when the config has no password.
+func setPgpassPassword(cfg *DBConfig, host string, port int) error {
+	if os.Getenv("PGPASSWORD") != "" {
+		return nil
+	}
+	if cfg.DataSourceName == "" {
+		if cfg.Passwd == "" {
+			cfg.Passwd = pgpassPassword(pgpassFile(), host, port, cfg.DBName, postgresUser(cfg.User))
+		}
+		return nil
+	}
+
+	opts, err := parsePostgresDSN(cfg.DataSourceName)
+	if err != nil {
+		return err
+	}
+	if opts["password"] != "" {
+		return nil
+	}
+	dbName := postgresOption(opts, "dbname", "PGDATABASE", "")
+	passwd := pgpassPassword(pgpassFile(), host, port, dbName, postgresUser(postgresOption(opts, "user", "PGUSER", "")))
+	if passwd == "" {
+		return nil
+	}
+	opts["password"] = passwd
+	cfg.DataSourceName = formatPostgresOptions(opts)
+	return nil
+}
+
+// postgresUser returns the user lib/pq connects as
+func postgresUser(name string) string {
+	if name != "" {
+		return name
+	}
+	if name = os.Getenv("PGUSER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func pgpassFile() string {
+	if file := os.Getenv("PGPASSFILE"); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".pgpass")
+}
+
+// pgpassPassword returns the password of the first matching line of the password file, like lib/pq does.
+// The file is ignored if it is readable by others.
+func pgpassPassword(file, host string, port int, dbName, userName string) string {
+	if file == "" {
+		return ""
+	}
+	info, err := os.Stat(file)
+	if err != nil || (runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0) {
+		return ""
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	want := []string{host, strconv.Itoa(port), dbName, userName}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := splitPgpassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for i, w := range want {
+			if fields[i] != "*" && fields[i] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4]
+		}
+	}
+	return ""
+}
+
+// splitPgpassLine splits the line by ":", which is escaped by a backslash like "\:"
+func splitPgpassLine(line string) []string {
+	fields := []string{}
+	var field strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	return append(fields, field.String())
+}
+
+// mySQLOptionFiles returns the option files read by the mysql client, in the order they are read
+func mySQLOptionFiles() []string {
+	files := []string{"/etc/my.cnf", "/etc/mysql/my.cnf"}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".my.cnf"))
+	}
+	return files
+}
+
+// readMySQLOptionFiles reads user and password of the [client] and [mysql] groups.
+// Later files override earlier ones. Missing files are skipped, and so are unreadable ones with a message in the log.
+func readMySQLOptionFiles(files []string) (user, passwd string) {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			log.Println("skip MySQL option file,", file, err)
+			continue
+		}
+		u, p, err := parseMySQLOptionFile(f)
+		f.Close()
+		if err != nil {
+			log.Println("skip MySQL option file,", file, err)
+			continue
+		}
+		if u != "" {
+			user = u
+		}
+		if p != "" {
+			passwd = p
+		}
+	}
+	return user, passwd
+}
+
+func parseMySQLOptionFile(r io.Reader) (user, passwd string, err error) {
+	inGroup := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '!' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group := strings.TrimSpace(line[1 : len(line)-1])
+			inGroup = group == "client" || group == "mysql"
+			continue
+		}
+		if !inGroup {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ReplaceAll(strings.TrimSpace(kv[0]), "_", "-")
+		value := unquoteOptionValue(strings.TrimSpace(kv[1]))
+		switch key {
+		case "user":
+			user = value
+		case "password":
+			passwd = value
+		}
+	}
+	return user, passwd, scanner.Err()
+}
+
+func unquoteOptionValue(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
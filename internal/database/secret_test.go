@@ -0,0 +1,204 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "passwd")
+	if err := ioutil.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("SQLS_TEST_PASSWD", "env-secret")
+	defer os.Unsetenv("SQLS_TEST_PASSWD")
+	os.Unsetenv("SQLS_TEST_UNSET")
+
+	tests := []struct {
+		name    string
+		value   string
+		env     string
+		file    string
+		command string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", value: "plain-secret", want: "plain-secret"},
+		{name: "empty", value: "", want: ""},
+		{name: "plain env reference", value: "${env:SQLS_TEST_PASSWD}", want: "${env:SQLS_TEST_PASSWD}"},
+		{name: "plain file reference", value: "file:" + secretFile, want: "file:" + secretFile},
+		{name: "env", env: "SQLS_TEST_PASSWD", want: "env-secret"},
+		{name: "env unset", env: "SQLS_TEST_UNSET", wantErr: true},
+		{name: "file", file: secretFile, want: "file-secret"},
+		{name: "file not found", file: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "command", command: "echo command-secret", want: "command-secret"},
+		{name: "command first line", command: "printf 'line1\\nline2\\n'", want: "line1"},
+		{name: "command failed", command: "exit 1", wantErr: true},
+		{name: "value takes precedence", value: "plain-secret", env: "SQLS_TEST_PASSWD", command: "echo command-secret", want: "plain-secret"},
+		{name: "env takes precedence", env: "SQLS_TEST_PASSWD", file: secretFile, command: "echo command-secret", want: "env-secret"},
+		{name: "file takes precedence", file: secretFile, command: "echo command-secret", want: "file-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSecret(tt.value, tt.env, tt.file, tt.command)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error, %+v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSecretsKeepsConfig(t *testing.T) {
+	os.Setenv("SQLS_TEST_PASSWD", "env-secret")
+	defer os.Unsetenv("SQLS_TEST_PASSWD")
+
+	cfg := &DBConfig{
+		Driver:    "postgresql",
+		PasswdEnv: "SQLS_TEST_PASSWD",
+		SSHCfg:    &SSHConfig{PassPhraseCommand: "echo ssh-secret"},
+	}
+	resolved, err := resolveSecrets(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Passwd != "env-secret" {
+		t.Errorf("Passwd got %q, want %q", resolved.Passwd, "env-secret")
+	}
+	if resolved.SSHCfg.PassPhrase != "ssh-secret" {
+		t.Errorf("PassPhrase got %q, want %q", resolved.SSHCfg.PassPhrase, "ssh-secret")
+	}
+	if cfg.Passwd != "" || cfg.SSHCfg.PassPhrase != "" {
+		t.Error("the original config must not hold the resolved secrets")
+	}
+}
+
+func TestParseMySQLOptionFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantUser   string
+		wantPasswd string
+	}{
+		{
+			name:       "client group",
+			input:      "[client]\nuser=root\npassword=secret\n",
+			wantUser:   "root",
+			wantPasswd: "secret",
+		},
+		{
+			name:       "quoted and spaced",
+			input:      "# comment\n[mysql]\nuser = \"admin\"\npassword = 'p#ss word'\n",
+			wantUser:   "admin",
+			wantPasswd: "p#ss word",
+		},
+		{
+			name:       "other groups are ignored",
+			input:      "[mysqld]\npassword=server\n[client]\npassword=client\n[mysqldump]\npassword=dump\n",
+			wantPasswd: "client",
+		},
+		{
+			name:  "no group",
+			input: "password=secret\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, passwd, err := parseMySQLOptionFile(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != tt.wantUser || passwd != tt.wantPasswd {
+				t.Errorf("got %q %q, want %q %q", user, passwd, tt.wantUser, tt.wantPasswd)
+			}
+		})
+	}
+}
+
+func TestReadMySQLOptionFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-mycnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	global := filepath.Join(dir, "my.cnf")
+	user := filepath.Join(dir, ".my.cnf")
+	if err := ioutil.WriteFile(global, []byte("[client]\nuser=global\npassword=global\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(user, []byte("[client]\npassword=user\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory cannot be read as a file, like an option file without permission
+	unreadable := filepath.Join(dir, "unreadable")
+	if err := os.Mkdir(unreadable, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	gotUser, gotPasswd := readMySQLOptionFiles([]string{filepath.Join(dir, "missing"), global, unreadable, user})
+	if gotUser != "global" || gotPasswd != "user" {
+		t.Errorf("got %q %q, want %q %q", gotUser, gotPasswd, "global", "user")
+	}
+}
+
+func TestPgpassPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-pgpass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "pgpass")
+	content := strings.Join([]string{
+		"# comment",
+		"db.example.com:5432:sales:report:first",
+		"db.example.com:*:*:report:pass\\:word",
+		"*:*:*:*:fallback",
+	}, "\n")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host   string
+		port   int
+		dbName string
+		user   string
+		want   string
+	}{
+		{"db.example.com", 5432, "sales", "report", "first"},
+		{"db.example.com", 15432, "sales", "report", "pass:word"},
+		{"other.example.com", 5432, "sales", "report", "fallback"},
+	}
+	for _, tt := range tests {
+		if got := pgpassPassword(file, tt.host, tt.port, tt.dbName, tt.user); got != tt.want {
+			t.Errorf("pgpassPassword(%s, %d, %s, %s) = %q, want %q", tt.host, tt.port, tt.dbName, tt.user, got, tt.want)
+		}
+	}
+
+	// lib/pq ignores the file readable by others
+	if err := os.Chmod(file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := pgpassPassword(file, "db.example.com", 5432, "sales", "report"); got != "" && runtime.GOOS != "windows" {
+		t.Errorf("got %q from the file readable by others", got)
+	}
+}
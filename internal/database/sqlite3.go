@@ -3,11 +3,14 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"io"
+	"strings"
+	"sync"
 
 	"github.com/lighttiger2505/sqls/dialect"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 func init() {
@@ -16,17 +19,172 @@ func init() {
 }
 
 func sqlite3Open(connCfg *DBConfig) (*DBConnection, error) {
-	conn, err := sql.Open("sqlite3", connCfg.DataSourceName)
-	if err != nil {
-		return nil, err
+	dsn := connCfg.DataSourceName
+	if connCfg.ReadOnly {
+		dsn = sqlite3ReadOnlyDSN(dsn)
 	}
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	conn := sql.OpenDB(&sqlite3Connector{
+		dsn:         dsn,
+		driver:      &sqlite3.SQLiteDriver{},
+		attachments: &sqlite3Attachments{stmts: map[string]string{}},
+	})
 	return &DBConnection{
 		Conn: conn,
 	}, nil
 }
 
+// sqlite3Attachments are the databases attached by ATTACH DATABASE.
+// ATTACH applies only to the connection executing it, so the other connections of the pool attach them before use.
+type sqlite3Attachments struct {
+	mu      sync.Mutex
+	version int
+	// stmts are the ATTACH statements keyed by the lower case schema name
+	stmts map[string]string
+}
+
+// record keeps the executed ATTACH and DETACH statements
+func (a *sqlite3Attachments) record(query string) {
+	words := ddlWords(query)
+	if len(words) < 2 {
+		return
+	}
+	unquote := func(s string) string {
+		return strings.ToLower(strings.Trim(s, "`\"[]'"))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch strings.ToUpper(words[0]) {
+	case "ATTACH":
+		// ATTACH [DATABASE] expr AS name
+		for i := len(words) - 2; i > 0; i-- {
+			if strings.ToUpper(words[i]) == "AS" {
+				a.stmts[unquote(words[i+1])] = query
+				a.version++
+				return
+			}
+		}
+	case "DETACH":
+		// DETACH [DATABASE] name
+		name := words[1]
+		if strings.ToUpper(name) == "DATABASE" && len(words) > 2 {
+			name = words[2]
+		}
+		delete(a.stmts, unquote(name))
+		a.version++
+	}
+}
+
+func (a *sqlite3Attachments) snapshot() (map[string]string, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stmts := make(map[string]string, len(a.stmts))
+	for name, stmt := range a.stmts {
+		stmts[name] = stmt
+	}
+	return stmts, a.version
+}
+
+type sqlite3Connector struct {
+	dsn         string
+	driver      *sqlite3.SQLiteDriver
+	attachments *sqlite3Attachments
+}
+
+func (c *sqlite3Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	sc := &sqlite3Conn{
+		SQLiteConn:  conn.(*sqlite3.SQLiteConn),
+		attachments: c.attachments,
+		version:     -1,
+	}
+	if err := sc.ResetSession(ctx); err != nil {
+		sc.Close()
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (c *sqlite3Connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sqlite3Conn is a connection of the pool which follows the databases attached by the other connections
+type sqlite3Conn struct {
+	*sqlite3.SQLiteConn
+	attachments *sqlite3Attachments
+	// version is the version of the attachments the connection has applied
+	version int
+}
+
+func (c *sqlite3Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.SQLiteConn.ExecContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	c.attachments.record(query)
+	return res, nil
+}
+
+// ResetSession attaches and detaches the databases to match the other connections, before the connection is reused.
+func (c *sqlite3Conn) ResetSession(ctx context.Context) error {
+	stmts, version := c.attachments.snapshot()
+	if version == c.version {
+		return nil
+	}
+	attached, err := c.attachedDatabases(ctx)
+	if err != nil {
+		return err
+	}
+	for name, stmt := range stmts {
+		if _, ok := attached[name]; ok {
+			continue
+		}
+		if _, err := c.SQLiteConn.ExecContext(ctx, stmt, nil); err != nil {
+			return err
+		}
+	}
+	for name, orig := range attached {
+		if _, ok := stmts[name]; ok {
+			continue
+		}
+		if _, err := c.SQLiteConn.ExecContext(ctx, "DETACH DATABASE "+sqlite3Quote(orig), nil); err != nil {
+			return err
+		}
+	}
+	c.version = version
+	return nil
+}
+
+// attachedDatabases returns the names of the attached databases keyed by the lower case name, except main and temp
+func (c *sqlite3Conn) attachedDatabases(ctx context.Context) (map[string]string, error) {
+	rows, err := c.SQLiteConn.QueryContext(ctx, "PRAGMA database_list", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	attached := map[string]string{}
+	dest := make([]driver.Value, len(rows.Columns()))
+	for {
+		if err := rows.Next(dest); err != nil {
+			if err == io.EOF {
+				return attached, nil
+			}
+			return nil, err
+		}
+		name := fmt.Sprint(dest[1])
+		if name == sqlite3MainSchema || name == "temp" {
+			continue
+		}
+		attached[strings.ToLower(name)] = name
+	}
+}
+
+// sqlite3MainSchema is the schema of the database file opened by the connection
+const sqlite3MainSchema = "main"
+
 type SQLite3DBRepository struct {
 	Conn *sql.DB
 }
@@ -39,44 +197,98 @@ func (db *SQLite3DBRepository) Driver() dialect.DatabaseDriver {
 	return dialect.DatabaseDriverSQLite3
 }
 
+func (db *SQLite3DBRepository) ServerVersion(ctx context.Context) (string, error) {
+	row := db.Conn.QueryRowContext(ctx, "SELECT sqlite_version()")
+	var version string
+	if err := row.Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
 func (db *SQLite3DBRepository) CurrentDatabase(ctx context.Context) (string, error) {
-	return "", nil
+	return sqlite3MainSchema, nil
 }
 
+// Databases returns main, temp and the attached databases
 func (db *SQLite3DBRepository) Databases(ctx context.Context) ([]string, error) {
-	return []string{}, nil
+	rows, err := db.Conn.QueryContext(ctx, "PRAGMA database_list;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	databases := []string{}
+	for rows.Next() {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return nil, err
+		}
+		databases = append(databases, name)
+	}
+	return databases, rows.Err()
 }
 
 func (db *SQLite3DBRepository) CurrentSchema(ctx context.Context) (string, error) {
 	return db.CurrentDatabase(ctx)
 }
 
+// SearchPath returns the order SQLite resolves unqualified names, temp first, then main and the attached databases.
+func (db *SQLite3DBRepository) SearchPath(ctx context.Context) ([]string, error) {
+	databases, err := db.Databases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchPath := []string{}
+	for _, name := range databases {
+		if name == "temp" {
+			searchPath = append([]string{name}, searchPath...)
+		} else {
+			searchPath = append(searchPath, name)
+		}
+	}
+	return searchPath, nil
+}
+
 func (db *SQLite3DBRepository) Schemas(ctx context.Context) ([]string, error) {
 	return db.Databases(ctx)
 }
 
 func (db *SQLite3DBRepository) SchemaTables(ctx context.Context) (map[string][]string, error) {
-	tables, err := db.Tables(ctx)
+	databases, err := db.Databases(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return map[string][]string{"": tables}, nil
+	schemaTables := map[string][]string{}
+	for _, schemaName := range databases {
+		tables, err := db.schemaTables(ctx, schemaName)
+		if err != nil {
+			return nil, err
+		}
+		schemaTables[schemaName] = tables
+	}
+	return schemaTables, nil
 }
 
 func (db *SQLite3DBRepository) Tables(ctx context.Context) ([]string, error) {
-	rows, err := db.Conn.QueryContext(ctx, `
+	return db.schemaTables(ctx, sqlite3MainSchema)
+}
+
+func (db *SQLite3DBRepository) schemaTables(ctx context.Context, schemaName string) ([]string, error) {
+	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf(`
 	SELECT
-	  name 
+	  name
 	FROM
-	  sqlite_master
+	  %s.sqlite_master
 	WHERE
-	  type = 'table' 
+	  type = 'table'
 	ORDER BY
 	  name
-	`)
+	`, sqlite3Quote(schemaName)))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	defer rows.Close()
 	tables := []string{}
 	for rows.Next() {
 		var table string
@@ -85,14 +297,15 @@ func (db *SQLite3DBRepository) Tables(ctx context.Context) ([]string, error) {
 		}
 		tables = append(tables, table)
 	}
-	return tables, nil
+	return tables, rows.Err()
 }
 
-func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName string) ([]*ColumnDesc, error) {
-	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s);", tableName))
+func (db *SQLite3DBRepository) describeTable(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA %s.table_info(%s);", sqlite3Quote(schemaName), sqlite3Quote(tableName)))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
 	for rows.Next() {
 		var id int
@@ -109,6 +322,7 @@ func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName stri
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.Schema = schemaName
 		tableInfo.Table = tableName
 		if nonnull != 0 {
 			tableInfo.Null = "NO"
@@ -117,17 +331,17 @@ func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName stri
 		}
 		tableInfos = append(tableInfos, &tableInfo)
 	}
-	return tableInfos, nil
+	return tableInfos, rows.Err()
 }
 
 func (db *SQLite3DBRepository) DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error) {
-	tables, err := db.Tables(ctx)
+	databases, err := db.Databases(ctx)
 	if err != nil {
 		return nil, err
 	}
 	all := []*ColumnDesc{}
-	for _, table := range tables {
-		descs, err := db.describeTable(ctx, table)
+	for _, schemaName := range databases {
+		descs, err := db.DescribeDatabaseTableBySchema(ctx, schemaName)
 		if err != nil {
 			return nil, err
 		}
@@ -137,7 +351,215 @@ func (db *SQLite3DBRepository) DescribeDatabaseTable(ctx context.Context) ([]*Co
 }
 
 func (db *SQLite3DBRepository) DescribeDatabaseTableBySchema(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
-	return db.DescribeDatabaseTable(ctx)
+	tables, err := db.schemaTables(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	all := []*ColumnDesc{}
+	for _, table := range tables {
+		descs, err := db.describeTable(ctx, schemaName, table)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, descs...)
+	}
+	return all, nil
+}
+
+func (db *SQLite3DBRepository) DescribeTableBySchema(ctx context.Context, schemaName, tableName string) ([]*ColumnDesc, error) {
+	return db.describeTable(ctx, schemaName, tableName)
+}
+
+func (db *SQLite3DBRepository) DescribeTablesBySchema(ctx context.Context, schemaName string) ([]*TableDesc, error) {
+	tables, err := db.schemaTables(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeTables(schemaName, tables), nil
+}
+
+func (db *SQLite3DBRepository) DescribeTablesByNames(ctx context.Context, schemaName string, tableNames []string) ([]*TableDesc, error) {
+	tables, err := db.namedTables(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeTables(schemaName, tables), nil
+}
+
+// namedTables returns the existing tables of tableNames, which are case insensitive
+func (db *SQLite3DBRepository) namedTables(ctx context.Context, schemaName string, tableNames []string) ([]string, error) {
+	if len(tableNames) == 0 {
+		return []string{}, nil
+	}
+	tables, err := db.schemaTables(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	named := []string{}
+	for _, table := range tables {
+		for _, name := range tableNames {
+			if strings.EqualFold(table, name) {
+				named = append(named, table)
+				break
+			}
+		}
+	}
+	return named, nil
+}
+
+func (db *SQLite3DBRepository) describeTables(schemaName string, tables []string) []*TableDesc {
+	// SQLite does not support table comments
+	descs := []*TableDesc{}
+	for _, table := range tables {
+		descs = append(descs, &TableDesc{Schema: schemaName, Name: table})
+	}
+	return descs
+}
+
+func (db *SQLite3DBRepository) describeIndexes(ctx context.Context, schemaName, tableName string) ([]*IndexDesc, error) {
+	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA %s.index_list(%s);", sqlite3Quote(schemaName), sqlite3Quote(tableName)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	indexes := []*IndexDesc{}
+	for rows.Next() {
+		var seq, unique, partial int
+		var origin string
+		index := &IndexDesc{
+			Schema: schemaName,
+			Table:  tableName,
+			Method: "btree",
+		}
+		if err := rows.Scan(&seq, &index.Name, &unique, &origin, &partial); err != nil {
+			return nil, err
+		}
+		index.Unique = unique != 0
+		index.Primary = origin == "pk"
+		indexes = append(indexes, index)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for _, index := range indexes {
+		index.Columns, err = db.indexColumns(ctx, schemaName, index.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+func (db *SQLite3DBRepository) indexColumns(ctx context.Context, schemaName, indexName string) ([]string, error) {
+	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA %s.index_info(%s);", sqlite3Quote(schemaName), sqlite3Quote(indexName)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := []string{}
+	for rows.Next() {
+		var seqno, cid int
+		var name sql.NullString
+		if err := rows.Scan(&seqno, &cid, &name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name.String)
+	}
+	return columns, rows.Err()
+}
+
+func (db *SQLite3DBRepository) DescribeIndexesBySchema(ctx context.Context, schemaName string) ([]*IndexDesc, error) {
+	tables, err := db.schemaTables(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeIndexesOf(ctx, schemaName, tables)
+}
+
+func (db *SQLite3DBRepository) DescribeIndexesByTables(ctx context.Context, schemaName string, tableNames []string) ([]*IndexDesc, error) {
+	tables, err := db.namedTables(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeIndexesOf(ctx, schemaName, tables)
+}
+
+func (db *SQLite3DBRepository) describeIndexesOf(ctx context.Context, schemaName string, tables []string) ([]*IndexDesc, error) {
+	all := []*IndexDesc{}
+	for _, table := range tables {
+		indexes, err := db.describeIndexes(ctx, schemaName, table)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, indexes...)
+	}
+	return all, nil
+}
+
+func (db *SQLite3DBRepository) DescribeConstraintsBySchema(ctx context.Context, schemaName string) ([]*ConstraintDesc, error) {
+	tables, err := db.schemaTables(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeConstraintsOf(ctx, schemaName, tables)
+}
+
+func (db *SQLite3DBRepository) DescribeConstraintsByTables(ctx context.Context, schemaName string, tableNames []string) ([]*ConstraintDesc, error) {
+	tables, err := db.namedTables(ctx, schemaName, tableNames)
+	if err != nil {
+		return nil, err
+	}
+	return db.describeConstraintsOf(ctx, schemaName, tables)
+}
+
+func (db *SQLite3DBRepository) describeConstraintsOf(ctx context.Context, schemaName string, tables []string) ([]*ConstraintDesc, error) {
+	all := []*ConstraintDesc{}
+	for _, table := range tables {
+		cols, err := db.describeTable(ctx, schemaName, table)
+		if err != nil {
+			return nil, err
+		}
+		pkCols := []string{}
+		for _, col := range cols {
+			if col.Key != "0" {
+				pkCols = append(pkCols, col.Name)
+			}
+		}
+		if len(pkCols) > 0 {
+			all = append(all, &ConstraintDesc{
+				Schema:  schemaName,
+				Table:   table,
+				Name:    table + "_pkey",
+				Type:    ConstraintTypePrimaryKey,
+				Columns: pkCols,
+			})
+		}
+
+		// SQLite implements UNIQUE constraints as automatically created indexes.
+		indexes, err := db.describeIndexes(ctx, schemaName, table)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indexes {
+			if !index.Unique || index.Primary || !strings.HasPrefix(index.Name, "sqlite_autoindex_") {
+				continue
+			}
+			all = append(all, &ConstraintDesc{
+				Schema:  schemaName,
+				Table:   table,
+				Name:    index.Name,
+				Type:    ConstraintTypeUnique,
+				Columns: index.Columns,
+			})
+		}
+	}
+	return all, nil
+}
+
+func (db *SQLite3DBRepository) DescribeFunctionsBySchema(ctx context.Context, schemaName string) ([]*FunctionDesc, error) {
+	// SQLite does not support stored functions and procedures
+	return []*FunctionDesc{}, nil
 }
 
 func (db *SQLite3DBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
@@ -147,3 +569,7 @@ func (db *SQLite3DBRepository) Exec(ctx context.Context, query string) (sql.Resu
 func (db *SQLite3DBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
 	return db.Conn.QueryContext(ctx, query)
 }
+
+func sqlite3Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
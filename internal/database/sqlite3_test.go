@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSQLite3AttachedDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := sqlite3Open(&DBConfig{DataSourceName: filepath.Join(dir, "main.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Conn.Close()
+
+	ctx := context.Background()
+	repo := NewSQLite3DBRepository(conn.Conn)
+	queries := []string{
+		"CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT)",
+		"ATTACH DATABASE '" + filepath.Join(dir, "analytics.db") + "' AS analytics",
+		"CREATE TABLE analytics.events (id INTEGER PRIMARY KEY, city_id INTEGER, name TEXT UNIQUE)",
+	}
+	for _, query := range queries {
+		if _, err := repo.Exec(ctx, query); err != nil {
+			t.Fatalf("%s: %+v", query, err)
+		}
+	}
+
+	schemas, err := repo.Schemas(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"main", "analytics"}, schemas); diff != "" {
+		t.Errorf("Schemas unmatch (- want, + got):\n%s", diff)
+	}
+
+	schemaTables, err := repo.SchemaTables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTables := map[string][]string{
+		"main":      {"city"},
+		"analytics": {"events"},
+	}
+	if diff := cmp.Diff(wantTables, schemaTables); diff != "" {
+		t.Errorf("SchemaTables unmatch (- want, + got):\n%s", diff)
+	}
+
+	cols, err := repo.DescribeTableBySchema(ctx, "analytics", "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotCols := []string{}
+	for _, col := range cols {
+		gotCols = append(gotCols, col.Schema+"."+col.Table+"."+col.Name)
+	}
+	wantCols := []string{"analytics.events.id", "analytics.events.city_id", "analytics.events.name"}
+	if diff := cmp.Diff(wantCols, gotCols); diff != "" {
+		t.Errorf("DescribeTableBySchema unmatch (- want, + got):\n%s", diff)
+	}
+
+	constraints, err := repo.DescribeConstraintsBySchema(ctx, "analytics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(constraints) != 2 {
+		t.Fatalf("constraints got %d, want 2", len(constraints))
+	}
+	for _, constraint := range constraints {
+		if constraint.Schema != "analytics" || constraint.Table != "events" {
+			t.Errorf("constraint of %s.%s, want analytics.events", constraint.Schema, constraint.Table)
+		}
+	}
+
+	cache, err := NewDBCacheUpdater(repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.ColumnDatabase("analytics", "events"); !ok {
+		t.Error("analytics.events not found")
+	}
+	// attached databases are searched for unqualified names
+	if _, ok := cache.Column("events", "city_id"); !ok {
+		t.Error("events.city_id not found")
+	}
+}
+
+func TestSQLite3AttachedDatabasePool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-sqlite3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conn, err := sqlite3Open(&DBConfig{DataSourceName: filepath.Join(dir, "main.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Conn.Close()
+
+	ctx := context.Background()
+	// keep a connection of the pool busy, the queries below use the others
+	busy, err := conn.Conn.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := busy.ExecContext(ctx, "ATTACH DATABASE '"+filepath.Join(dir, "analytics.db")+"' AS Analytics"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := busy.ExecContext(ctx, "CREATE TABLE analytics.events (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewSQLite3DBRepository(conn.Conn)
+	databases, err := repo.Databases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"main", "Analytics"}, databases); diff != "" {
+		t.Errorf("Databases unmatch (- want, + got):\n%s", diff)
+	}
+
+	if _, err := busy.ExecContext(ctx, "DETACH DATABASE analytics"); err != nil {
+		t.Fatal(err)
+	}
+	busy.Close()
+	// the idle connections detach it before reuse
+	for i := 0; i < 3; i++ {
+		databases, err := repo.Databases(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"main"}, databases); diff != "" {
+			t.Errorf("Databases unmatch (- want, + got):\n%s", diff)
+		}
+	}
+}
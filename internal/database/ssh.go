@@ -0,0 +1,454 @@
+package database
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/xerrors"
+)
+
+const defaultSSHPort = 22
+
+// dialSSHChain connects to the ProxyJump hosts in order and then to the SSH server through them.
+// The settings of ~/.ssh/config are applied to every host, and the returned clients are ordered from the first hop.
+func dialSSHChain(cfg *SSHConfig) ([]*ssh.Client, error) {
+	target, err := cfg.withSSHConfigFile(userSSHConfigFile())
+	if err != nil {
+		return nil, err
+	}
+	hosts := []*SSHConfig{}
+	for _, hop := range target.ProxyJump {
+		// nested ProxyJump of a hop is not followed
+		hopCfg, err := hop.withSSHConfigFile(userSSHConfigFile())
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, hopCfg)
+	}
+	hosts = append(hosts, target)
+
+	clients := []*ssh.Client{}
+	var via *ssh.Client
+	for _, host := range hosts {
+		client, err := host.dial(via)
+		if err != nil {
+			closeSSHChain(clients)
+			return nil, err
+		}
+		clients = append(clients, client)
+		via = client
+	}
+	return clients, nil
+}
+
+func closeSSHChain(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
+
+// dial connects to the SSH server, directly or through the client of the previous hop.
+// The host key is verified against known_hosts unless InsecureIgnoreHostKey is set.
+func (s *SSHConfig) dial(via *ssh.Client) (*ssh.Client, error) {
+	clientConfig, closeAgent, err := s.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	// the agent is only needed while authenticating
+	defer closeAgent()
+
+	if via == nil {
+		client, err := ssh.Dial("tcp", s.Endpoint(), clientConfig)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot ssh dial, %s, %+v", s.Endpoint(), err)
+		}
+		return client, nil
+	}
+	conn, err := via.Dial("tcp", s.Endpoint())
+	if err != nil {
+		return nil, xerrors.Errorf("cannot ssh dial through jump host, %s, %+v", s.Endpoint(), err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.Endpoint(), clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, xerrors.Errorf("cannot ssh dial through jump host, %s, %+v", s.Endpoint(), err)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+func (s *SSHConfig) clientConfig() (*ssh.ClientConfig, func(), error) {
+	known, err := s.knownHosts()
+	if err != nil {
+		return nil, nil, err
+	}
+	auth, closeAgent, err := s.authMethods()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ssh.ClientConfig{
+		User:              s.User,
+		Auth:              auth,
+		HostKeyCallback:   verifyHostKey(known),
+		HostKeyAlgorithms: knownHostKeyAlgorithms(known, s.Endpoint()),
+	}, closeAgent, nil
+}
+
+func (s *SSHConfig) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	known, err := s.knownHosts()
+	if err != nil {
+		return nil, err
+	}
+	return verifyHostKey(known), nil
+}
+
+// knownHosts returns the callback of the known_hosts files, nil if InsecureIgnoreHostKey is set
+func (s *SSHConfig) knownHosts() (ssh.HostKeyCallback, error) {
+	if s.InsecureIgnoreHostKey {
+		return nil, nil
+	}
+
+	files := []string{}
+	if s.KnownHostsFile != "" {
+		files = append(files, expandHome(s.KnownHostsFile))
+	} else {
+		candidates := []string{"/etc/ssh/ssh_known_hosts"}
+		if home, err := os.UserHomeDir(); err == nil {
+			candidates = append(candidates, filepath.Join(home, ".ssh", "known_hosts"))
+		}
+		for _, file := range candidates {
+			if _, err := os.Stat(file); err == nil {
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 {
+			return nil, xerrors.New("cannot verify SSH host key, known_hosts file not found, set knownHostsFile or insecureIgnoreHostKey")
+		}
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read known_hosts file, %+v", err)
+	}
+	return callback, nil
+}
+
+// verifyHostKey reports the host keys the known_hosts callback rejects with their fingerprints
+func verifyHostKey(known ssh.HostKeyCallback) ssh.HostKeyCallback {
+	if known == nil {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if xerrors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return xerrors.Errorf("SSH host key of %s is not in known_hosts, %s", hostname, ssh.FingerprintSHA256(key))
+			}
+			return xerrors.Errorf("SSH host key of %s does not match known_hosts, %s", hostname, ssh.FingerprintSHA256(key))
+		}
+		return err
+	}
+}
+
+// hostKeyAlgorithms is the preference order of the host key algorithms the ssh package supports
+var hostKeyAlgorithms = []string{
+	ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSA, ssh.KeyAlgoDSA,
+	ssh.KeyAlgoED25519,
+}
+
+// knownHostKeyAlgorithms returns the algorithms of the keys known_hosts has for the address, as OpenSSH prefers them.
+// Otherwise the server may choose a key of another type, which is rejected as a mismatch. nil means the default algorithms.
+func knownHostKeyAlgorithms(known ssh.HostKeyCallback, address string) []string {
+	if known == nil {
+		return nil
+	}
+	// the known keys are reported by the error of a key nobody has
+	err := known(address, &net.TCPAddr{}, probeKey{})
+	var keyErr *knownhosts.KeyError
+	if !xerrors.As(err, &keyErr) || len(keyErr.Want) == 0 {
+		return nil
+	}
+	types := map[string]bool{}
+	for _, k := range keyErr.Want {
+		types[k.Key.Type()] = true
+	}
+	algos := []string{}
+	for _, algo := range hostKeyAlgorithms {
+		if types[algo] {
+			algos = append(algos, algo)
+		}
+	}
+	if len(algos) == 0 {
+		return nil
+	}
+	return algos
+}
+
+// probeKey is a host key to look up the known keys of a host with
+type probeKey struct{}
+
+func (probeKey) Type() string {
+	return "sqls-probe"
+}
+
+func (probeKey) Marshal() []byte {
+	return []byte("sqls-probe")
+}
+
+func (probeKey) Verify([]byte, *ssh.Signature) error {
+	return xerrors.New("probe key cannot verify signatures")
+}
+
+// authMethods returns the private key and agent keys as a single publickey method, since the
+// client tries each method name only once, followed by password and keyboard-interactive.
+func (s *SSHConfig) authMethods() ([]ssh.AuthMethod, func(), error) {
+	closeAgent := func() {}
+	signers := []ssh.Signer{}
+	encryptedKey := false
+	if s.PrivateKey != "" {
+		signer, err := s.privateKeySigner()
+		if err != nil {
+			return nil, nil, err
+		}
+		if signer != nil {
+			signers = append(signers, signer)
+		} else {
+			// the agent may hold the decrypted key
+			log.Printf("SSH private key %s is encrypted and passPhrase is not set, skipping it", s.PrivateKey)
+			encryptedKey = true
+		}
+	}
+	var agentClient agent.Agent
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		// an unreachable agent is not an error, other methods may succeed
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentClient = agent.NewClient(conn)
+			closeAgent = func() { conn.Close() }
+		}
+	}
+
+	methods := []ssh.AuthMethod{}
+	if len(signers) > 0 || agentClient != nil {
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if agentClient == nil {
+				return signers, nil
+			}
+			agentSigners, err := agentClient.Signers()
+			if err != nil {
+				return signers, nil
+			}
+			return append(signers, agentSigners...), nil
+		}))
+	}
+	if s.Password != "" {
+		password := s.Password
+		methods = append(methods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+	if len(methods) == 0 {
+		closeAgent()
+		if encryptedKey {
+			return nil, nil, xerrors.Errorf("SSH private key %s is encrypted, set passPhrase or add the key to ssh-agent", s.PrivateKey)
+		}
+		return nil, nil, xerrors.New("no SSH authentication method, set privateKey or password, or run ssh-agent")
+	}
+	return methods, closeAgent, nil
+}
+
+// privateKeySigner returns nil without error when the key is encrypted and no passphrase is set.
+func (s *SSHConfig) privateKeySigner() (ssh.Signer, error) {
+	buffer, err := ioutil.ReadFile(s.PrivateKey)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot read SSH private key file, PrivateKey=%s, %+v", s.PrivateKey, err)
+	}
+
+	if s.PassPhrase != "" {
+		key, err := ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(s.PassPhrase))
+		if err != nil {
+			return nil, xerrors.Errorf("cannot parse SSH private key file with passphrase, PrivateKey=%s, %+v", s.PrivateKey, err)
+		}
+		return key, nil
+	}
+	key, err := ssh.ParsePrivateKey(buffer)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("cannot parse SSH private key file, PrivateKey=%s, %+v", s.PrivateKey, err)
+	}
+	return key, nil
+}
+
+var userSSHConfigFile = func() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// withSSHConfigFile returns a copy of the config completed with the settings of the host alias in the OpenSSH client config file.
+// Settings in the sqls config take precedence.
+func (s *SSHConfig) withSSHConfigFile(configFile string) (*SSHConfig, error) {
+	cfg := *s
+	options := map[string]string{}
+	if configFile != "" {
+		f, err := os.Open(configFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, xerrors.Errorf("cannot open SSH config file, %s, %+v", configFile, err)
+		}
+		if err == nil {
+			hosts, err := parseSSHConfig(f)
+			f.Close()
+			if err != nil {
+				return nil, xerrors.Errorf("cannot read SSH config file, %s, %+v", configFile, err)
+			}
+			options = lookupSSHConfig(hosts, s.Host)
+		}
+	}
+
+	if hostname, ok := options["hostname"]; ok {
+		cfg.Host = strings.ReplaceAll(hostname, "%h", s.Host)
+	}
+	if cfg.Port == 0 {
+		if port, err := strconv.Atoi(options["port"]); err == nil {
+			cfg.Port = port
+		} else {
+			cfg.Port = defaultSSHPort
+		}
+	}
+	if cfg.User == "" {
+		cfg.User = options["user"]
+	}
+	if cfg.User == "" {
+		cfg.User = os.Getenv("USER")
+	}
+	if cfg.PrivateKey == "" {
+		cfg.PrivateKey = options["identityfile"]
+	}
+	cfg.PrivateKey = expandHome(cfg.PrivateKey)
+	if cfg.KnownHostsFile == "" {
+		if fields := strings.Fields(options["userknownhostsfile"]); len(fields) > 0 {
+			cfg.KnownHostsFile = fields[0]
+		}
+	}
+	if len(cfg.ProxyJump) == 0 {
+		cfg.ProxyJump = parseProxyJump(options["proxyjump"])
+	}
+	return &cfg, nil
+}
+
+// parseProxyJump parses the comma separated [user@]host[:port] list of the ProxyJump option
+func parseProxyJump(value string) []*SSHConfig {
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	hops := []*SSHConfig{}
+	for _, hop := range strings.Split(value, ",") {
+		hopCfg := &SSHConfig{}
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			hopCfg.User, hop = hop[:i], hop[i+1:]
+		}
+		if host, port, err := net.SplitHostPort(hop); err == nil {
+			hopCfg.Host = host
+			hopCfg.Port, _ = strconv.Atoi(port)
+		} else {
+			hopCfg.Host = hop
+		}
+		hops = append(hops, hopCfg)
+	}
+	return hops
+}
+
+type sshConfigHost struct {
+	patterns []string
+	options  map[string]string
+}
+
+// parseSSHConfig parses the Host blocks of an OpenSSH client config file.
+// Options before the first Host line apply to every host, and Match blocks are skipped.
+func parseSSHConfig(r io.Reader) ([]*sshConfigHost, error) {
+	current := &sshConfigHost{patterns: []string{"*"}, options: map[string]string{}}
+	hosts := []*sshConfigHost{current}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		var key, value string
+		if i := strings.IndexAny(line, " \t="); i >= 0 {
+			key, value = line[:i], strings.TrimLeft(line[i:], " \t=")
+		} else {
+			key = line
+		}
+		key = strings.ToLower(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch key {
+		case "host":
+			current = &sshConfigHost{patterns: strings.Fields(value), options: map[string]string{}}
+			hosts = append(hosts, current)
+		case "match":
+			current = &sshConfigHost{options: map[string]string{}}
+			hosts = append(hosts, current)
+		default:
+			// the first obtained value is used, like ssh(1)
+			if _, ok := current.options[key]; !ok {
+				current.options[key] = value
+			}
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+func lookupSSHConfig(hosts []*sshConfigHost, alias string) map[string]string {
+	options := map[string]string{}
+	for _, host := range hosts {
+		if !matchSSHHost(host.patterns, alias) {
+			continue
+		}
+		for k, v := range host.options {
+			if _, ok := options[k]; !ok {
+				options[k] = v
+			}
+		}
+	}
+	return options
+}
+
+func matchSSHHost(patterns []string, alias string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if ok, _ := path.Match(pattern, alias); !ok {
+			continue
+		}
+		if negate {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
@@ -0,0 +1,341 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const testSSHConfig = `
+User globaluser
+
+Host bastion prod-*
+  HostName 10.0.0.%h
+  Port 2222
+  IdentityFile ~/.ssh/prod_ed25519
+
+Host prod-db !prod-db-old
+  User produser
+  UserKnownHostsFile /etc/sqls/known_hosts /etc/sqls/known_hosts2
+
+Host *
+  Port 22
+  User fallback
+
+Match host other
+  User matchuser
+`
+
+func TestLookupSSHConfig(t *testing.T) {
+	hosts, err := parseSSHConfig(strings.NewReader(testSSHConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		alias string
+		want  map[string]string
+	}{
+		{
+			alias: "prod-db",
+			want: map[string]string{
+				"user":               "globaluser",
+				"hostname":           "10.0.0.%h",
+				"port":               "2222",
+				"identityfile":       "~/.ssh/prod_ed25519",
+				"userknownhostsfile": "/etc/sqls/known_hosts /etc/sqls/known_hosts2",
+			},
+		},
+		{
+			alias: "prod-db-old",
+			want: map[string]string{
+				"user":         "globaluser",
+				"hostname":     "10.0.0.%h",
+				"port":         "2222",
+				"identityfile": "~/.ssh/prod_ed25519",
+			},
+		},
+		{
+			alias: "other",
+			want: map[string]string{
+				"user": "globaluser",
+				"port": "22",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got := lookupSSHConfig(hosts, tt.alias)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithSSHConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(configFile, []byte(testSSHConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := (&SSHConfig{Host: "prod-db"}).withSSHConfigFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SSHConfig{
+		Host:           "10.0.0.prod-db",
+		Port:           2222,
+		User:           "globaluser",
+		PrivateKey:     filepath.Join(home, ".ssh", "prod_ed25519"),
+		KnownHostsFile: "/etc/sqls/known_hosts",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unmatch (- want, + got):\n%s", diff)
+	}
+
+	// the sqls config takes precedence
+	got, err = (&SSHConfig{Host: "prod-db", Port: 22, User: "sqls", PrivateKey: "/keys/id"}).withSSHConfigFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Port != 22 || got.User != "sqls" || got.PrivateKey != "/keys/id" {
+		t.Errorf("unexpected override, %+v", got)
+	}
+
+	// a missing config file is not an error
+	got, err = (&SSHConfig{Host: "example.com", User: "sqls"}).withSSHConfigFile(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Host != "example.com" || got.Port != defaultSSHPort {
+		t.Errorf("unexpected default, %+v", got)
+	}
+}
+
+func TestSSHHostKeyCallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newKey := func() ssh.PublicKey {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	knownKey, otherKey := newKey(), newKey()
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize("db.example.com:2222")}, knownKey) + "\n"
+	if err := ioutil.WriteFile(knownHostsFile, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 2222}
+
+	tests := []struct {
+		name     string
+		cfg      *SSHConfig
+		hostname string
+		key      ssh.PublicKey
+		wantErr  string
+	}{
+		{
+			name:     "known host",
+			cfg:      &SSHConfig{KnownHostsFile: knownHostsFile},
+			hostname: "db.example.com:2222",
+			key:      knownKey,
+		},
+		{
+			name:     "changed host key",
+			cfg:      &SSHConfig{KnownHostsFile: knownHostsFile},
+			hostname: "db.example.com:2222",
+			key:      otherKey,
+			wantErr:  "does not match known_hosts",
+		},
+		{
+			name:     "unknown host",
+			cfg:      &SSHConfig{KnownHostsFile: knownHostsFile},
+			hostname: "other.example.com:22",
+			key:      knownKey,
+			wantErr:  "is not in known_hosts",
+		},
+		{
+			name:     "insecure",
+			cfg:      &SSHConfig{KnownHostsFile: knownHostsFile, InsecureIgnoreHostKey: true},
+			hostname: "other.example.com:22",
+			key:      otherKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := tt.cfg.hostKeyCallback()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = callback(tt.hostname, remote, tt.key)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error, %+v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := (&SSHConfig{KnownHostsFile: filepath.Join(dir, "missing")}).hostKeyCallback(); err == nil {
+		t.Error("expected error for missing known_hosts file")
+	}
+}
+
+func TestKnownHostKeyAlgorithms(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines string
+	for _, entry := range []struct {
+		host string
+		key  interface{}
+	}{
+		{host: "db.example.com:2222", key: &rsaKey.PublicKey},
+		{host: "db.example.com:2222", key: ed25519Key},
+		{host: "bastion.example.com:22", key: &ecdsaKey.PublicKey},
+	} {
+		key, err := ssh.NewPublicKey(entry.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines += knownhosts.Line([]string{knownhosts.Normalize(entry.host)}, key) + "\n"
+	}
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	if err := ioutil.WriteFile(knownHostsFile, []byte(lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *SSHConfig
+		address string
+		want    []string
+	}{
+		{name: "rsa and ed25519", cfg: &SSHConfig{KnownHostsFile: knownHostsFile}, address: "db.example.com:2222", want: []string{ssh.KeyAlgoRSA, ssh.KeyAlgoED25519}},
+		{name: "ecdsa", cfg: &SSHConfig{KnownHostsFile: knownHostsFile}, address: "bastion.example.com:22", want: []string{ssh.KeyAlgoECDSA256}},
+		{name: "unknown host", cfg: &SSHConfig{KnownHostsFile: knownHostsFile}, address: "other.example.com:22", want: nil},
+		{name: "insecure", cfg: &SSHConfig{KnownHostsFile: knownHostsFile, InsecureIgnoreHostKey: true}, address: "db.example.com:2222", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known, err := tt.cfg.knownHosts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := knownHostKeyAlgorithms(known, tt.address)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSSHAuthMethods(t *testing.T) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	os.Unsetenv("SSH_AUTH_SOCK")
+	defer os.Setenv("SSH_AUTH_SOCK", sock)
+
+	if _, _, err := (&SSHConfig{}).authMethods(); err == nil {
+		t.Error("expected error without any authentication method")
+	}
+
+	methods, closeAgent, err := (&SSHConfig{Password: "secret"}).authMethods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeAgent()
+	if len(methods) != 2 {
+		t.Errorf("got %d methods, want password and keyboard-interactive", len(methods))
+	}
+
+	// an encrypted key without passPhrase is skipped for the agent and other methods
+	dir, err := ioutil.TempDir("", "sqls-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), []byte("phrase"), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_rsa")
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	methods, closeAgent, err = (&SSHConfig{PrivateKey: keyFile, Password: "secret"}).authMethods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeAgent()
+	if len(methods) != 2 {
+		t.Errorf("got %d methods, want password and keyboard-interactive", len(methods))
+	}
+	if _, _, err := (&SSHConfig{PrivateKey: keyFile}).authMethods(); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Errorf("got %v, want error of the encrypted key", err)
+	}
+	methods, closeAgent, err = (&SSHConfig{PrivateKey: keyFile, PassPhrase: "phrase"}).authMethods()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeAgent()
+	if len(methods) != 1 {
+		t.Errorf("got %d methods, want publickey", len(methods))
+	}
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/xerrors"
+)
+
+type TLSMode string
+
+const (
+	TLSModeDisable    TLSMode = "disable"
+	TLSModePreferred  TLSMode = "preferred"
+	TLSModeRequire    TLSMode = "require"
+	TLSModeVerifyCA   TLSMode = "verify-ca"
+	TLSModeVerifyFull TLSMode = "verify-full"
+)
+
+type TLSConfig struct {
+	Mode       TLSMode `json:"mode" yaml:"mode"`
+	CAFile     string  `json:"caFile" yaml:"caFile"`
+	CertFile   string  `json:"certFile" yaml:"certFile"`
+	KeyFile    string  `json:"keyFile" yaml:"keyFile"`
+	ServerName string  `json:"serverName" yaml:"serverName"`
+	SkipVerify bool    `json:"skipVerify" yaml:"skipVerify"`
+}
+
+// effectiveMode returns the mode to connect with.
+// Without a mode, the server is verified when a CA file is given, and the connection is only encrypted otherwise.
+func (c *TLSConfig) effectiveMode() TLSMode {
+	if c.Mode == TLSModeDisable {
+		return TLSModeDisable
+	}
+	if c.SkipVerify {
+		return TLSModeRequire
+	}
+	if c.Mode != "" {
+		return c.Mode
+	}
+	if c.CAFile != "" {
+		return TLSModeVerifyFull
+	}
+	return TLSModeRequire
+}
+
+// clientConfig returns the crypto/tls config for the mode, serverName is used unless ServerName is set.
+func (c *TLSConfig) clientConfig(serverName string) (*tls.Config, error) {
+	mode := c.effectiveMode()
+	switch mode {
+	case TLSModePreferred, TLSModeRequire, TLSModeVerifyCA, TLSModeVerifyFull:
+	default:
+		return nil, xerrors.Errorf("unsupported tls mode, %s", mode)
+	}
+
+	cfg := &tls.Config{ServerName: serverName}
+	if c.ServerName != "" {
+		cfg.ServerName = c.ServerName
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot load TLS client certificate, %+v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, xerrors.Errorf("cannot read TLS CA file, %+v", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, xerrors.Errorf("no certificate found in TLS CA file, %s", c.CAFile)
+		}
+	}
+
+	switch mode {
+	case TLSModePreferred, TLSModeRequire:
+		cfg.InsecureSkipVerify = true
+	case TLSModeVerifyCA:
+		// crypto/tls always verifies the host name, so the chain is verified by itself
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = verifyCertificateChain(cfg.RootCAs)
+	case TLSModeVerifyFull:
+		if cfg.ServerName == "" {
+			return nil, xerrors.New("tls mode verify-full needs serverName")
+		}
+	}
+	return cfg, nil
+}
+
+func verifyCertificateChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return xerrors.New("no server certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+// mysqlTLSConfigName returns the value of the tls DSN parameter, registering the config with the MySQL driver when needed.
+func mysqlTLSConfigName(c *TLSConfig, serverName string) (string, error) {
+	mode := c.effectiveMode()
+	switch {
+	case mode == TLSModeDisable:
+		return "false", nil
+	case mode == TLSModePreferred && c.CAFile == "" && c.CertFile == "":
+		return "preferred", nil
+	case mode == TLSModePreferred:
+		// the driver falls back to plain text only for its own preferred config, a registered config is always required
+		return "", xerrors.New("tls mode preferred cannot be used with caFile or certFile, use require or verify-ca")
+	case mode == TLSModeRequire && c.CAFile == "" && c.CertFile == "":
+		return "skip-verify", nil
+	}
+
+	tlsCfg, err := c.clientConfig(serverName)
+	if err != nil {
+		return "", err
+	}
+	// the same settings share a name, so that reconnecting does not grow the driver registry
+	b, _ := json.Marshal(struct {
+		*TLSConfig
+		Host string
+	}{c, serverName})
+	sum := sha256.Sum256(b)
+	name := "sqls-" + hex.EncodeToString(sum[:8])
+	if err := mysql.RegisterTLSConfig(name, tlsCfg); err != nil {
+		return "", xerrors.Errorf("cannot register TLS config, %+v", err)
+	}
+	return name, nil
+}
+
+// setPostgresTLSOptions sets the ssl options of lib/pq, which verifies the server against host.
+func setPostgresTLSOptions(q url.Values, c *TLSConfig, host string) error {
+	mode := c.effectiveMode()
+	switch mode {
+	case TLSModeDisable, TLSModeRequire, TLSModeVerifyCA, TLSModeVerifyFull:
+	default:
+		return xerrors.Errorf("unsupported tls mode for postgresql, %s", mode)
+	}
+	if mode == TLSModeVerifyFull && c.ServerName != "" && c.ServerName != host {
+		return xerrors.Errorf("tls serverName %s must be the host for postgresql, use verify-ca instead", c.ServerName)
+	}
+	q.Set("sslmode", string(mode))
+	if c.CAFile != "" {
+		q.Set("sslrootcert", c.CAFile)
+	}
+	if c.CertFile != "" {
+		q.Set("sslcert", c.CertFile)
+	}
+	if c.KeyFile != "" {
+		q.Set("sslkey", c.KeyFile)
+	}
+	return nil
+}
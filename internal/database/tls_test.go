@@ -0,0 +1,178 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.DNSNames = []string{commonName}
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func TestTLSClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificate(t, "sqls test ca", nil)
+	otherCA := newTestCertificate(t, "other ca", nil)
+	server := newTestCertificate(t, "db.example.com", ca)
+	caFile := filepath.Join(dir, "ca.pem")
+	otherCAFile := filepath.Join(dir, "other-ca.pem")
+	if err := ioutil.WriteFile(caFile, ca.pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(otherCAFile, otherCA.pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.cert.Raw}, PrivateKey: server.key}},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        *TLSConfig
+		serverName string
+		wantErr    bool
+	}{
+		{name: "verify-full", cfg: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: caFile}, serverName: "db.example.com"},
+		{name: "verify-full wrong host", cfg: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: caFile}, serverName: "127.0.0.1", wantErr: true},
+		{name: "verify-full server name", cfg: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: caFile, ServerName: "db.example.com"}, serverName: "127.0.0.1"},
+		{name: "verify-full other ca", cfg: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: otherCAFile}, serverName: "db.example.com", wantErr: true},
+		{name: "default mode with ca", cfg: &TLSConfig{CAFile: caFile}, serverName: "127.0.0.1", wantErr: true},
+		{name: "verify-ca", cfg: &TLSConfig{Mode: TLSModeVerifyCA, CAFile: caFile}, serverName: "127.0.0.1"},
+		{name: "verify-ca other ca", cfg: &TLSConfig{Mode: TLSModeVerifyCA, CAFile: otherCAFile}, serverName: "127.0.0.1", wantErr: true},
+		{name: "require", cfg: &TLSConfig{Mode: TLSModeRequire}, serverName: "127.0.0.1"},
+		{name: "skip verify", cfg: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: otherCAFile, SkipVerify: true}, serverName: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientTLS, err := tt.cfg.clientConfig(tt.serverName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientConn, serverConn := net.Pipe()
+			defer clientConn.Close()
+			defer serverConn.Close()
+			go tls.Server(serverConn, serverTLS).Handshake()
+
+			err = tls.Client(clientConn, clientTLS).Handshake()
+			if tt.wantErr && err == nil {
+				t.Error("expected handshake error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected handshake error, %+v", err)
+			}
+		})
+	}
+
+	if _, err := (&TLSConfig{Mode: "prefer"}).clientConfig("db.example.com"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestGenMysqlConfigTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(caFile, newTestCertificate(t, "sqls test ca", nil).pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     *TLSConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "disable", tls: &TLSConfig{Mode: TLSModeDisable}, want: "false"},
+		{name: "preferred", tls: &TLSConfig{Mode: TLSModePreferred}, want: "preferred"},
+		{name: "require", tls: &TLSConfig{}, want: "skip-verify"},
+		{name: "custom ca", tls: &TLSConfig{Mode: TLSModeVerifyFull, CAFile: caFile}, want: "sqls-"},
+		{name: "preferred with ca", tls: &TLSConfig{Mode: TLSModePreferred, CAFile: caFile}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := genMysqlConfig(&DBConfig{
+				Driver: "mysql",
+				Proto:  ProtoTCP,
+				Host:   "db.example.com",
+				TLS:    tt.tls,
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", cfg.TLSConfig)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(cfg.TLSConfig, tt.want) {
+				t.Errorf("got %q, want %q", cfg.TLSConfig, tt.want)
+			}
+			// the driver resolves the registered name
+			if _, err := mysql.ParseDSN(cfg.FormatDSN()); err != nil {
+				t.Errorf("cannot parse DSN, %+v", err)
+			}
+		})
+	}
+}
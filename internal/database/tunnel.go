@@ -0,0 +1,196 @@
+package database
+
+import (
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+)
+
+const defaultSSHKeepAliveInterval = 30 * time.Second
+
+// Tunnel forwards the connections to a local port to the remote address through SSH, so that any driver can connect via SSH.
+// The SSH connection is kept alive and dialed again when it is lost.
+type Tunnel struct {
+	cfg        *SSHConfig
+	remoteAddr string
+	keepAlive  time.Duration
+	listener   net.Listener
+	done       chan struct{}
+
+	mu      sync.Mutex
+	clients []*ssh.Client
+	closed  bool
+}
+
+// OpenTunnel dials the SSH server and listens on a random local port.
+func OpenTunnel(cfg *SSHConfig, remoteAddr string) (*Tunnel, error) {
+	t := &Tunnel{
+		cfg:        cfg,
+		remoteAddr: remoteAddr,
+		keepAlive:  defaultSSHKeepAliveInterval,
+		done:       make(chan struct{}),
+	}
+	if cfg.KeepAliveInterval > 0 {
+		t.keepAlive = time.Duration(cfg.KeepAliveInterval) * time.Second
+	}
+	if _, err := t.client(); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Close()
+		return nil, xerrors.Errorf("cannot listen on local port for ssh tunnel, %+v", err)
+	}
+	t.listener = listener
+
+	go t.serve()
+	go t.keepAliveLoop()
+	return t, nil
+}
+
+func (t *Tunnel) LocalAddr() *net.TCPAddr {
+	return t.listener.Addr().(*net.TCPAddr)
+}
+
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	close(t.done)
+	closeSSHChain(t.clients)
+	t.clients = nil
+	if t.listener != nil {
+		return t.listener.Close()
+	}
+	return nil
+}
+
+// client returns the SSH client of the last host, dialing the chain when it is not connected.
+func (t *Tunnel) client() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, xerrors.New("ssh tunnel is closed")
+	}
+	if len(t.clients) == 0 {
+		clients, err := dialSSHChain(t.cfg)
+		if err != nil {
+			return nil, err
+		}
+		t.clients = clients
+	}
+	return t.clients[len(t.clients)-1], nil
+}
+
+// reset closes the chain of the client, unless it has already been dialed again.
+func (t *Tunnel) reset(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.clients) > 0 && t.clients[len(t.clients)-1] == client {
+		closeSSHChain(t.clients)
+		t.clients = nil
+	}
+}
+
+func (t *Tunnel) serve() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+func (t *Tunnel) forward(local net.Conn) {
+	defer local.Close()
+	remote, err := t.dialRemote()
+	if err != nil {
+		log.Printf("ssh tunnel, %+v", err)
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// dialRemote dials the remote address, dialing SSH again once when the connection has been lost.
+func (t *Tunnel) dialRemote() (net.Conn, error) {
+	client, err := t.client()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial("tcp", t.remoteAddr)
+	if err == nil {
+		return conn, nil
+	}
+	t.reset(client)
+	client, err = t.client()
+	if err != nil {
+		return nil, err
+	}
+	conn, err = client.Dial("tcp", t.remoteAddr)
+	if err != nil {
+		return nil, xerrors.Errorf("cannot dial %s through ssh, %+v", t.remoteAddr, err)
+	}
+	return conn, nil
+}
+
+func (t *Tunnel) keepAliveLoop() {
+	ticker := time.NewTicker(t.keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		}
+		t.mu.Lock()
+		var client *ssh.Client
+		if len(t.clients) > 0 {
+			client = t.clients[len(t.clients)-1]
+		}
+		t.mu.Unlock()
+		if client == nil {
+			continue
+		}
+		if err := sendKeepAlive(client, t.keepAlive); err != nil {
+			log.Printf("ssh keepalive failed, reconnecting, %+v", err)
+			t.reset(client)
+			if _, err := t.client(); err != nil {
+				log.Printf("cannot reconnect ssh tunnel, %+v", err)
+			}
+		}
+	}
+}
+
+func sendKeepAlive(client *ssh.Client, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(timeout):
+		return xerrors.New("no response to keepalive")
+	}
+}
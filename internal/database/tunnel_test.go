@@ -0,0 +1,315 @@
+package database
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer accepts password authentication and forwards direct-tcpip channels.
+type testSSHServer struct {
+	listener net.Listener
+	hostKey  ssh.Signer
+
+	mu    sync.Mutex
+	conns []ssh.Conn
+}
+
+func startTestSSHServer(t *testing.T) *testSSHServer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSSHServer{listener: listener, hostKey: hostKey}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "sqls" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(hostKey)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, sconn)
+	s.mu.Unlock()
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		if newCh.ChannelType() != "direct-tcpip" {
+			newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var payload struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+			newCh.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newCh.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chReqs)
+		go func() {
+			defer ch.Close()
+			defer target.Close()
+			go io.Copy(target, ch)
+			io.Copy(ch, target)
+		}()
+	}
+}
+
+// dropConnections closes the established SSH connections, like a network failure.
+func (s *testSSHServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testSSHServer) addr() *net.TCPAddr {
+	return s.listener.Addr().(*net.TCPAddr)
+}
+
+func (s *testSSHServer) sshConfig(knownHostsFile string) *SSHConfig {
+	return &SSHConfig{
+		Host:           s.addr().IP.String(),
+		Port:           s.addr().Port,
+		User:           "sqls",
+		Password:       "secret",
+		KnownHostsFile: knownHostsFile,
+	}
+}
+
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func assertEcho(t *testing.T, addr string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("cannot read through tunnel, %+v", err)
+	}
+	if got != "ping\n" {
+		t.Errorf("got %q, want %q", got, "ping\n")
+	}
+}
+
+func TestTunnel(t *testing.T) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	os.Unsetenv("SSH_AUTH_SOCK")
+	defer os.Setenv("SSH_AUTH_SOCK", sock)
+	configFile := userSSHConfigFile
+	userSSHConfigFile = func() string { return "" }
+	defer func() { userSSHConfigFile = configFile }()
+
+	dir, err := ioutil.TempDir("", "sqls-tunnel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bastion := startTestSSHServer(t)
+	defer bastion.listener.Close()
+	target := startTestSSHServer(t)
+	defer target.listener.Close()
+	echo := startEchoServer(t)
+	defer echo.Close()
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	knownHosts := ""
+	for _, s := range []*testSSHServer{bastion, target} {
+		knownHosts += knownhosts.Line([]string{knownhosts.Normalize(s.addr().String())}, s.hostKey.PublicKey()) + "\n"
+	}
+	if err := ioutil.WriteFile(knownHostsFile, []byte(knownHosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		tunnel, err := OpenTunnel(target.sshConfig(knownHostsFile), echo.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tunnel.Close()
+		assertEcho(t, tunnel.LocalAddr().String())
+
+		// the lost SSH connection is dialed again
+		target.dropConnections()
+		assertEcho(t, tunnel.LocalAddr().String())
+	})
+
+	t.Run("proxy jump", func(t *testing.T) {
+		cfg := target.sshConfig(knownHostsFile)
+		cfg.ProxyJump = []*SSHConfig{bastion.sshConfig(knownHostsFile)}
+		tunnel, err := OpenTunnel(cfg, echo.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tunnel.Close()
+		assertEcho(t, tunnel.LocalAddr().String())
+
+		bastion.dropConnections()
+		assertEcho(t, tunnel.LocalAddr().String())
+	})
+
+	t.Run("data source name", func(t *testing.T) {
+		echoAddr := echo.Addr().(*net.TCPAddr)
+		pgpass := filepath.Join(dir, "pgpass")
+		line := "127.0.0.1:" + strconv.Itoa(echoAddr.Port) + ":sales:report:hunter2\n"
+		if err := ioutil.WriteFile(pgpass, []byte(line), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Setenv("PGPASSFILE", pgpass)
+		defer os.Unsetenv("PGPASSFILE")
+
+		var got *DBConfig
+		open := func(cfg *DBConfig) (*DBConnection, error) {
+			got = cfg
+			return &DBConnection{}, nil
+		}
+		conn, err := openViaSSH(open, &DBConfig{
+			Driver:         "postgresql",
+			DataSourceName: "postgres://report@127.0.0.1:" + strconv.Itoa(echoAddr.Port) + "/sales?sslmode=disable",
+			SSHCfg:         target.sshConfig(knownHostsFile),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		opts, err := parsePostgresDSN(got.DataSourceName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the password of the database host is used, not of the local end of the tunnel
+		if opts["password"] != "hunter2" || opts["dbname"] != "sales" || opts["sslmode"] != "disable" {
+			t.Errorf("unexpected dataSourceName %s", got.DataSourceName)
+		}
+		assertEcho(t, net.JoinHostPort(opts["host"], opts["port"]))
+	})
+
+	t.Run("postgresql verify-full", func(t *testing.T) {
+		var got *DBConfig
+		open := func(cfg *DBConfig) (*DBConnection, error) {
+			got = cfg
+			return &DBConnection{}, nil
+		}
+		echoAddr := echo.Addr().(*net.TCPAddr)
+		conn, err := openViaSSH(open, &DBConfig{
+			Driver: "postgresql",
+			Proto:  ProtoTCP,
+			Host:   "127.0.0.1",
+			Port:   echoAddr.Port,
+			TLS:    &TLSConfig{Mode: TLSModeVerifyFull},
+			SSHCfg: target.sshConfig(knownHostsFile),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		// lib/pq cannot verify the host name through the tunnel
+		if got.TLS.Mode != TLSModeVerifyCA || got.TLS.ServerName != "127.0.0.1" {
+			t.Errorf("unexpected tls config %+v", got.TLS)
+		}
+	})
+
+	t.Run("unknown host key", func(t *testing.T) {
+		emptyKnownHosts := filepath.Join(dir, "empty_known_hosts")
+		if err := ioutil.WriteFile(emptyKnownHosts, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenTunnel(target.sshConfig(emptyKnownHosts), echo.Addr().String()); err == nil {
+			t.Error("expected host key error")
+		}
+	})
+}
+
+func TestParseProxyJump(t *testing.T) {
+	got := parseProxyJump("admin@bastion:2222,jump2")
+	if len(got) != 2 {
+		t.Fatalf("got %d hops, want 2", len(got))
+	}
+	if got[0].User != "admin" || got[0].Host != "bastion" || got[0].Port != 2222 {
+		t.Errorf("unexpected first hop, %+v", got[0])
+	}
+	if got[1].User != "" || got[1].Host != "jump2" || got[1].Port != 0 {
+		t.Errorf("unexpected second hop, %+v", got[1])
+	}
+	if hops := parseProxyJump("none"); len(hops) != 0 {
+		t.Errorf("got %d hops for none", len(hops))
+	}
+}
@@ -3,13 +3,22 @@ package database
 import (
 	"context"
 	"log"
+	"os"
 	"sync"
+
+	"github.com/lighttiger2505/sqls/parser/parseutil"
 )
 
 type Worker struct {
 	dbRepo  DBRepository
 	dbCache *DBCache
 
+	store      *CacheStore
+	dbCfg      *DBConfig
+	generation int
+	// loadingGen is the generation the columns are being loaded for in the background, 0 if not loading
+	loadingGen int
+
 	done   chan struct{}
 	update chan struct{}
 	lock   sync.Mutex
@@ -23,21 +32,39 @@ func NewWorker() *Worker {
 }
 
 func (w *Worker) Cache() *DBCache {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 	return w.dbCache
 }
 
-func (w *Worker) setCache(c *DBCache) {
+// setCacheOf replaces the cache unless the connection was switched after gen, and reports whether it is replaced.
+func (w *Worker) setCacheOf(c *DBCache, gen int) bool {
 	w.lock.Lock()
 	defer w.lock.Unlock()
+	if gen != w.generation {
+		return false
+	}
 	w.dbCache = c
+	return true
 }
 
-func (w *Worker) setColumnCache(col map[string][]*ColumnDesc) {
+// setColumnCache replaces the columns unless the connection was switched after gen, and reports whether it is replaced.
+func (w *Worker) setColumnCache(col map[string][]*ColumnDesc, gen int) bool {
 	w.lock.Lock()
 	defer w.lock.Unlock()
-	if w.dbCache != nil {
-		w.dbCache.ColumnsWithParent = col
+	if gen != w.generation || w.dbCache == nil {
+		return false
 	}
+	// replace the cache instead of modifying it, readers may hold the current one
+	newCache := *w.dbCache
+	newCache.ColumnsWithParent = col
+	w.dbCache = &newCache
+	return true
+}
+
+// SetCacheStore enables persisting the cache so that it is available immediately on the next start.
+func (w *Worker) SetCacheStore(store *CacheStore) {
+	w.store = store
 }
 
 func (w *Worker) Start() {
@@ -49,13 +76,28 @@ func (w *Worker) Start() {
 				log.Println("db worker: done")
 				return
 			case <-w.update:
-				generator := NewDBCacheUpdater(w.dbRepo)
-				col, err := generator.GenerateDBCacheSecondary(context.Background())
+				w.lock.Lock()
+				repo, gen := w.dbRepo, w.generation
+				w.loadingGen = gen
+				w.lock.Unlock()
+				if repo == nil {
+					continue
+				}
+				col, err := w.newGenerator(repo).GenerateDBCacheSecondary(context.Background())
+				w.lock.Lock()
+				w.loadingGen = 0
+				w.lock.Unlock()
 				if err != nil {
+					// keep the current cache, an empty one must not be stored
 					log.Println(err)
+					continue
+				}
+				if !w.setColumnCache(col, gen) {
+					// the connection was switched while generating
+					continue
 				}
-				w.setColumnCache(col)
 				log.Println("db worker: Update db chache secondary complete")
+				w.saveCache(gen)
 			}
 		}
 	}()
@@ -65,26 +107,203 @@ func (w *Worker) Stop() {
 	close(w.done)
 }
 
-func (w *Worker) ReCache(ctx context.Context, repo DBRepository) error {
+func (w *Worker) ReCache(ctx context.Context, repo DBRepository, cfg *DBConfig) error {
+	return w.Load(ctx, w.Switch(repo, cfg))
+}
+
+// Switch makes the worker use the connection and returns the generation to pass to Load.
+// The caches being loaded for the previous connection are discarded.
+func (w *Worker) Switch(repo DBRepository, cfg *DBConfig) int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 	w.dbRepo = repo
-	if err := w.updateAllCache(ctx); err != nil {
+	w.dbCfg = cfg
+	w.generation++
+	return w.generation
+}
+
+// Load loads the cache of the connection given to Switch, it does nothing if the connection was switched again.
+func (w *Worker) Load(ctx context.Context, gen int) error {
+	w.lock.Lock()
+	repo, cfg, current := w.dbRepo, w.dbCfg, gen == w.generation
+	w.lock.Unlock()
+	if !current {
+		return nil
+	}
+
+	if cache := w.loadCache(cfg); cache != nil {
+		w.newGenerator(repo).AttachLazyColumns(cache)
+		if !w.setCacheOf(cache, gen) {
+			return nil
+		}
+		log.Println("db worker: Load db chache from disk")
+		// revalidate the stored cache in the background
+		go func() {
+			if err := w.updateAllCache(context.Background(), repo, gen); err != nil {
+				log.Println(err)
+				return
+			}
+			w.updateAdditionalCache()
+		}()
+		return nil
+	}
+
+	if err := w.updateAllCache(ctx, repo, gen); err != nil {
 		return err
 	}
 	w.updateAdditionalCache()
 	return nil
 }
 
-func (w *Worker) updateAllCache(ctx context.Context) error {
-	generator := NewDBCacheUpdater(w.dbRepo)
+// RefreshAll regenerates the whole cache from the current connection.
+func (w *Worker) RefreshAll(ctx context.Context) error {
+	w.lock.Lock()
+	repo := w.dbRepo
+	w.generation++
+	gen := w.generation
+	w.lock.Unlock()
+	if repo == nil {
+		return nil
+	}
+
+	if err := w.updateAllCache(ctx, repo, gen); err != nil {
+		return err
+	}
+	w.updateAdditionalCache()
+	return nil
+}
+
+// RefreshTables reloads only the given tables, tables without schema belong to the default schema.
+func (w *Worker) RefreshTables(ctx context.Context, tables []*parseutil.TableInfo) error {
+	w.lock.Lock()
+	repo := w.dbRepo
+	cache := w.dbCache
+	w.lock.Unlock()
+	if repo == nil {
+		return nil
+	}
+	if cache == nil {
+		return w.RefreshAll(ctx)
+	}
+
+	// the columns loaded before the tables are changed are discarded
+	w.lock.Lock()
+	w.generation++
+	gen := w.generation
+	w.lock.Unlock()
+
+	schemaTables := map[string][]string{}
+	schemaNames := []string{}
+	for _, table := range tables {
+		schemaName := table.DatabaseSchema
+		if schemaName == "" {
+			schemaName = cache.TableSchema(table.Name)
+		}
+		if _, ok := schemaTables[schemaName]; !ok {
+			schemaNames = append(schemaNames, schemaName)
+		}
+		schemaTables[schemaName] = append(schemaTables[schemaName], table.Name)
+	}
+
+	generator := w.newGenerator(repo)
+	for _, schemaName := range schemaNames {
+		newCache, err := generator.RefreshTables(ctx, cache, schemaName, schemaTables[schemaName])
+		if err != nil {
+			return err
+		}
+		cache = newCache
+	}
+
+	w.lock.Lock()
+	current := gen == w.generation
+	if current {
+		w.dbCache = cache
+	}
+	reload := current && w.loadingGen != 0
+	w.lock.Unlock()
+	if !current {
+		// the connection was switched while refreshing
+		return nil
+	}
+	log.Println("db worker: Refresh db chache tables complete")
+	if reload {
+		// the columns being loaded are discarded, load them again
+		select {
+		case w.update <- struct{}{}:
+		default:
+		}
+	}
+	w.saveCache(gen)
+	return nil
+}
+
+func (w *Worker) updateAllCache(ctx context.Context, repo DBRepository, gen int) error {
+	generator := w.newGenerator(repo)
 	cache, err := generator.GenerateDBCachePrimary(ctx)
 	if err != nil {
 		return err
 	}
-	w.setCache(cache)
+	if !w.setCacheOf(cache, gen) {
+		// the connection was switched while generating
+		return nil
+	}
 	log.Println("db worker: Update db chache primary complete")
+	if w.lazyColumns() {
+		// no columns are loaded in the background in lazy mode, which saves the cache otherwise
+		w.saveCache(gen)
+	}
 	return nil
 }
 
+func (w *Worker) newGenerator(repo DBRepository) *DBCacheGenerator {
+	generator := NewDBCacheUpdater(repo)
+	w.lock.Lock()
+	cfg := w.dbCfg
+	w.lock.Unlock()
+	if cfg != nil && cfg.LazyColumns {
+		generator.EnableLazyColumns(cfg.ColumnCacheSize)
+	}
+	return generator
+}
+
+func (w *Worker) lazyColumns() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.dbCfg != nil && w.dbCfg.LazyColumns
+}
+
+func (w *Worker) loadCache(cfg *DBConfig) *DBCache {
+	if w.store == nil || cfg == nil {
+		return nil
+	}
+	cache, err := w.store.Load(cfg)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("db worker: cannot load db cache,", err)
+		}
+		return nil
+	}
+	return cache
+}
+
+// saveCache stores the cache unless the connection was switched after gen
+func (w *Worker) saveCache(gen int) {
+	// encode and write outside of the lock not to block the readers, the cache is never modified in place
+	w.lock.Lock()
+	store, cfg, cache, current := w.store, w.dbCfg, w.dbCache, gen == w.generation
+	w.lock.Unlock()
+	if !current || store == nil || cfg == nil || cache == nil {
+		return
+	}
+	if err := store.Save(cfg, cache); err != nil {
+		log.Println("db worker: cannot save db cache,", err)
+	}
+}
+
 func (w *Worker) updateAdditionalCache() {
+	// all columns are never loaded in lazy mode
+	if w.lazyColumns() {
+		return
+	}
 	w.update <- struct{}{}
 }
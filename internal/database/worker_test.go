@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWorkerSecondaryCacheError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	called := make(chan struct{}, 1)
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	repo.MockDescribeDatabaseTable = func(ctx context.Context) ([]*ColumnDesc, error) {
+		called <- struct{}{}
+		return nil, errors.New("permission denied")
+	}
+
+	store := NewCacheStore(dir)
+	w := NewWorker()
+	w.SetCacheStore(store)
+	w.Start()
+	defer w.Stop()
+
+	cfg := &DBConfig{Driver: "mock"}
+	if err := w.ReCache(context.Background(), repo, cfg); err != nil {
+		t.Fatal(err)
+	}
+	primary := w.Cache()
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("secondary cache is not generated")
+	}
+	// wait for the worker to handle the error
+	time.Sleep(50 * time.Millisecond)
+
+	if got := w.Cache(); got != primary || len(got.ColumnsWithParent) == 0 {
+		t.Errorf("the cache is replaced after the error, got %d columns", len(got.ColumnsWithParent))
+	}
+	if _, err := store.Load(cfg); !os.IsNotExist(err) {
+		t.Errorf("the cache is saved after the error, %+v", err)
+	}
+}
+
+func TestWorkerSaveLazyCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo := NewMockDBRepository(nil).(*MockDBRepository)
+	store := NewCacheStore(dir)
+	w := NewWorker()
+	w.SetCacheStore(store)
+	w.Start()
+	defer w.Stop()
+
+	cfg := &DBConfig{Driver: "mock", LazyColumns: true}
+	if err := w.ReCache(context.Background(), repo, cfg); err != nil {
+		t.Fatal(err)
+	}
+	// all columns are never loaded in lazy mode, the primary cache is stored
+	stored, err := store.Load(cfg)
+	if err != nil {
+		t.Fatalf("the cache is not saved, %+v", err)
+	}
+	if len(stored.SortedTables()) == 0 {
+		t.Error("no tables are saved")
+	}
+}
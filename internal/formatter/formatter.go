@@ -330,11 +330,15 @@ func formatIdentifer(node ast.Node, env *formatEnvironment) ast.Node {
 }
 
 func formatMemberIdentifer(node *ast.MemberIdentifer, env *formatEnvironment) ast.Node {
-	results := []ast.Node{
+	results := []ast.Node{}
+	if node.Schema != nil {
+		results = append(results, Eval(node.Schema, env), periodNode)
+	}
+	results = append(results,
 		Eval(node.Parent, env),
 		periodNode,
 		Eval(node.Child, env),
-	}
+	)
 	return &ast.ItemWith{Toks: results}
 }
 
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.CodeActionParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	f, ok := s.files[params.TextDocument.URI]
+	if !s.codeActionLiteral {
+		// the client accepts only commands, and shows them as the menu of sqls
+		commands := []lsp.Command{}
+		if ok {
+			for _, action := range statementActions(params, f.Text) {
+				commands = append(commands, *action.Command)
+			}
+		}
+		return append(commands, commonCommands(params.TextDocument.URI)...), nil
+	}
+
+	actions := []lsp.CodeAction{}
+	if ok {
+		actions = append(actions, s.quickFixes(params, f.Text)...)
+		// code actions are requested on every cursor move, they use only the connection already open
+		if sess := s.openedSession(params.TextDocument.URI); sess != nil {
+			if action, ok := expandStarAction(params.TextDocument.URI, f.Text, params.Range.Start, sess.worker.Cache(), sess.driver()); ok {
+				actions = append(actions, *action)
+			}
+		}
+		actions = append(actions, statementActions(params, f.Text)...)
+	}
+	return filterCodeActions(actions, params.Context.Only), nil
+}
+
+// statementActions executes the statement at the range, and explains it if it is a query
+func statementActions(params lsp.CodeActionParams, text string) []lsp.CodeAction {
+	stmts, err := getStatements(text)
+	if err != nil {
+		return nil
+	}
+	for _, stmt := range stmts {
+		query := statementText(stmt)
+		if query == "" {
+			continue
+		}
+		rng := statementRange(stmt)
+		if !inRange(params.Range.Start, rng) {
+			continue
+		}
+		uri := params.TextDocument.URI
+		actions := []lsp.CodeAction{
+			{
+				Title: "Execute statement",
+				Command: &lsp.Command{
+					Title:     "Execute statement",
+					Command:   CommandExecuteQuery,
+					Arguments: []interface{}{uri, rng},
+				},
+			},
+		}
+		if isExplainable(query) {
+			actions = append(actions, lsp.CodeAction{
+				Title: "Explain",
+				Command: &lsp.Command{
+					Title:     "Explain",
+					Command:   CommandExplainQuery,
+					Arguments: []interface{}{uri, rng},
+				},
+			})
+		}
+		return actions
+	}
+	return nil
+}
+
+// quickFixes replaces the unknown columns of the diagnostics with the closest columns
+func (s *Server) quickFixes(params lsp.CodeActionParams, text string) []lsp.CodeAction {
+	var targets []lsp.Diagnostic
+	for _, d := range params.Context.Diagnostics {
+		if d.Source != nil && *d.Source == diagnosticSource && d.Code != nil && *d.Code == diagnosticCodeUnknownColumn {
+			targets = append(targets, d)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	sess := s.openedSession(params.TextDocument.URI)
+	if sess == nil {
+		return nil
+	}
+
+	actions := []lsp.CodeAction{}
+	unknowns := findUnknownColumns(text, sess.worker.Cache())
+	for _, d := range targets {
+		for _, unknown := range unknowns {
+			if unknown.rng != d.Range || unknown.replacement == "" {
+				continue
+			}
+			actions = append(actions, lsp.CodeAction{
+				Title:       fmt.Sprintf("Change to %s", unknown.replacement),
+				Kind:        lsp.QuickFix,
+				Diagnostics: []lsp.Diagnostic{d},
+				IsPreferred: true,
+				Edit: &lsp.WorkspaceEdit{
+					Changes: map[string][]lsp.TextEdit{
+						params.TextDocument.URI: {{Range: unknown.rng, NewText: unknown.replacement}},
+					},
+				},
+			})
+		}
+	}
+	return actions
+}
+
+// commonCommands are the commands which do not depend on the range, offered to the clients accepting only commands
+func commonCommands(uri string) []lsp.Command {
+	return []lsp.Command{
+		{
+			Title:     "Execute Query",
+			Command:   CommandExecuteQuery,
+			Arguments: []interface{}{uri},
+		},
+		{
+			Title:     "Show Databases",
+			Command:   CommandShowDatabases,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Show Schemas",
+			Command:   CommandShowSchemas,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Show Connections",
+			Command:   CommandShowConnections,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Switch Database",
+			Command:   CommandSwitchDatabase,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Switch Connections",
+			Command:   CommandSwitchConnection,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Refresh Schema Cache",
+			Command:   CommandRefreshSchema,
+			Arguments: []interface{}{},
+		},
+	}
+}
+
+// filterCodeActions keeps the actions of the kinds the client asks for, actions without kind are dropped then
+func filterCodeActions(actions []lsp.CodeAction, only []lsp.CodeActionKind) []lsp.CodeAction {
+	if len(only) == 0 {
+		return actions
+	}
+	filtered := []lsp.CodeAction{}
+	for _, action := range actions {
+		for _, kind := range only {
+			if action.Kind != "" && (action.Kind == kind || strings.HasPrefix(string(action.Kind), string(kind)+".")) {
+				filtered = append(filtered, action)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func inRange(pos lsp.Position, rng lsp.Range) bool {
+	if pos.Line < rng.Start.Line || (pos.Line == rng.Start.Line && pos.Character < rng.Start.Character) {
+		return false
+	}
+	if pos.Line > rng.End.Line || (pos.Line == rng.End.Line && pos.Character > rng.End.Character) {
+		return false
+	}
+	return true
+}
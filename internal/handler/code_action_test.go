@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestFindUnknownColumns(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Driver: "mock"}},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	cases := []struct {
+		name  string
+		input string
+		want  []unknownColumn
+	}{
+		{
+			name:  "alias",
+			input: "SELECT ci.nme FROM city AS ci",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 0, Character: 13}}, table: "city", name: "nme", replacement: "Name"},
+			},
+		},
+		{
+			name:  "table name",
+			input: "SELECT 1 FROM city WHERE city.Popultion > 1",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 30}, End: lsp.Position{Line: 0, Character: 39}}, table: "city", name: "Popultion", replacement: "Population"},
+			},
+		},
+		{
+			name:  "quoted",
+			input: "SELECT ci.`nme` FROM city ci",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 0, Character: 15}}, table: "city", name: "nme", replacement: "`Name`"},
+			},
+		},
+		{
+			name:  "no close column",
+			input: "SELECT ci.xyzzy FROM city ci",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 0, Character: 15}}, table: "city", name: "xyzzy"},
+			},
+		},
+		{
+			name:  "known columns",
+			input: "SELECT ci.name, co.Code, ci.* FROM world.city ci JOIN country co ON ci.CountryCode = co.Code",
+		},
+		{
+			name:  "subquery",
+			input: "SELECT sub.nme FROM (SELECT Name AS nme FROM city) AS sub",
+		},
+		{
+			name:  "unknown table",
+			input: "SELECT t.nme FROM nothing t",
+		},
+		{
+			name:  "statements",
+			input: "SELECT ci.nme FROM city ci;\nSELECT ci.Name FROM country ci",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 0, Character: 13}}, table: "city", name: "nme", replacement: "Name"},
+			},
+		},
+		{
+			name:  "in subquery",
+			input: "SELECT ci.Name FROM city ci WHERE ci.CountryCode IN (SELECT co.Cod FROM country co)",
+			want: []unknownColumn{
+				{rng: lsp.Range{Start: lsp.Position{Line: 0, Character: 63}, End: lsp.Position{Line: 0, Character: 66}}, table: "country", name: "Cod", replacement: "Code"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []unknownColumn
+			for _, unknown := range findUnknownColumns(tt.input, tx.server.worker.Cache()) {
+				got = append(got, *unknown)
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeAction(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Driver: "mock"}},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+	tx.server.codeActionLiteral = true
+
+	uri := "file:///test.sql"
+	text := "SELECT ci.nme FROM city AS ci;\nDELETE FROM city WHERE ID = 1;\n"
+	tx.textDocumentDidOpen(t, uri, text)
+
+	unknown := findUnknownColumns(text, tx.server.worker.Cache())
+	if len(unknown) != 1 {
+		t.Fatalf("got %d unknown columns, want 1", len(unknown))
+	}
+	diagnostic := unknown[0].diagnostic()
+	stmtRange := func(line, end int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: line, Character: 0}, End: lsp.Position{Line: line, Character: end}}
+	}
+
+	codeActions := func(t *testing.T, line, char int, diagnostics []lsp.Diagnostic, only ...lsp.CodeActionKind) []lsp.CodeAction {
+		t.Helper()
+		params := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Range:        lsp.Range{Start: lsp.Position{Line: line, Character: char}, End: lsp.Position{Line: line, Character: char}},
+			Context:      lsp.CodeActionContext{Diagnostics: diagnostics, Only: only},
+		}
+		var got []lsp.CodeAction
+		if err := tx.conn.Call(tx.ctx, "textDocument/codeAction", params, &got); err != nil {
+			t.Fatal("conn.Call textDocument/codeAction:", err)
+		}
+		return got
+	}
+	titles := func(actions []lsp.CodeAction) []string {
+		titles := []string{}
+		for _, action := range actions {
+			titles = append(titles, action.Title)
+		}
+		return titles
+	}
+	t.Run("query", func(t *testing.T) {
+		got := codeActions(t, 0, 11, []lsp.Diagnostic{diagnostic})
+		want := []string{"Change to Name", "Execute statement", "Explain"}
+		if diff := cmp.Diff(want, titles(got)); diff != "" {
+			t.Fatalf("unmatch (- want, + got):\n%s", diff)
+		}
+		wantEdit := &lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				uri: {{Range: diagnostic.Range, NewText: "Name"}},
+			},
+		}
+		if diff := cmp.Diff(wantEdit, got[0].Edit); diff != "" {
+			t.Errorf("unmatch edit (- want, + got):\n%s", diff)
+		}
+		if got[0].Kind != lsp.QuickFix {
+			t.Errorf("got kind %q, want quickfix", got[0].Kind)
+		}
+		wantArgs := []interface{}{uri, map[string]interface{}{
+			"start": map[string]interface{}{"line": float64(0), "character": float64(0)},
+			"end":   map[string]interface{}{"line": float64(0), "character": float64(30)},
+		}}
+		if diff := cmp.Diff(wantArgs, got[1].Command.Arguments); diff != "" {
+			t.Errorf("unmatch arguments (- want, + got):\n%s", diff)
+		}
+		if got[2].Command.Command != CommandExplainQuery {
+			t.Errorf("got command %q, want %q", got[2].Command.Command, CommandExplainQuery)
+		}
+	})
+
+	t.Run("statement", func(t *testing.T) {
+		got := codeActions(t, 1, 3, nil)
+		want := []string{"Execute statement"}
+		if diff := cmp.Diff(want, titles(got)); diff != "" {
+			t.Errorf("unmatch (- want, + got):\n%s", diff)
+		}
+	})
+
+	t.Run("only quickfix", func(t *testing.T) {
+		got := codeActions(t, 0, 11, []lsp.Diagnostic{diagnostic}, lsp.QuickFix)
+		if diff := cmp.Diff([]string{"Change to Name"}, titles(got)); diff != "" {
+			t.Errorf("unmatch (- want, + got):\n%s", diff)
+		}
+	})
+
+	t.Run("commands only", func(t *testing.T) {
+		tx.server.codeActionLiteral = false
+		defer func() { tx.server.codeActionLiteral = true }()
+		params := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Range:        stmtRange(1, 0),
+			Context:      lsp.CodeActionContext{Diagnostics: []lsp.Diagnostic{diagnostic}},
+		}
+		var got []lsp.Command
+		if err := tx.conn.Call(tx.ctx, "textDocument/codeAction", params, &got); err != nil {
+			t.Fatal("conn.Call textDocument/codeAction:", err)
+		}
+		want := []string{"Execute statement", "Execute Query", "Show Databases", "Show Schemas", "Show Connections", "Switch Database", "Switch Connections", "Refresh Schema Cache"}
+		gotTitles := []string{}
+		for _, command := range got {
+			gotTitles = append(gotTitles, command.Title)
+		}
+		if diff := cmp.Diff(want, gotTitles); diff != "" {
+			t.Errorf("unmatch (- want, + got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/ast/astutil"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+	"github.com/lighttiger2505/sqls/parser"
+	"github.com/lighttiger2505/sqls/token"
+)
+
+var diagnosticCodeUnknownColumn = "unknown-column"
+
+// unknownColumn is a qualified column such as "ci.nme" which the table does not have
+type unknownColumn struct {
+	rng   lsp.Range
+	table string
+	name  string
+	// replacement is the closest column quoted like the original, empty if no column is close
+	replacement string
+}
+
+func (c *unknownColumn) diagnostic() lsp.Diagnostic {
+	msg := fmt.Sprintf("unknown column %q in table %q", c.name, c.table)
+	if c.replacement != "" {
+		msg += fmt.Sprintf(", did you mean %s?", c.replacement)
+	}
+	return lsp.Diagnostic{
+		Range:    c.rng,
+		Severity: lsp.SeverityWarning,
+		Code:     &diagnosticCodeUnknownColumn,
+		Source:   &diagnosticSource,
+		Message:  msg,
+	}
+}
+
+// findUnknownColumns checks the columns qualified with a table name or alias against the schema cache.
+// Unqualified columns are not checked since they cannot be told from aliases and functions.
+// Only the columns already cached are checked, the database is never queried.
+func findUnknownColumns(text string, dbCache *database.DBCache) []*unknownColumn {
+	if dbCache == nil {
+		return nil
+	}
+	parsed, err := parser.Parse(text)
+	if err != nil {
+		return nil
+	}
+
+	var unknowns []*unknownColumn
+	// the environment is collected once for each statement and subquery
+	envs := map[ast.Node]*hoverEnvironment{}
+	walkScopes(parsed, parsed, func(node, scope ast.Node) bool {
+		mi, ok := node.(*ast.MemberIdentifer)
+		if !ok {
+			return true
+		}
+		if mi.Schema != nil || mi.ParentTok == nil || mi.ChildTok == nil || mi.Child == nil {
+			return false
+		}
+		name := mi.ChildTok.NoQuateString()
+		if name == "*" {
+			return false
+		}
+		env, ok := envs[scope]
+		if !ok {
+			env, err = collectEnvirontment(parsed, token.Pos{Line: mi.Pos().Line, Col: mi.Pos().Col + 1})
+			if err != nil {
+				env = nil
+			}
+			envs[scope] = env
+		}
+		if env == nil {
+			return false
+		}
+		if unknown, ok := checkMemberColumn(mi, name, env, dbCache); ok {
+			unknowns = append(unknowns, unknown)
+		}
+		return false
+	})
+	return unknowns
+}
+
+func checkMemberColumn(mi *ast.MemberIdentifer, name string, env *hoverEnvironment, dbCache *database.DBCache) (*unknownColumn, bool) {
+	parent := mi.ParentTok.NoQuateString()
+	if env.isSubQuery(parent) {
+		return nil, false
+	}
+	for _, table := range env.tables {
+		if table.Alias != parent && !(table.Alias == "" && table.Name == parent) {
+			continue
+		}
+		if len(table.SubQueryColumns) > 0 {
+			return nil, false
+		}
+		cols, ok := dbCache.CachedColumnDescsBySchema(table.DatabaseSchema, table.Name)
+		if !ok {
+			return nil, false
+		}
+		names := make([]string, len(cols))
+		for i, col := range cols {
+			if strings.EqualFold(col.Name, name) {
+				return nil, false
+			}
+			names[i] = col.Name
+		}
+		unknown := &unknownColumn{
+			rng: lsp.Range{
+				Start: lsp.Position{Line: mi.Child.Pos().Line, Character: mi.Child.Pos().Col},
+				End:   lsp.Position{Line: mi.Child.End().Line, Character: mi.Child.End().Col},
+			},
+			table: table.Name,
+			name:  name,
+		}
+		if closest, ok := closestName(name, names); ok {
+			unknown.replacement = requote(mi.Child.String(), name, closest)
+		}
+		return unknown, true
+	}
+	return nil, false
+}
+
+// walkScopes is walkNodes which also passes the innermost statement or subquery of the node
+func walkScopes(node, scope ast.Node, fn func(node, scope ast.Node) bool) {
+	switch v := node.(type) {
+	case *ast.Statement:
+		scope = v
+	case *ast.Parenthesis:
+		if isSubQueryParenthesis(v) {
+			scope = v
+		}
+	}
+	if !fn(node, scope) {
+		return
+	}
+	if list, ok := node.(ast.TokenList); ok {
+		for _, child := range list.GetTokens() {
+			walkScopes(child, scope, fn)
+		}
+	}
+}
+
+func isSubQueryParenthesis(list ast.TokenList) bool {
+	reader := astutil.NewNodeReader(list)
+	if !reader.NextNode(false) || !reader.NextNode(false) {
+		return false
+	}
+	return reader.CurNodeIs(astutil.NodeMatcher{ExpectKeyword: []string{"SELECT"}})
+}
+
+// walkNodes calls fn for the nodes in depth-first order, the children are skipped when fn returns false
+func walkNodes(node ast.Node, fn func(ast.Node) bool) {
+	if !fn(node) {
+		return
+	}
+	if list, ok := node.(ast.TokenList); ok {
+		for _, child := range list.GetTokens() {
+			walkNodes(child, fn)
+		}
+	}
+}
+
+// closestName returns the name with the smallest edit distance, when the distance is at most half of the length
+func closestName(name string, names []string) (string, bool) {
+	closest, min := "", -1
+	for _, n := range names {
+		d := editDistance(strings.ToLower(name), strings.ToLower(n))
+		if min < 0 || d < min {
+			closest, min = n, d
+		}
+	}
+	if min < 0 || min*2 > len([]rune(name))+1 {
+		return "", false
+	}
+	return closest, true
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(x int, ys ...int) int {
+	for _, y := range ys {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
+
+// requote quotes the name like the original identifier such as `nme` and "nme"
+func requote(raw, unquoted, name string) string {
+	if raw == unquoted || len(raw) < 2 {
+		return name
+	}
+	return raw[:1] + name + raw[len(raw)-1:]
+}
@@ -306,6 +306,100 @@ var selectExprCase = []completionTestCase{
 			"countrylanguage",
 		},
 	},
+	{
+		name:  "stored functions",
+		input: "select  from city",
+		line:  0,
+		col:   7,
+		want: []string{
+			"city_population",
+			"refresh_country",
+		},
+	},
+	{
+		name:  "filterd stored functions",
+		input: "select city_p from city",
+		line:  0,
+		col:   13,
+		want: []string{
+			"city_population",
+		},
+		bad: []string{
+			"refresh_country",
+		},
+	},
+	{
+		name:  "built-in functions",
+		input: "select coa from city",
+		line:  0,
+		col:   10,
+		want: []string{
+			"coalesce",
+		},
+	},
+	{
+		name:  "built-in functions are not schema member",
+		input: "select world.coa from city",
+		line:  0,
+		col:   16,
+		bad: []string{
+			"coalesce",
+		},
+	},
+	{
+		name:  "stored functions of schema",
+		input: "select world. from city",
+		line:  0,
+		col:   13,
+		want: []string{
+			"city_population",
+			"refresh_country",
+		},
+	},
+	{
+		name:  "schema qualified table columns",
+		input: "select world.city. from world.city",
+		line:  0,
+		col:   18,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+		bad: []string{
+			"Code",
+			"city",
+		},
+	},
+	{
+		name:  "schema qualified table filterd columns",
+		input: "select world.country.Co from city",
+		line:  0,
+		col:   23,
+		want: []string{
+			"Code",
+			"Continent",
+			"Code2",
+		},
+		bad: []string{
+			"city",
+		},
+	},
+	{
+		name:  "schema qualified table reference alias columns",
+		input: "select c. from world.city as c",
+		line:  0,
+		col:   9,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
 }
 
 var tableReferenceCase = []completionTestCase{
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+// isConfigFile reports whether the document is a config file of sqls
+func (s *Server) isConfigFile(uri string) bool {
+	fp := uriToPath(uri)
+	if fp == "" {
+		return false
+	}
+	return fp == s.SpecificFilePath || fp == s.DefaultFilePath || filepath.Base(fp) == config.ProjectConfigFileName
+}
+
+func configDiagnostics(text string) []lsp.Diagnostic {
+	_, errs := config.Check([]byte(text))
+	lines := strings.Split(text, "\n")
+	diagnostics := []lsp.Diagnostic{}
+	for _, err := range errs {
+		line := 0
+		if err.Line > 0 {
+			line = err.Line - 1
+		}
+		start, end := 0, 0
+		if err.Column > 0 {
+			start = err.Column - 1
+		}
+		if line < len(lines) {
+			end = len(lines[line])
+		}
+		if start > end {
+			start = 0
+		}
+		diagnostics = append(diagnostics, lsp.Diagnostic{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: line, Character: start},
+				End:   lsp.Position{Line: line, Character: end},
+			},
+			Severity: lsp.SeverityError,
+			Source:   &diagnosticSource,
+			Message:  err.Message,
+		})
+	}
+	return diagnostics
+}
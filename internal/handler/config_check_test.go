@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestConfigDiagnostics(t *testing.T) {
+	text := "connections:\n  - alias: dev\n    driver: mysql\n    proto: tcp\n    prot: 3306\n"
+	got := configDiagnostics(text)
+	if len(got) != 1 {
+		t.Fatalf("got %+v, want 1 diagnostic", got)
+	}
+	want := lsp.Range{
+		Start: lsp.Position{Line: 4, Character: 0},
+		End:   lsp.Position{Line: 4, Character: 14},
+	}
+	if got[0].Range != want {
+		t.Errorf("got range %+v, want %+v", got[0].Range, want)
+	}
+	if got[0].Message != `unknown key "prot"` || got[0].Severity != lsp.SeverityError {
+		t.Errorf("unexpected diagnostic %+v", got[0])
+	}
+
+	// the validation errors start at the key
+	got = configDiagnostics("connections:\n  - alias: dev\n    driver: oracle\n")
+	want = lsp.Range{
+		Start: lsp.Position{Line: 2, Character: 4},
+		End:   lsp.Position{Line: 2, Character: 18},
+	}
+	if len(got) != 1 || got[0].Range != want {
+		t.Errorf("got %+v, want range %+v", got, want)
+	}
+
+	if got := configDiagnostics("connections:\n  - alias: dev\n    driver: mock\n"); len(got) != 0 {
+		t.Errorf("got %+v, want no diagnostics", got)
+	}
+}
+
+func TestIsConfigFile(t *testing.T) {
+	s := NewServer()
+	defer s.Stop()
+	s.DefaultFilePath = "/home/user/.config/sqls/config.yml"
+
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{uri: "file:///home/user/.config/sqls/config.yml", want: true},
+		{uri: "file:///work/repo/.sqls.yml", want: true},
+		{uri: "file:///work/repo/config.yml", want: false},
+		{uri: "file:///work/repo/query.sql", want: false},
+	}
+	for _, tt := range tests {
+		if got := s.isConfigFile(tt.uri); got != tt.want {
+			t.Errorf("isConfigFile(%q) got %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
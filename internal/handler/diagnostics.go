@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+	"github.com/lighttiger2505/sqls/token"
+)
+
+var diagnosticSource = "sqls"
+
+// publishDiagnostics reports the errors of a config file, or the dangerous statements and unknown columns of a SQL document
+func (s *Server) publishDiagnostics(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	f, ok := s.files[uri]
+	if !ok {
+		return nil
+	}
+	var diagnostics []lsp.Diagnostic
+	if s.isConfigFile(uri) {
+		diagnostics = configDiagnostics(f.Text)
+	} else {
+		diagnostics = statementDiagnostics(f.Text)
+		// the diagnostics never connect, the bound connection is opened by the requests using it
+		if sess := s.openedSession(uri); sess != nil {
+			for _, unknown := range findUnknownColumns(f.Text, sess.worker.Cache()) {
+				diagnostics = append(diagnostics, unknown.diagnostic())
+			}
+		}
+	}
+	return conn.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// statementDiagnostics warns of UPDATE and DELETE without WHERE, TRUNCATE and DROP
+func statementDiagnostics(text string) []lsp.Diagnostic {
+	diagnostics := []lsp.Diagnostic{}
+	stmts, err := getStatements(text)
+	if err != nil {
+		return diagnostics
+	}
+	for _, stmt := range stmts {
+		d, ok := parseutil.CheckDangerousStatement(stmt)
+		if !ok {
+			continue
+		}
+		diagnostics = append(diagnostics, lsp.Diagnostic{
+			Range:    statementRange(stmt),
+			Severity: lsp.SeverityWarning,
+			Source:   &diagnosticSource,
+			Message:  d.Reason,
+		})
+	}
+	return diagnostics
+}
+
+// statementRange is the range of the statement without the leading whitespaces and comments
+func statementRange(stmt *ast.Statement) lsp.Range {
+	start := stmt.Pos()
+	for _, node := range stmt.GetTokens() {
+		if tok, ok := node.(ast.Token); ok {
+			sqlTok := tok.GetToken()
+			if sqlTok.MatchKind(token.Whitespace) || sqlTok.MatchKind(token.Comment) {
+				continue
+			}
+		}
+		start = node.Pos()
+		break
+	}
+	end := stmt.End()
+	return lsp.Range{
+		Start: lsp.Position{Line: start.Line, Character: start.Col},
+		End:   lsp.Position{Line: end.Line, Character: end.Col},
+	}
+}
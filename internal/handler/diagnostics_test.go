@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestStatementDiagnostics(t *testing.T) {
+	text := "SELECT 1;\nDELETE FROM city WHERE ID = 1;\n  UPDATE city SET Name = 'x';\nDROP TABLE city"
+	got := statementDiagnostics(text)
+	want := []lsp.Diagnostic{
+		{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: 2, Character: 2},
+				End:   lsp.Position{Line: 2, Character: 29},
+			},
+			Severity: lsp.SeverityWarning,
+			Message:  "UPDATE without WHERE updates every row",
+		},
+		{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: 3, Character: 0},
+				End:   lsp.Position{Line: 3, Character: 15},
+			},
+			Severity: lsp.SeverityWarning,
+			Message:  "DROP cannot be undone",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Range != want[i].Range || got[i].Severity != want[i].Severity || got[i].Message != want[i].Message {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
@@ -8,70 +8,68 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"strconv"
 	"strings"
 
 	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/dialect"
 	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/lsp"
 	"github.com/lighttiger2505/sqls/parser"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+	"github.com/lighttiger2505/sqls/token"
 	"github.com/olekukonko/tablewriter"
 	"github.com/sourcegraph/jsonrpc2"
 	"golang.org/x/xerrors"
 )
 
 const (
-	CommandExecuteQuery     = "executeQuery"
-	CommandShowDatabases    = "showDatabases"
-	CommandShowSchemas      = "showSchemas"
-	CommandShowConnections  = "showConnections"
-	CommandSwitchDatabase   = "switchDatabase"
-	CommandSwitchConnection = "switchConnections"
+	CommandExecuteQuery        = "executeQuery"
+	CommandConfirmExecuteQuery = "confirmExecuteQuery"
+	CommandShowDatabases       = "showDatabases"
+	CommandShowSchemas         = "showSchemas"
+	CommandShowConnections     = "showConnections"
+	CommandSwitchDatabase      = "switchDatabase"
+	CommandSwitchConnection    = "switchConnections"
+	CommandDescribeTable       = "describeTable"
+	CommandRefreshSchema       = "refreshSchemaCache"
+	CommandExplainQuery        = "explainQuery"
 )
 
-func (h *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
-	if req.Params == nil {
-		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
-	}
+// commands are advertised by ExecuteCommandProvider
+var commands = []string{
+	CommandExecuteQuery,
+	CommandConfirmExecuteQuery,
+	CommandShowDatabases,
+	CommandShowSchemas,
+	CommandShowConnections,
+	CommandSwitchDatabase,
+	CommandSwitchConnection,
+	CommandDescribeTable,
+	CommandRefreshSchema,
+	CommandExplainQuery,
+}
 
-	var params lsp.CodeActionParams
-	if err := json.Unmarshal(*req.Params, &params); err != nil {
-		return nil, err
-	}
+// flagJSON makes showConnections, showDatabases and showSchemas return objects instead of text
+const flagJSON = "-json"
+
+// ConnectionInfo is a connection returned by showConnections with -json
+type ConnectionInfo struct {
+	// Index is the 1-based index for switchConnections
+	Index   int    `json:"index"`
+	Alias   string `json:"alias"`
+	Driver  string `json:"driver"`
+	Host    string `json:"host,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	DBName  string `json:"dbName,omitempty"`
+	Current bool   `json:"current"`
+}
 
-	commands := []lsp.Command{
-		{
-			Title:     "Execute Query",
-			Command:   CommandExecuteQuery,
-			Arguments: []interface{}{params.TextDocument.URI},
-		},
-		{
-			Title:     "Show Databases",
-			Command:   CommandShowDatabases,
-			Arguments: []interface{}{},
-		},
-		{
-			Title:     "Show Schemas",
-			Command:   CommandShowSchemas,
-			Arguments: []interface{}{},
-		},
-		{
-			Title:     "Show Connections",
-			Command:   CommandShowConnections,
-			Arguments: []interface{}{},
-		},
-		{
-			Title:     "Switch Database",
-			Command:   CommandSwitchDatabase,
-			Arguments: []interface{}{},
-		},
-		{
-			Title:     "Switch Connections",
-			Command:   CommandSwitchConnection,
-			Arguments: []interface{}{},
-		},
-	}
-	return commands, nil
+// DatabaseInfo is a database returned by showDatabases and a schema returned by showSchemas with -json
+type DatabaseInfo struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
 }
 
 func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -86,7 +84,9 @@ func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrp
 
 	switch params.Command {
 	case CommandExecuteQuery:
-		return s.executeQuery(ctx, params)
+		return s.executeQuery(ctx, params, false)
+	case CommandConfirmExecuteQuery:
+		return s.executeQuery(ctx, params, true)
 	case CommandShowDatabases:
 		return s.showDatabases(ctx, params)
 	case CommandShowSchemas:
@@ -94,18 +94,29 @@ func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrp
 	case CommandShowConnections:
 		return s.showConnections(ctx, params)
 	case CommandSwitchDatabase:
-		return s.switchDatabase(ctx, params)
+		return s.switchDatabase(ctx, conn, params)
 	case CommandSwitchConnection:
-		return s.switchConnections(ctx, params)
+		return s.switchConnections(ctx, conn, params)
+	case CommandDescribeTable:
+		return s.describeTable(ctx, params)
+	case CommandRefreshSchema:
+		return s.refreshSchemaCache(ctx, params)
+	case CommandExplainQuery:
+		return s.explainQuery(ctx, params)
 	}
 	return nil, fmt.Errorf("unsupported command: %v", params.Command)
 }
 
-func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
-	// parse execute command arguments
-	if s.dbConn == nil {
-		return nil, errors.New("database connection is not open")
-	}
+// queryArguments are the arguments of executeQuery and explainQuery, <File URI> [Range] [Flags...]
+type queryArguments struct {
+	uri string
+	// rng is the range of the statements to run, the whole document if nil
+	rng          *lsp.Range
+	showVertical bool
+	force        bool
+}
+
+func parseQueryArguments(params lsp.ExecuteCommandParams) (*queryArguments, error) {
 	if len(params.Arguments) == 0 {
 		return nil, fmt.Errorf("required arguments were not provided: <File URI>")
 	}
@@ -113,62 +124,205 @@ func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandPara
 	if !ok {
 		return nil, fmt.Errorf("specify the file uri as a string")
 	}
+	args := &queryArguments{uri: uri, rng: params.Range}
+	for _, arg := range params.Arguments[1:] {
+		switch arg := arg.(type) {
+		case string:
+			switch arg {
+			case "-show-vertical":
+				args.showVertical = true
+			case "-force":
+				args.force = true
+			}
+		case map[string]interface{}:
+			// the range of the statement given by the code action
+			b, err := json.Marshal(arg)
+			if err != nil {
+				return nil, err
+			}
+			var rng lsp.Range
+			if err := json.Unmarshal(b, &rng); err != nil {
+				return nil, fmt.Errorf("specify the range as a Range, %s", err)
+			}
+			args.rng = &rng
+		}
+	}
+	return args, nil
+}
+
+func (args *queryArguments) targetText(text string) string {
+	if args.rng == nil {
+		return text
+	}
+	return extractRangeText(
+		text,
+		args.rng.Start.Line,
+		args.rng.Start.Character,
+		args.rng.End.Line,
+		args.rng.End.Character,
+	)
+}
+
+// executeQuery runs the statements of the document, confirmed is true for confirmExecuteQuery.
+func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandParams, confirmed bool) (result interface{}, err error) {
+	// parse execute command arguments
+	args, err := parseQueryArguments(params)
+	if err != nil {
+		return nil, err
+	}
+	uri := args.uri
 	f, ok := s.files[uri]
 	if !ok {
 		return nil, fmt.Errorf("document not found, %q", uri)
 	}
-
-	showVertical := false
-	if len(params.Arguments) > 1 {
-		showVerticalFlag, ok := params.Arguments[1].(string)
-		if ok {
-			if showVerticalFlag == "-show-vertical" {
-				showVertical = true
-			}
-		}
+	sess, err := s.documentSession(ctx, uri)
+	if err != nil {
+		return nil, err
 	}
+	repo, err := sess.repository()
+	if err != nil {
+		return nil, err
+	}
+	showVertical, force := args.showVertical, args.force
 
 	// extract target query
-	text := f.Text
-	if params.Range != nil {
-		text = extractRangeText(
-			text,
-			params.Range.Start.Line,
-			params.Range.Start.Character,
-			params.Range.End.Line,
-			params.Range.End.Character,
-		)
-	}
-	stmts, err := getStatements(text)
+	stmts, err := getStatements(args.targetText(f.Text))
 	if err != nil {
 		return nil, err
 	}
+	targets := []*ast.Statement{}
+	queries := []string{}
+	for _, stmt := range stmts {
+		if query := strings.TrimSpace(stmt.String()); query != "" {
+			targets = append(targets, stmt)
+			queries = append(queries, query)
+		}
+	}
+	if err := rejectReadOnly(sess, queries); err != nil {
+		return nil, err
+	}
+	if !force {
+		if err := refuseDangerous(ctx, repo, targets); err != nil {
+			return nil, err
+		}
+	}
+	if msg, err := s.confirmExecution(sess, uri, queries, confirmed); err != nil || msg != "" {
+		return msg, err
+	}
 
 	// execute statements
 	buf := new(bytes.Buffer)
-	for _, stmt := range stmts {
-		query := strings.TrimSpace(stmt.String())
-		if query == "" {
-			continue
+	ddlTables := []*parseutil.TableInfo{}
+	refreshAll := false
+	for _, query := range queries {
+		if tables, ok := database.DDLTables(query, sess.driver()); ok {
+			if len(tables) == 0 {
+				refreshAll = true
+			}
+			ddlTables = append(ddlTables, tables...)
 		}
 
 		if _, isQuery := database.QueryExecType(query, ""); isQuery {
-			res, err := s.query(ctx, query, showVertical)
+			res, err := s.query(ctx, repo, query, showVertical)
 			if err != nil {
 				return nil, err
 			}
 			fmt.Fprintln(buf, res)
 		} else {
-			res, err := s.exec(ctx, query, showVertical)
+			res, err := s.exec(ctx, repo, query, showVertical)
 			if err != nil {
 				return nil, err
 			}
 			fmt.Fprintln(buf, res)
 		}
 	}
+
+	// the executed DDL changed the schema, reload the affected tables only if they are known
+	var refreshErr error
+	if refreshAll {
+		refreshErr = sess.worker.RefreshAll(ctx)
+	} else if len(ddlTables) > 0 {
+		refreshErr = sess.worker.RefreshTables(ctx, ddlTables)
+	}
+	if refreshErr != nil {
+		log.Println("cannot refresh schema cache,", refreshErr)
+		fmt.Fprintf(buf, "cannot refresh schema cache, %s", refreshErr)
+		fmt.Fprintln(buf, "")
+	}
+	return buf.String(), nil
+}
+
+// explainQuery shows the execution plans of the queries of the document
+func (s *Server) explainQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	args, err := parseQueryArguments(params)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := s.files[args.uri]
+	if !ok {
+		return nil, fmt.Errorf("document not found, %q", args.uri)
+	}
+	sess, err := s.documentSession(ctx, args.uri)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := sess.repository()
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := getStatements(args.targetText(f.Text))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "EXPLAIN "
+	if sess.driver() == dialect.DatabaseDriverSQLite3 {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+	buf := new(bytes.Buffer)
+	for _, stmt := range stmts {
+		query := statementText(stmt)
+		if query == "" {
+			continue
+		}
+		if !isExplainable(query) {
+			return nil, fmt.Errorf("%q cannot be explained, only queries can", query)
+		}
+		res, err := s.query(ctx, repo, prefix+query, args.showVertical)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(buf, res)
+	}
 	return buf.String(), nil
 }
 
+// isExplainable reports whether the statement is a query which EXPLAIN accepts
+func isExplainable(query string) bool {
+	typ, _ := database.QueryExecType(query, "")
+	return typ == "SELECT" || typ == "WITH" || typ == "VALUES"
+}
+
+// statementText is the statement without the leading whitespaces and comments, and the trailing semicolon
+func statementText(stmt *ast.Statement) string {
+	var b strings.Builder
+	started := false
+	for _, node := range stmt.GetTokens() {
+		if tok, ok := node.(ast.Token); ok {
+			sqlTok := tok.GetToken()
+			if !started && (sqlTok.MatchKind(token.Whitespace) || sqlTok.MatchKind(token.Comment)) {
+				continue
+			}
+			if sqlTok.MatchKind(token.Semicolon) {
+				continue
+			}
+		}
+		started = true
+		b.WriteString(node.String())
+	}
+	return strings.TrimSpace(b.String())
+}
+
 func extractRangeText(text string, startLine, startChar, endLine, endChar int) string {
 	writer := bytes.NewBufferString("")
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -196,11 +350,7 @@ func extractRangeText(text string, startLine, startChar, endLine, endChar int) s
 	return writer.String()
 }
 
-func (s *Server) query(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
-	if err != nil {
-		return "", err
-	}
+func (s *Server) query(ctx context.Context, repo database.DBRepository, query string, vertical bool) (string, error) {
 	rows, err := repo.Query(context.Background(), query)
 	if err != nil {
 		return err.Error(), nil
@@ -236,11 +386,7 @@ func (s *Server) query(ctx context.Context, query string, vertical bool) (string
 	return buf.String(), nil
 }
 
-func (s *Server) exec(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
-	if err != nil {
-		return "", err
-	}
+func (s *Server) exec(ctx context.Context, repo database.DBRepository, query string, vertical bool) (string, error) {
 	result, err := repo.Exec(context.Background(), query)
 	if err != nil {
 		return err.Error(), nil
@@ -266,7 +412,14 @@ func (s *Server) showDatabases(ctx context.Context, params lsp.ExecuteCommandPar
 	if err != nil {
 		return nil, err
 	}
-	return strings.Join(databases, "\n"), nil
+	if !hasArgument(params, flagJSON) {
+		return strings.Join(databases, "\n"), nil
+	}
+	current, err := repo.CurrentDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return databaseInfos(databases, current), nil
 }
 
 func (s *Server) showSchemas(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
@@ -278,10 +431,52 @@ func (s *Server) showSchemas(ctx context.Context, params lsp.ExecuteCommandParam
 	if err != nil {
 		return nil, err
 	}
-	return strings.Join(schemas, "\n"), nil
+	if !hasArgument(params, flagJSON) {
+		return strings.Join(schemas, "\n"), nil
+	}
+	current, err := repo.CurrentSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return databaseInfos(schemas, current), nil
 }
 
-func (s *Server) switchDatabase(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+func databaseInfos(names []string, current string) []*DatabaseInfo {
+	infos := make([]*DatabaseInfo, len(names))
+	for i, name := range names {
+		infos[i] = &DatabaseInfo{Name: name, Current: name == current}
+	}
+	return infos
+}
+
+func hasArgument(params lsp.ExecuteCommandParams, arg string) bool {
+	for _, a := range params.Arguments {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// switchDatabase changes the database of the connection, the user picks one of the databases when no name is given.
+func (s *Server) switchDatabase(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		repo, err := s.newDBRepository(ctx)
+		if err != nil {
+			return nil, err
+		}
+		databases, err := repo.Databases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(databases) == 0 {
+			return nil, fmt.Errorf("no database found")
+		}
+		s.pick(conn, "Switch Database", databases, func(ctx context.Context, index int) error {
+			return s.switchDatabaseTo(ctx, databases[index])
+		})
+		return nil, nil
+	}
 	if len(params.Arguments) != 1 {
 		return nil, fmt.Errorf("required arguments were not provided: <DB Name>")
 	}
@@ -289,19 +484,41 @@ func (s *Server) switchDatabase(ctx context.Context, params lsp.ExecuteCommandPa
 	if !ok {
 		return nil, fmt.Errorf("specify the db name as a string")
 	}
+	if err := s.switchDatabaseTo(ctx, dbName); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
 
+func (s *Server) switchDatabaseTo(ctx context.Context, dbName string) error {
 	// Change current database
 	s.curDBName = dbName
 
 	// close and reconnection to database
-	if err := s.reconnectionDB(ctx); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
+	return s.reconnectionDB(ctx)
 }
 
 func (s *Server) showConnections(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if !hasArgument(params, flagJSON) {
+		return strings.Join(s.connectionList(), "\n"), nil
+	}
+	infos := []*ConnectionInfo{}
+	for i, conn := range s.getConfig().Connections {
+		infos = append(infos, &ConnectionInfo{
+			Index:   i + 1,
+			Alias:   conn.Alias,
+			Driver:  string(conn.Driver),
+			Host:    conn.Host,
+			Port:    conn.Port,
+			DBName:  conn.DBName,
+			Current: s.dbConn != nil && i == s.curConnectionIndex,
+		})
+	}
+	return infos, nil
+}
+
+// connectionList describes the connections with the 1-based index used by switchConnections
+func (s *Server) connectionList() []string {
 	results := []string{}
 	conns := s.getConfig().Connections
 	for i, conn := range conns {
@@ -319,10 +536,21 @@ func (s *Server) showConnections(ctx context.Context, params lsp.ExecuteCommandP
 		res := fmt.Sprintf("%d %s %s %s", i+1, conn.Driver, conn.Alias, desc)
 		results = append(results, res)
 	}
-	return strings.Join(results, "\n"), nil
+	return results
 }
 
-func (s *Server) switchConnections(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+// switchConnections changes the connection, the user picks one of the connections when no index is given.
+func (s *Server) switchConnections(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		conns := s.connectionList()
+		if len(conns) == 0 {
+			return nil, ErrNoConnection
+		}
+		s.pick(conn, "Switch Connections", conns, func(ctx context.Context, index int) error {
+			return s.switchConnectionTo(ctx, index)
+		})
+		return nil, nil
+	}
 	if len(params.Arguments) != 1 {
 		return nil, fmt.Errorf("required arguments were not provided: <Connection Index>")
 	}
@@ -334,16 +562,107 @@ func (s *Server) switchConnections(ctx context.Context, params lsp.ExecuteComman
 	if err != nil {
 		return nil, fmt.Errorf("specify the connection index as a number, %s", err)
 	}
-	index = index - 1
+	if err := s.switchConnectionTo(ctx, index-1); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
 
-	// Reconnect database
+// switchConnectionTo reconnects to the connection of the 0-based index
+func (s *Server) switchConnectionTo(ctx context.Context, index int) error {
 	s.curConnectionIndex = index
 
 	// close and reconnection to database
-	if err := s.reconnectionDB(ctx); err != nil {
-		return nil, err
+	return s.reconnectionDB(ctx)
+}
+
+func (s *Server) describeTable(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if len(params.Arguments) != 1 {
+		return nil, fmt.Errorf("required arguments were not provided: <Table Name>")
+	}
+	name, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the table name as a string")
+	}
+	dbCache := s.worker.Cache()
+	if dbCache == nil {
+		return nil, errors.New("database cache is not ready")
+	}
+
+	schemaName, tableName := dbCache.TableSchema(name), name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		schemaName, tableName = name[:i], name[i+1:]
+	}
+	cols, ok := dbCache.ColumnDatabase(schemaName, tableName)
+	if !ok {
+		return nil, fmt.Errorf("table not found, %q", name)
+	}
+
+	if len(cols) > 0 {
+		// the names of the database, which may differ in case from the argument
+		schemaName, tableName = cols[0].Schema, cols[0].Table
+	}
+
+	var tableComment string
+	var indexes []*database.IndexDesc
+	var constraints []*database.ConstraintDesc
+	if table, ok := dbCache.TableDesc(schemaName, tableName); ok {
+		tableComment = table.Comment
+		indexes = dbCache.IndexesDatabase(schemaName, tableName)
+		constraints = dbCache.ConstraintsDatabase(schemaName, tableName)
+	} else {
+		// the tables outside of the search path are not cached
+		repo, err := s.newDBRepository(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tableNames := []string{tableName}
+		tables, err := repo.DescribeTablesByNames(ctx, schemaName, tableNames)
+		if err != nil {
+			return nil, err
+		}
+		if len(tables) > 0 {
+			tableComment = tables[0].Comment
+		}
+		if indexes, err = repo.DescribeIndexesByTables(ctx, schemaName, tableNames); err != nil {
+			log.Println("cannot describe indexes,", err)
+		}
+		if constraints, err = repo.DescribeConstraintsByTables(ctx, schemaName, tableNames); err != nil {
+			log.Println("cannot describe constraints,", err)
+		}
 	}
+	return database.TableDetailDoc(name, tableComment, cols, indexes, constraints), nil
+}
 
+func (s *Server) refreshSchemaCache(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if len(params.Arguments) == 0 {
+		if err := s.worker.RefreshAll(ctx); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	tables := []*parseutil.TableInfo{}
+	for _, arg := range params.Arguments {
+		name, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("specify the table name as a string")
+		}
+		table := &parseutil.TableInfo{Name: name}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			table.DatabaseSchema, table.Name = name[:i], name[i+1:]
+		}
+		tables = append(tables, table)
+	}
+	if err := s.worker.RefreshTables(ctx, tables); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
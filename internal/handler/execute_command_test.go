@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/lighttiger2505/sqls/internal/config"
 	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/lsp"
@@ -55,6 +58,58 @@ func Test_executeQuery(t *testing.T) {
 	// pass error
 }
 
+func TestDescribeTable(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	tests := []struct {
+		name    string
+		table   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "default schema",
+			table: "countrylanguage",
+			want:  "countrylanguage table\n\n- CountryCode: char(3) PRI\n- Language: char(30) PRI\n- IsOfficial: enum('T','F') F\n- Percentage: decimal(4,1)\n\nindexes\n\n- PRIMARY: (CountryCode, Language) PRIMARY BTREE\n- CountryCode: (CountryCode) BTREE\n\nconstraints\n\n- PRIMARY: PRIMARY KEY (CountryCode, Language)\n- countrylanguage_chk_1: CHECK (`Percentage` >= 0)\n",
+		},
+		{
+			name:  "schema qualified",
+			table: "world.city",
+			want:  "world.city table\n\n- ID: int(11) PRI auto_increment\n- Name: char(35)\n- CountryCode: char(3) MUL\n- District: char(20)\n- Population: int(11)\n",
+		},
+		{
+			name:    "not found",
+			table:   "notfound",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.ExecuteCommandParams{
+				Command:   CommandDescribeTable,
+				Arguments: []interface{}{tt.table},
+			}
+			var got string
+			err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("conn.Call workspace/executeCommand: %+v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_extractRangeText(t *testing.T) {
 	type args struct {
 		text      string
@@ -110,3 +165,232 @@ func Test_extractRangeText(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshSchemaCache(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	tests := []struct {
+		name      string
+		args      []interface{}
+		wantTable string
+		wantOk    bool
+	}{
+		{
+			name:      "all",
+			args:      []interface{}{},
+			wantTable: "city",
+			wantOk:    true,
+		},
+		{
+			name:      "table",
+			args:      []interface{}{"city"},
+			wantTable: "city",
+			wantOk:    true,
+		},
+		{
+			name:      "schema qualified table",
+			args:      []interface{}{"world.country"},
+			wantTable: "country",
+			wantOk:    true,
+		},
+		{
+			name:      "dropped table",
+			args:      []interface{}{"notfound"},
+			wantTable: "notfound",
+			wantOk:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := lsp.ExecuteCommandParams{
+				Command:   CommandRefreshSchema,
+				Arguments: tt.args,
+			}
+			if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil); err != nil {
+				t.Fatalf("conn.Call workspace/executeCommand: %+v", err)
+			}
+			_, ok := tx.server.worker.Cache().ColumnDescs(tt.wantTable)
+			if ok != tt.wantOk {
+				t.Errorf("ColumnDescs(%q) ok = %v, want %v", tt.wantTable, ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestProtectedConnection(t *testing.T) {
+	const query = "DELETE FROM city WHERE ID = 1"
+	executeQuery := func(t *testing.T, tx *TestContext, command string) (string, error) {
+		t.Helper()
+		params := lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{testFileURI},
+		}
+		var got string
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got)
+		return got, err
+	}
+
+	t.Run("read-only", func(t *testing.T) {
+		tx := newTestContext()
+		tx.setup(t)
+		defer tx.tearDown()
+		tx.addWorkspaceConfig(t, &config.Config{
+			Connections: []*database.DBConfig{{Alias: "replica", Driver: "mock", ReadOnly: true}},
+		})
+		tx.textDocumentDidOpen(t, testFileURI, query)
+
+		_, err := executeQuery(t, tx, CommandExecuteQuery)
+		if err == nil || !strings.Contains(err.Error(), "connection replica is read-only") {
+			t.Errorf("got %v, want read-only error", err)
+		}
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		tx := newTestContext()
+		tx.setup(t)
+		defer tx.tearDown()
+		tx.addWorkspaceConfig(t, &config.Config{
+			Connections: []*database.DBConfig{{Alias: "production", Driver: "mock", Confirm: true}},
+		})
+		tx.textDocumentDidOpen(t, testFileURI, query)
+
+		if _, err := executeQuery(t, tx, CommandConfirmExecuteQuery); err == nil {
+			t.Error("confirmed statements not waiting for confirmation")
+		}
+		got, err := executeQuery(t, tx, CommandExecuteQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, "need confirmation") || strings.Contains(got, "Query OK") {
+			t.Errorf("executed without confirmation, %q", got)
+		}
+		got, err = executeQuery(t, tx, CommandConfirmExecuteQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, "Query OK, 22 row affected") {
+			t.Errorf("not executed, %q", got)
+		}
+		// a confirmation is used once
+		if _, err := executeQuery(t, tx, CommandConfirmExecuteQuery); err == nil {
+			t.Error("confirmation is used twice")
+		}
+	})
+}
+
+func TestDangerousStatement(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{{Driver: "mock"}},
+	})
+	tx.textDocumentDidOpen(t, testFileURI, "DELETE FROM city")
+
+	params := lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{testFileURI},
+	}
+	var got string
+	err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got)
+	if err == nil || !strings.Contains(err.Error(), "DELETE without WHERE deletes every row") {
+		t.Fatalf("got %v, want refused", err)
+	}
+
+	params.Arguments = []interface{}{testFileURI, "-force"}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Query OK, 22 row affected") {
+		t.Errorf("not executed with -force, %q", got)
+	}
+}
+
+func TestShowCommandsJSON(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "dev", Driver: "mock"},
+			{Alias: "staging", Driver: "mock", Host: "db.example.com", Port: 3306, DBName: "world"},
+		},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	t.Run("showConnections", func(t *testing.T) {
+		var got []*ConnectionInfo
+		params := lsp.ExecuteCommandParams{Command: CommandShowConnections, Arguments: []interface{}{"-json"}}
+		if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got); err != nil {
+			t.Fatal("conn.Call workspace/executeCommand:", err)
+		}
+		want := []*ConnectionInfo{
+			{Index: 1, Alias: "dev", Driver: "mock", Current: true},
+			{Index: 2, Alias: "staging", Driver: "mock", Host: "db.example.com", Port: 3306, DBName: "world"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unmatch (- want, + got):\n%s", diff)
+		}
+	})
+
+	for _, command := range []string{CommandShowDatabases, CommandShowSchemas} {
+		t.Run(command, func(t *testing.T) {
+			var got []*DatabaseInfo
+			params := lsp.ExecuteCommandParams{Command: command, Arguments: []interface{}{"-json"}}
+			if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got); err != nil {
+				t.Fatal("conn.Call workspace/executeCommand:", err)
+			}
+			want := []*DatabaseInfo{
+				{Name: "information_schema"},
+				{Name: "mysql"},
+				{Name: "performance_schema"},
+				{Name: "sys"},
+				{Name: "world", Current: true},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var got string
+		params := lsp.ExecuteCommandParams{Command: CommandShowDatabases}
+		if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, &got); err != nil {
+			t.Fatal("conn.Call workspace/executeCommand:", err)
+		}
+		if want := "information_schema\nmysql\nperformance_schema\nsys\nworld"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestExplainQuery(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Driver: "mock"}},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "SELECT 1;\nDELETE FROM city WHERE ID = 1;")
+
+	rng := map[string]interface{}{
+		"start": map[string]interface{}{"line": 1, "character": 0},
+		"end":   map[string]interface{}{"line": 1, "character": 30},
+	}
+	params := lsp.ExecuteCommandParams{Command: CommandExplainQuery, Arguments: []interface{}{uri, rng}}
+	err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot be explained") {
+		t.Errorf("got %v, want the statement refused", err)
+	}
+}
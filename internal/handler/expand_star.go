@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/ast/astutil"
+	"github.com/lighttiger2505/sqls/dialect"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+	"github.com/lighttiger2505/sqls/parser"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+	"github.com/lighttiger2505/sqls/token"
+)
+
+// expandStarAction replaces "*" or "alias.*" of the select list at the position with the columns
+func expandStarAction(uri, text string, pos lsp.Position, dbCache *database.DBCache, driver dialect.DatabaseDriver) (*lsp.CodeAction, bool) {
+	if dbCache == nil {
+		return nil, false
+	}
+	parsed, err := parser.Parse(text)
+	if err != nil {
+		return nil, false
+	}
+	star, parent, ok := findStar(parsed, pos)
+	if !ok {
+		return nil, false
+	}
+
+	starPos := token.Pos{Line: star.Pos().Line, Col: star.Pos().Col + 1}
+	nw := parseutil.NewNodeWalker(parsed, starPos)
+	if parseutil.CheckSyntaxPosition(nw) != parseutil.SelectExpr {
+		return nil, false
+	}
+	// such as count(*)
+	if nw.CurNodeIs(astutil.NodeMatcher{NodeTypes: []ast.NodeType{ast.TypeFunctionLiteral}}) {
+		return nil, false
+	}
+	env, err := collectEnvirontment(parsed, starPos)
+	if err != nil {
+		return nil, false
+	}
+	columns, ok := expandStar(parent, env, dbCache, driver)
+	if !ok || len(columns) == 0 {
+		return nil, false
+	}
+
+	rng := lsp.Range{
+		Start: lsp.Position{Line: star.Pos().Line, Character: star.Pos().Col},
+		End:   lsp.Position{Line: star.End().Line, Character: star.End().Col},
+	}
+	return &lsp.CodeAction{
+		Title: fmt.Sprintf("Expand %s", star.String()),
+		Kind:  lsp.RefactorRewrite,
+		Edit: &lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				uri: {{Range: rng, NewText: strings.Join(columns, ", ")}},
+			},
+		},
+	}, true
+}
+
+// findStar returns "*" or "alias.*" at the position, and the alias which is empty for "*"
+func findStar(parsed ast.TokenList, pos lsp.Position) (ast.Node, string, bool) {
+	var (
+		star   ast.Node
+		parent string
+	)
+	walkNodes(parsed, func(node ast.Node) bool {
+		if star != nil || !nodeContains(node, pos) {
+			return false
+		}
+		switch v := node.(type) {
+		case *ast.MemberIdentifer:
+			if v.Schema == nil && v.ParentTok != nil && v.ChildTok != nil && v.ChildTok.NoQuateString() == "*" {
+				star, parent = v, v.ParentTok.NoQuateString()
+			}
+			return false
+		case *ast.Identifer:
+			if v.String() == "*" {
+				star = v
+			}
+			return false
+		}
+		return true
+	})
+	return star, parent, star != nil
+}
+
+func nodeContains(node ast.Node, pos lsp.Position) bool {
+	p := token.Pos{Line: pos.Line, Col: pos.Character}
+	return token.ComparePos(node.Pos(), p) <= 0 && token.ComparePos(p, node.End()) <= 0
+}
+
+// expandStar lists the columns of the table or subquery of the alias, or of all of them qualified with their names when the alias is empty.
+// The names are quoted for the driver when needed. It fails when a table is not in the cache, not to drop its columns.
+func expandStar(parent string, env *hoverEnvironment, dbCache *database.DBCache, driver dialect.DatabaseDriver) ([]string, bool) {
+	if parent != "" {
+		var (
+			columns []string
+			ok      bool
+		)
+		if subQuery, found := env.getSubQueryView(parent); found {
+			columns, ok = subQueryColumns(subQuery, dbCache, driver)
+		}
+		for _, table := range env.tables {
+			if !ok && (table.Alias == parent || (table.Alias == "" && table.Name == parent)) {
+				columns, ok = tableColumns(table, dbCache, driver)
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+		return qualify(parent, columns, driver), true
+	}
+
+	type source struct {
+		name    string
+		columns []string
+		ok      bool
+	}
+	sources := []source{}
+	for _, table := range env.tables {
+		name := table.Alias
+		if name == "" {
+			name = table.Name
+		}
+		cols, ok := tableColumns(table, dbCache, driver)
+		sources = append(sources, source{name, cols, ok})
+	}
+	for _, subQuery := range env.subQueries {
+		cols, ok := subQueryColumns(subQuery, dbCache, driver)
+		sources = append(sources, source{subQuery.Name, cols, ok})
+	}
+	if len(sources) == 1 {
+		return sources[0].columns, sources[0].ok
+	}
+	columns := []string{}
+	for _, src := range sources {
+		if !src.ok {
+			return nil, false
+		}
+		columns = append(columns, qualify(src.name, src.columns, driver)...)
+	}
+	return columns, true
+}
+
+func tableColumns(table *parseutil.TableInfo, dbCache *database.DBCache, driver dialect.DatabaseDriver) ([]string, bool) {
+	descs, ok := dbCache.CachedColumnDescsBySchema(table.DatabaseSchema, table.Name)
+	if !ok {
+		return nil, false
+	}
+	columns := make([]string, len(descs))
+	for i, desc := range descs {
+		columns[i] = dialect.QuoteIdentifier(driver, desc.Name)
+	}
+	return columns, true
+}
+
+// subQueryColumns lists the columns of the subquery, "*" in the subquery is expanded with the columns of its table
+func subQueryColumns(subQuery *parseutil.SubQueryInfo, dbCache *database.DBCache, driver dialect.DatabaseDriver) ([]string, bool) {
+	if len(subQuery.Views) == 0 {
+		return nil, false
+	}
+	columns := []string{}
+	for _, col := range subQuery.Views[0].SubQueryColumns {
+		if col.ColumnName != "*" {
+			columns = append(columns, dialect.QuoteIdentifier(driver, col.DisplayName()))
+			continue
+		}
+		if col.ParentTable == nil {
+			return nil, false
+		}
+		cols, ok := tableColumns(col.ParentTable, dbCache, driver)
+		if !ok {
+			return nil, false
+		}
+		columns = append(columns, cols...)
+	}
+	return columns, true
+}
+
+func qualify(name string, columns []string, driver dialect.DatabaseDriver) []string {
+	name = dialect.QuoteIdentifier(driver, name)
+	qualified := make([]string, len(columns))
+	for i, col := range columns {
+		qualified[i] = name + "." + col
+	}
+	return qualified
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/lighttiger2505/sqls/dialect"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestExpandStarAction(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Driver: "mock"}},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	cases := []struct {
+		name   string
+		input  string
+		char   int
+		driver dialect.DatabaseDriver
+		// want is the text replacing the star, empty if no action
+		want      string
+		wantStart int
+		wantEnd   int
+	}{
+		{
+			name:      "star",
+			input:     "SELECT * FROM city",
+			char:      7,
+			want:      "ID, Name, CountryCode, District, Population",
+			wantStart: 7,
+			wantEnd:   8,
+		},
+		{
+			name:      "after star",
+			input:     "SELECT * FROM world.city",
+			char:      8,
+			want:      "ID, Name, CountryCode, District, Population",
+			wantStart: 7,
+			wantEnd:   8,
+		},
+		{
+			name:      "alias",
+			input:     "SELECT ci.*, co.Name FROM city AS ci JOIN country co ON ci.CountryCode = co.Code",
+			char:      10,
+			want:      "ci.ID, ci.Name, ci.CountryCode, ci.District, ci.Population",
+			wantStart: 7,
+			wantEnd:   11,
+		},
+		{
+			name:      "star of join",
+			input:     "SELECT * FROM city ci JOIN countrylanguage ON ci.CountryCode = countrylanguage.CountryCode",
+			char:      7,
+			want:      "ci.ID, ci.Name, ci.CountryCode, ci.District, ci.Population, countrylanguage.CountryCode, countrylanguage.Language, countrylanguage.IsOfficial, countrylanguage.Percentage",
+			wantStart: 7,
+			wantEnd:   8,
+		},
+		{
+			name:      "subquery",
+			input:     "SELECT sub.* FROM (SELECT ID, Name AS n FROM city) AS sub",
+			char:      8,
+			want:      "sub.ID, sub.n",
+			wantStart: 7,
+			wantEnd:   12,
+		},
+		{
+			name:      "star of subquery",
+			input:     "SELECT * FROM (SELECT * FROM countrylanguage) AS sub",
+			char:      7,
+			want:      "CountryCode, Language, IsOfficial, Percentage",
+			wantStart: 7,
+			wantEnd:   8,
+		},
+		{
+			name:      "postgresql mixed case",
+			input:     "SELECT ci.* FROM city ci",
+			char:      10,
+			driver:    dialect.DatabaseDriverPostgreSQL,
+			want:      `ci."ID", ci."Name", ci."CountryCode", ci."District", ci."Population"`,
+			wantStart: 7,
+			wantEnd:   11,
+		},
+		{
+			name:      "mysql keyword",
+			input:     "SELECT * FROM (SELECT Name AS `order` FROM city) AS sub",
+			char:      7,
+			driver:    dialect.DatabaseDriverMySQL,
+			want:      "`order`",
+			wantStart: 7,
+			wantEnd:   8,
+		},
+		{
+			name:  "count",
+			input: "SELECT count(*) FROM city",
+			char:  13,
+		},
+		{
+			name:  "unknown table",
+			input: "SELECT * FROM nothing",
+			char:  7,
+		},
+		{
+			name:  "not on star",
+			input: "SELECT * FROM city",
+			char:  15,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			uri := "file:///test.sql"
+			got, ok := expandStarAction(uri, tt.input, lsp.Position{Line: 0, Character: tt.char}, tx.server.worker.Cache(), tt.driver)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("unexpected action %+v", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("no action")
+			}
+			want := &lsp.WorkspaceEdit{
+				Changes: map[string][]lsp.TextEdit{
+					uri: {{
+						Range: lsp.Range{
+							Start: lsp.Position{Line: 0, Character: tt.wantStart},
+							End:   lsp.Position{Line: 0, Character: tt.wantEnd},
+						},
+						NewText: tt.want,
+					}},
+				},
+			}
+			if diff := cmp.Diff(want, got.Edit); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+			if got.Kind != lsp.RefactorRewrite {
+				t.Errorf("got kind %q, want %q", got.Kind, lsp.RefactorRewrite)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"log"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
 	"golang.org/x/xerrors"
@@ -21,23 +23,61 @@ var (
 )
 
 type Server struct {
+	// mu serializes the requests and the health check replacing the connection
+	mu sync.Mutex
+
 	SpecificFileCfg *config.Config
 	DefaultFileCfg  *config.Config
+	ProjectFileCfg  *config.Config
 	WSCfg           *config.Config
 
+	// SpecificFilePath and DefaultFilePath are reloaded when the client notifies their changes
+	SpecificFilePath string
+	DefaultFilePath  string
+	// watchFiles is true when the client can register workspace/didChangeWatchedFiles
+	watchFiles bool
+	// codeActionLiteral is true when the client accepts CodeAction, otherwise only Command is returned
+	codeActionLiteral bool
+
+	// rootPath is the workspace root, project configs are looked up below it
+	rootPath       string
+	projectCfgPath string
+	// projectCfgs are the project configs of the documents under another project than the workspace, nil if unreadable
+	projectCfgs map[string]*config.Config
+
 	dbConn *database.DBConnection
 
 	curDBCfg           *database.DBConfig
 	curDBName          string
 	curConnectionIndex int
+	// serverVersion is reported by the database on connect, empty if unknown
+	serverVersion string
 
 	worker *database.Worker
 	files  map[string]*File
+
+	// sessions are the connections documents are bound to by the binding comment, keyed by connectionBinding.key
+	sessions map[string]*dbSession
+	// openings are the bound connections being opened in the background for the requests, or failed to open
+	openings   map[string]*sessionOpening
+	cacheStore *database.CacheStore
+
+	// pendingExecution is waiting for confirmExecuteQuery on a connection of the confirm mode
+	pendingExecution *pendingExecution
+
+	// healthCheckDone stops the health check of the connection started on initialize
+	healthCheckDone     chan struct{}
+	healthCheckInterval time.Duration
+	reconnectBackoff    time.Duration
 }
 
 type File struct {
 	LanguageID string
 	Text       string
+	// binding is the key of the session the document uses, empty until the bound connection is opened
+	binding string
+	// projectCfgPath is the nearest project config of the document, empty if there is none
+	projectCfgPath string
 }
 
 func NewServer() *Server {
@@ -45,11 +85,23 @@ func NewServer() *Server {
 	worker.Start()
 
 	return &Server{
-		files:  make(map[string]*File),
-		worker: worker,
+		files:       make(map[string]*File),
+		worker:      worker,
+		sessions:    make(map[string]*dbSession),
+		openings:    make(map[string]*sessionOpening),
+		projectCfgs: make(map[string]*config.Config),
+
+		healthCheckInterval: defaultHealthCheckInterval,
+		reconnectBackoff:    defaultReconnectBackoff,
 	}
 }
 
+// SetSchemaCacheDir persists the schema cache to dir and loads it on the next connection.
+func (s *Server) SetSchemaCacheDir(dir string) {
+	s.cacheStore = database.NewCacheStore(dir)
+	s.worker.SetCacheStore(s.cacheStore)
+}
+
 func panicf(r interface{}, format string, v ...interface{}) error {
 	if r != nil {
 		// Same as net/http
@@ -64,6 +116,8 @@ func panicf(r interface{}, format string, v ...interface{}) error {
 }
 
 func (s *Server) Stop() error {
+	s.stopHealthCheck()
+	s.closeSessions()
 	if err := s.dbConn.Close(); err != nil {
 		return err
 	}
@@ -78,6 +132,8 @@ func (s *Server) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.
 			err = perr
 		}
 	}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	res, err := s.handle(ctx, conn, req)
 	if err != nil {
 		log.Printf("error serving, %+v\n", err)
@@ -89,7 +145,7 @@ func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.
 	case "initialize":
 		return s.handleInitialize(ctx, conn, req)
 	case "initialized":
-		return
+		return s.handleInitialized(ctx, conn, req)
 	case "shutdown":
 		return s.handleShutdown(ctx, conn, req)
 	case "exit":
@@ -110,6 +166,8 @@ func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.
 		return s.handleTextDocumentCodeAction(ctx, conn, req)
 	case "workspace/executeCommand":
 		return s.handleWorkspaceExecuteCommand(ctx, conn, req)
+	case "workspace/didChangeWatchedFiles":
+		return s.handleWorkspaceDidChangeWatchedFiles(ctx, conn, req)
 	case "workspace/didChangeConfiguration":
 		return s.handleWorkspaceDidChangeConfiguration(ctx, conn, req)
 	case "textDocument/formatting":
@@ -150,12 +208,24 @@ func (s *Server) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 			DefinitionProvider:              false,
 			DocumentFormattingProvider:      true,
 			DocumentRangeFormattingProvider: true,
+			ExecuteCommandProvider: &lsp.ExecuteCommandOptions{
+				Commands: commands,
+			},
 		},
 	}
 
+	messenger := lsp.NewLspMessenger(conn)
+	s.watchFiles = params.Capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	s.codeActionLiteral = params.Capabilities.TextDocument.CodeAction.CodeActionLiteralSupport != nil
+	s.rootPath = rootPath(params)
+	if _, err := s.loadProjectConfig(); err != nil {
+		if err := messenger.ShowError(ctx, err.Error()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize database database connection
 	// NOTE: If no connection is found at this point, it is possible that the connection settings are sent to workspace config, so don't make an error
-	messenger := lsp.NewLspMessenger(conn)
 	if err := s.reconnectionDB(ctx); err != nil {
 		if err != ErrNoConnection {
 			if err := messenger.ShowInfo(ctx, err.Error()); err != nil {
@@ -169,10 +239,20 @@ func (s *Server) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 			}
 		}
 	}
+	s.startHealthCheck(conn)
 	return result, nil
 }
 
+func (s *Server) handleInitialized(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if s.watchFiles {
+		s.registerConfigWatchers(ctx, conn)
+	}
+	return nil, nil
+}
+
 func (s *Server) handleShutdown(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	s.stopHealthCheck()
+	s.closeSessions()
 	if s.dbConn != nil {
 		s.dbConn.Close()
 	}
@@ -203,6 +283,11 @@ func (s *Server) handleTextDocumentDidOpen(ctx context.Context, conn *jsonrpc2.C
 	if err := s.updateFile(params.TextDocument.URI, params.TextDocument.Text); err != nil {
 		return nil, err
 	}
+
+	s.setDocumentProject(params.TextDocument.URI)
+	if err := s.publishDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -219,6 +304,10 @@ func (s *Server) handleTextDocumentDidChange(ctx context.Context, conn *jsonrpc2
 	if err := s.updateFile(params.TextDocument.URI, params.ContentChanges[0].Text); err != nil {
 		return nil, err
 	}
+	s.unbindDocument(params.TextDocument.URI)
+	if err := s.publishDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -256,6 +345,13 @@ func (s *Server) handleTextDocumentDidClose(ctx context.Context, conn *jsonrpc2.
 	if err := s.closeFile(params.TextDocument.URI); err != nil {
 		return nil, err
 	}
+	// clear the diagnostics of the closed document
+	if err := conn.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []lsp.Diagnostic{},
+	}); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -270,6 +366,7 @@ func (s *Server) openFile(uri string, languageID string) error {
 
 func (s *Server) closeFile(uri string) error {
 	delete(s.files, uri)
+	s.closeUnusedSessions()
 	return nil
 }
 
@@ -293,6 +390,10 @@ func (s *Server) handleWorkspaceDidChangeConfiguration(ctx context.Context, conn
 		return nil, err
 	}
 	s.WSCfg = params.Settings.SQLS
+	// the documents of other projects may use the connection of the editor settings now
+	for uri := range s.files {
+		s.unbindDocument(uri)
+	}
 
 	// Skip database connection
 	if s.dbConn != nil {
@@ -322,20 +423,27 @@ func (s *Server) reconnectionDB(ctx context.Context) error {
 	if err := s.dbConn.Close(); err != nil {
 		return err
 	}
+	s.serverVersion = ""
 
 	dbConn, err := s.newDBConnection(ctx)
 	if err != nil {
 		return err
 	}
 	s.dbConn = dbConn
+	return s.recache(ctx)
+}
+
+// recache reads the server version and the schema of the current connection
+func (s *Server) recache(ctx context.Context) error {
 	dbRepo, err := s.newDBRepository(ctx)
 	if err != nil {
 		return err
 	}
-	if err := s.worker.ReCache(ctx, dbRepo); err != nil {
-		return err
+	if s.serverVersion, err = dbRepo.ServerVersion(ctx); err != nil {
+		// the newest keywords are used instead
+		log.Println("cannot get server version,", err)
 	}
-	return nil
+	return s.worker.ReCache(ctx, dbRepo, s.curDBCfg)
 }
 
 func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, error) {
@@ -381,31 +489,16 @@ func (s *Server) topConnection() *database.DBConfig {
 
 func (s *Server) getConnection(index int) *database.DBConfig {
 	cfg := s.getConfig()
-	if cfg == nil || (index < 0 && len(cfg.Connections) <= index) {
+	if cfg == nil || index < 0 || len(cfg.Connections) <= index {
 		return nil
 	}
 	return cfg.Connections[index]
 }
 
+// getConfig returns the config file given by the -config flag, or else merges the user, project and editor settings in ascending order of precedence.
 func (s *Server) getConfig() *config.Config {
-	var cfg *config.Config
-	switch {
-	case validConfig(s.SpecificFileCfg):
-		cfg = s.SpecificFileCfg
-	case validConfig(s.WSCfg):
-		cfg = s.WSCfg
-	case validConfig(s.DefaultFileCfg):
-		cfg = s.DefaultFileCfg
-	default:
-		cfg = config.NewConfig()
-	}
-	return cfg
-}
-
-func validConfig(cfg *config.Config) bool {
-	// if cfg != nil && len(cfg.Connections) > 0 {
-	if cfg != nil {
-		return true
+	if s.SpecificFileCfg != nil {
+		return s.SpecificFileCfg
 	}
-	return false
+	return config.Merge(s.DefaultFileCfg, s.trustProjectConfig(s.projectCfgPath, s.ProjectFileCfg), s.WSCfg)
 }
@@ -2,9 +2,11 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -17,6 +19,7 @@ const testFileURI = "file:///Users/octref/Code/css-test/test.sql"
 
 type TestContext struct {
 	h          jsonrpc2.Handler
+	client     *testClient
 	conn       *jsonrpc2.Conn
 	connServer *jsonrpc2.Conn
 	server     *Server
@@ -29,11 +32,50 @@ func newTestContext() *TestContext {
 	ctx := context.Background()
 	return &TestContext{
 		h:      handler,
+		client: &testClient{},
 		ctx:    ctx,
 		server: server,
 	}
 }
 
+// testClient records the notifications sent by the server.
+// The client must not share the handler of the server, which holds the lock while the server writes.
+type testClient struct {
+	mu            sync.Mutex
+	notifications []*jsonrpc2.Request
+	// pick answers window/showMessageRequest, nil dismisses it
+	pick func(lsp.ShowMessageRequestParams) *lsp.MessageActionItem
+}
+
+func (c *testClient) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	if req.Notif {
+		c.mu.Lock()
+		c.notifications = append(c.notifications, req)
+		c.mu.Unlock()
+		return nil, nil
+	}
+	if req.Method == "window/showMessageRequest" && c.pick != nil {
+		var params lsp.ShowMessageRequestParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		return c.pick(params), nil
+	}
+	return nil, nil
+}
+
+func (c *testClient) notified(method string) []*jsonrpc2.Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reqs := []*jsonrpc2.Request{}
+	for _, req := range c.notifications {
+		if req.Method == method {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
 func (tx *TestContext) setup(t *testing.T) {
 	t.Helper()
 	tx.initServer(t)
@@ -59,7 +101,7 @@ func (tx *TestContext) initServer(t *testing.T) {
 	// Prepare the server and client connection.
 	client, server := net.Pipe()
 	tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
-	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(tx.client.handle))
 
 	// Initialize Langage Server
 	params := lsp.InitializeParams{
@@ -86,7 +128,7 @@ func (tx *TestContext) addWorkspaceConfig(t *testing.T, cfg *config.Config) {
 func (tx *TestContext) textDocumentDidOpen(t *testing.T, uri, input string) {
 	didOpenParams := lsp.DidOpenTextDocumentParams{
 		TextDocument: lsp.TextDocumentItem{
-			URI:        testFileURI,
+			URI:        uri,
 			LanguageID: "sql",
 			Version:    0,
 			Text:       input,
@@ -121,6 +163,20 @@ func TestInitialized(t *testing.T) {
 			DefinitionProvider:              false,
 			DocumentFormattingProvider:      true,
 			DocumentRangeFormattingProvider: true,
+			ExecuteCommandProvider: &lsp.ExecuteCommandOptions{
+				Commands: []string{
+					"executeQuery",
+					"confirmExecuteQuery",
+					"showDatabases",
+					"showSchemas",
+					"showConnections",
+					"switchDatabase",
+					"switchConnections",
+					"describeTable",
+					"refreshSchemaCache",
+					"explainQuery",
+				},
+			},
 		},
 	}
 	var got lsp.InitializeResult
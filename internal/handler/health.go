@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultReconnectBackoff    = time.Second
+	maxReconnectBackoff        = time.Minute
+	healthCheckTimeout         = 10 * time.Second
+)
+
+// ConnectionStatus is the state of the active connection sent by the sqls/connectionStatus notification
+type ConnectionStatus string
+
+const (
+	ConnectionStatusConnected    ConnectionStatus = "connected"
+	ConnectionStatusDisconnected ConnectionStatus = "disconnected"
+)
+
+type ConnectionStatusParams struct {
+	Connection string           `json:"connection"`
+	Status     ConnectionStatus `json:"status"`
+	Message    string           `json:"message,omitempty"`
+}
+
+func (s *Server) startHealthCheck(conn *jsonrpc2.Conn) {
+	if s.healthCheckDone != nil {
+		return
+	}
+	s.healthCheckDone = make(chan struct{})
+	go s.healthCheckLoop(conn, s.healthCheckDone)
+}
+
+func (s *Server) stopHealthCheck() {
+	if s.healthCheckDone != nil {
+		close(s.healthCheckDone)
+		s.healthCheckDone = nil
+	}
+}
+
+func (s *Server) healthCheckLoop(conn *jsonrpc2.Conn, done chan struct{}) {
+	ticker := time.NewTicker(s.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.checkHealth(conn, done)
+		}
+	}
+}
+
+// checkHealth pings the active connection and the connections documents are bound to, and reconnects the lost ones.
+func (s *Server) checkHealth(conn *jsonrpc2.Conn, done chan struct{}) {
+	s.mu.Lock()
+	var sessions []*dbSession
+	if s.dbConn != nil && s.curDBCfg != nil {
+		sess := s.defaultSession()
+		cfg := *s.curDBCfg
+		sess.cfg = &cfg
+		sessions = append(sessions, sess)
+	}
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sess := range sessions {
+		wg.Add(1)
+		go func(sess *dbSession) {
+			defer wg.Done()
+			s.checkSessionHealth(conn, done, sess)
+		}(sess)
+	}
+	wg.Wait()
+}
+
+// checkSessionHealth pings the connection, and reconnects with backoff until it succeeds.
+// It gives up once the session is not in use anymore, such as when the connection is switched, the document is closed or the config is reloaded.
+// Requests are not blocked while connecting and loading the schema, the lock is taken only to check and replace the session.
+func (s *Server) checkSessionHealth(conn *jsonrpc2.Conn, done chan struct{}, sess *dbSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	err := sess.conn.Ping(ctx)
+	cancel()
+	if err == nil {
+		return
+	}
+	name := sess.name()
+	log.Printf("connection %s is lost, %+v", name, err)
+	s.notifyConnectionStatus(conn, name, ConnectionStatusDisconnected, fmt.Sprintf("connection %s is lost, reconnecting, %s", name, err))
+
+	backoff := s.reconnectBackoff
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+		s.mu.Lock()
+		inUse := s.sessionInUse(sess)
+		s.mu.Unlock()
+		if !inUse {
+			log.Printf("stop reconnecting to %s, the connection is not in use", name)
+			return
+		}
+
+		newConn, repo, version, err := reconnect(sess.cfg)
+		if err != nil {
+			log.Printf("cannot reconnect to %s, %+v", name, err)
+			if backoff *= 2; backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		gen, ok := s.replaceSessionConn(sess, newConn, repo, version)
+		s.mu.Unlock()
+		if !ok {
+			// switched to another connection in the meantime
+			newConn.Close()
+			return
+		}
+		sess.conn.Close()
+		if err := sess.worker.Load(context.Background(), gen); err != nil {
+			log.Println("cannot reload schema cache,", err)
+		}
+		s.notifyConnectionStatus(conn, name, ConnectionStatusConnected, fmt.Sprintf("reconnected to %s", name))
+		return
+	}
+}
+
+func reconnect(cfg *database.DBConfig) (*database.DBConnection, database.DBRepository, string, error) {
+	conn, err := database.Open(cfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	repo, err := database.CreateRepository(cfg.Driver, conn.Conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	version, err := repo.ServerVersion(ctx)
+	if err != nil {
+		log.Println("cannot get server version,", err)
+	}
+	return conn, repo, version, nil
+}
+
+// sessionInUse reports whether the session is still the default one or the one its documents are bound to
+func (s *Server) sessionInUse(sess *dbSession) bool {
+	if sess.binding == nil {
+		return s.dbConn == sess.conn
+	}
+	return s.sessions[sess.binding.key()] == sess
+}
+
+// replaceSessionConn replaces the lost connection of the session, unless it is not in use anymore.
+// It returns the generation to load the cache of the worker with.
+func (s *Server) replaceSessionConn(sess *dbSession, conn *database.DBConnection, repo database.DBRepository, version string) (int, bool) {
+	if !s.sessionInUse(sess) {
+		return 0, false
+	}
+	if sess.binding == nil {
+		s.dbConn = conn
+		s.serverVersion = version
+		return s.worker.Switch(repo, s.curDBCfg), true
+	}
+	key := sess.binding.key()
+	// the session is replaced, not modified, the handlers may hold the current one
+	newSess := *sess
+	newSess.conn = conn
+	newSess.serverVersion = version
+	s.sessions[key] = &newSess
+	return sess.worker.Switch(repo, sess.cfg), true
+}
+
+func (s *Server) notifyConnectionStatus(conn *jsonrpc2.Conn, name string, status ConnectionStatus, message string) {
+	ctx := context.Background()
+	params := ConnectionStatusParams{
+		Connection: name,
+		Status:     status,
+		Message:    message,
+	}
+	if err := conn.Notify(ctx, "sqls/connectionStatus", params); err != nil {
+		log.Println("cannot notify connection status,", err)
+	}
+
+	var err error
+	messenger := lsp.NewLspMessenger(conn)
+	if status == ConnectionStatusDisconnected {
+		err = messenger.ShowWarning(ctx, message)
+	} else {
+		err = messenger.ShowInfo(ctx, message)
+	}
+	if err != nil {
+		log.Println("cannot show connection status,", err)
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+)
+
+func TestHealthCheckReconnect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tx := newTestContext()
+	tx.server.healthCheckInterval = 10 * time.Millisecond
+	tx.server.reconnectBackoff = 10 * time.Millisecond
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "local", Driver: "sqlite3", DataSourceName: filepath.Join(dir, "health.db")},
+		},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+	defer tx.server.Stop()
+
+	tx.server.mu.Lock()
+	broken := tx.server.dbConn
+	tx.server.mu.Unlock()
+	if broken == nil {
+		t.Fatal("not connected")
+	}
+	// the connection is lost like the database restarted
+	broken.Conn.Close()
+
+	var dbConn *database.DBConnection
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		tx.server.mu.Lock()
+		dbConn = tx.server.dbConn
+		tx.server.mu.Unlock()
+		if dbConn != broken && len(tx.client.notified("sqls/connectionStatus")) >= 2 {
+			break
+		}
+	}
+	if dbConn == broken {
+		t.Fatal("not reconnected")
+	}
+	if err := dbConn.Ping(context.Background()); err != nil {
+		t.Errorf("ping the new connection, %+v", err)
+	}
+
+	want := []ConnectionStatus{ConnectionStatusDisconnected, ConnectionStatusConnected}
+	notifications := tx.client.notified("sqls/connectionStatus")
+	if len(notifications) != len(want) {
+		t.Fatalf("got %d notifications, want %d", len(notifications), len(want))
+	}
+	for i, req := range notifications {
+		var params ConnectionStatusParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.Connection != "local" || params.Status != want[i] {
+			t.Errorf("notification %d, got %+v, want status %s of local", i, params, want[i])
+		}
+	}
+	if got := len(tx.client.notified("window/showMessage")); got < 2 {
+		t.Errorf("got %d messages, want status changes shown", got)
+	}
+}
+
+func TestHealthCheckReconnectBoundSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tx := newTestContext()
+	tx.server.healthCheckInterval = 10 * time.Millisecond
+	tx.server.reconnectBackoff = 10 * time.Millisecond
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "local", Driver: "sqlite3", DataSourceName: filepath.Join(dir, "health.db")},
+			{Alias: "reporting", Driver: "sqlite3", DataSourceName: filepath.Join(dir, "reporting.db")},
+		},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+	defer tx.server.Stop()
+
+	const reportURI = "file:///report.sql"
+	tx.textDocumentDidOpen(t, reportURI, "-- sqls: connection=reporting\nSELECT 1")
+	tx.server.mu.Lock()
+	broken, err := tx.server.documentSession(tx.ctx, reportURI)
+	tx.server.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken.conn.Conn.Close()
+
+	var sess *dbSession
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		tx.server.mu.Lock()
+		sess = tx.server.sessions["reporting"]
+		tx.server.mu.Unlock()
+		if sess != broken && len(tx.client.notified("sqls/connectionStatus")) >= 2 {
+			break
+		}
+	}
+	if sess == nil || sess == broken {
+		t.Fatal("not reconnected")
+	}
+	if err := sess.conn.Ping(context.Background()); err != nil {
+		t.Errorf("ping the new connection, %+v", err)
+	}
+	if sess.worker != broken.worker {
+		t.Error("the schema cache of the session is not kept")
+	}
+	tx.server.mu.Lock()
+	dbConn := tx.server.dbConn
+	tx.server.mu.Unlock()
+	if err := dbConn.Ping(context.Background()); err != nil {
+		t.Errorf("the default connection is affected, %+v", err)
+	}
+}
+
+func TestHealthCheckGiveUpUnusedSession(t *testing.T) {
+	tx := newTestContext()
+	tx.server.reconnectBackoff = 10 * time.Millisecond
+	tx.setup(t)
+	defer tx.tearDown()
+	defer tx.server.Stop()
+
+	lost, err := database.Open(&database.DBConfig{Driver: "sqlite3", DataSourceName: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost.Close()
+	binding := &connectionBinding{Connection: "gone"}
+	sess := &dbSession{
+		binding: binding,
+		// the target cannot be dialed anymore
+		cfg:  &database.DBConfig{Alias: "gone", Driver: "unknown"},
+		conn: lost,
+	}
+	tx.server.mu.Lock()
+	tx.server.sessions[binding.key()] = sess
+	tx.server.mu.Unlock()
+
+	returned := make(chan struct{})
+	go func() {
+		tx.server.checkSessionHealth(tx.connServer, make(chan struct{}), sess)
+		close(returned)
+	}()
+	select {
+	case <-returned:
+		t.Fatal("gave up while the session is in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the document is closed
+	tx.server.mu.Lock()
+	delete(tx.server.sessions, binding.key())
+	tx.server.mu.Unlock()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("still reconnecting to the closed session")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/lighttiger2505/sqls/ast"
 	"github.com/lighttiger2505/sqls/ast/astutil"
+	"github.com/lighttiger2505/sqls/dialect"
 	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/lsp"
 	"github.com/lighttiger2505/sqls/parser"
@@ -33,7 +35,8 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := hover(f.Text, params, s.worker.Cache())
+	sess := s.cachedSession(params.TextDocument.URI)
+	res, err := hover(f.Text, params, sess.cache(), sess.driver())
 	if err != nil {
 		if err == ErrNoHover {
 			return nil, nil
@@ -43,11 +46,7 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 	return res, nil
 }
 
-func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp.Hover, error) {
-	if dbCache == nil {
-		return nil, nil
-	}
-
+func hover(text string, params lsp.HoverParams, dbCache *database.DBCache, driver dialect.DatabaseDriver) (*lsp.Hover, error) {
 	pos := token.Pos{
 		Line: params.Position.Line,
 		Col:  params.Position.Character + 1,
@@ -59,6 +58,17 @@ func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp
 
 	// Find identifiers from focused statement
 	nodeWalker := parseutil.NewNodeWalker(parsed, pos)
+
+	// The cursor is on the function name
+	// example "co[u]nt(ID)"
+	if res := functionHover(nodeWalker, pos, dbCache, driver); res != nil {
+		return res, nil
+	}
+
+	if dbCache == nil {
+		return nil, nil
+	}
+
 	hoverTargetMatcher := astutil.NodeMatcher{
 		NodeTypes: []ast.NodeType{
 			ast.TypeMemberIdentifer,
@@ -132,19 +142,62 @@ func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp
 	return res, nil
 }
 
+func functionHover(nw *parseutil.NodeWalker, pos token.Pos, dbCache *database.DBCache, driver dialect.DatabaseDriver) *lsp.Hover {
+	functionLiteralMatcher := astutil.NodeMatcher{
+		NodeTypes: []ast.NodeType{ast.TypeFunctionLiteral},
+	}
+	nodes := nw.CurNodeMatches(functionLiteralMatcher)
+	if len(nodes) == 0 {
+		return nil
+	}
+	// use the innermost function
+	fl := nodes[len(nodes)-1].(*ast.FunctionLiteral)
+	nameNode := fl.GetTokens()[0]
+	if token.ComparePos(pos, nameNode.End()) > 0 {
+		return nil
+	}
+
+	hoverContent := functionHoverInfo(parseutil.FunctionName(fl), dbCache, driver)
+	if hoverContent == nil {
+		return nil
+	}
+	return &lsp.Hover{
+		Contents: *hoverContent,
+		Range: lsp.Range{
+			Start: lsp.Position{
+				Line:      nameNode.Pos().Line,
+				Character: nameNode.Pos().Col,
+			},
+			End: lsp.Position{
+				Line:      nameNode.End().Line,
+				Character: nameNode.End().Col,
+			},
+		},
+	}
+}
+
 type hoverEnvironment struct {
 	aliases    []ast.Node
 	tables     []*parseutil.TableInfo
 	subQueries []*parseutil.SubQueryInfo
 }
 
-func (e *hoverEnvironment) getTableRealName(aliasName string) (string, bool) {
+// resolveTable translates the alias and returns the schema written in the query, the schema is empty if not qualified.
+func (e *hoverEnvironment) resolveTable(schemaName, name string) (string, string) {
+	if schemaName != "" {
+		return schemaName, name
+	}
 	for _, table := range e.tables {
-		if table.Alias == aliasName {
-			return table.Name, true
+		if table.Alias == name {
+			return table.DatabaseSchema, table.Name
 		}
 	}
-	return "", false
+	for _, table := range e.tables {
+		if table.Name == name {
+			return table.DatabaseSchema, table.Name
+		}
+	}
+	return "", name
 }
 
 func (e *hoverEnvironment) getColumnRealName(aliasedName string) (string, bool) {
@@ -227,11 +280,11 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 		}
 		hoverContents := []*lsp.MarkupContent{}
 		for _, table := range hoverEnv.tables {
-			colDesc, ok := dbCache.Column(table.Name, columnName)
+			colDesc, ok := dbCache.ColumnBySchema(table.DatabaseSchema, table.Name, columnName)
 			if ok {
 				hoverContents = append(
 					hoverContents,
-					columnHoverInfo(table.Name, columnName, colDesc),
+					columnHoverInfo(table.DatabaseSchema, table.Name, colDesc, dbCache),
 				)
 			}
 		}
@@ -244,16 +297,11 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 	}
 	if hoverTypeIs(ctx.types, hoverTypeTable) {
 		// translate table alias
-		tableName := identName
-		for _, table := range hoverEnv.tables {
-			if table.Alias == tableName {
-				tableName = table.Name
-			}
-		}
+		schemaName, tableName := hoverEnv.resolveTable("", identName)
 		// find table
-		cols, ok := dbCache.ColumnDescs(tableName)
+		cols, ok := dbCache.ColumnDescsBySchema(schemaName, tableName)
 		if ok {
-			return tableHoverInfo(tableName, cols)
+			return tableHoverInfo(schemaName, tableName, cols, dbCache)
 		}
 	}
 	if hoverTypeIs(ctx.types, hoverTypeSubQueryColumn) {
@@ -273,14 +321,10 @@ func hoverContentFromParentIdent(ctx *hoverContext, identName string, dbCache *d
 		return nil
 	case parentTypeSchema:
 	case parentTypeTable:
-		tableName := identName
-		realName, ok := hoverEnv.getTableRealName(tableName)
+		schemaName, tableName := hoverEnv.resolveTable(ctx.parent.Schema, identName)
+		columns, ok := dbCache.ColumnDescsBySchema(schemaName, tableName)
 		if ok {
-			tableName = realName
-		}
-		columns, ok := dbCache.ColumnDescs(tableName)
-		if ok {
-			return tableHoverInfo(tableName, columns)
+			return tableHoverInfo(schemaName, tableName, columns, dbCache)
 		}
 	case parentTypeSubQuery:
 		subQueryName := identName
@@ -298,18 +342,17 @@ func hoverContentFromChildIdent(ctx *hoverContext, identName string, dbCache *da
 	case parentTypeNone:
 		return nil
 	case parentTypeSchema:
+		if columns, ok := dbCache.ColumnDatabase(ctx.parent.Name, identName); ok {
+			return tableHoverInfo(ctx.parent.Name, identName, columns, dbCache)
+		}
 		columns, ok := dbCache.ColumnDescs(identName)
 		if ok {
-			return tableHoverInfo(identName, columns)
+			return tableHoverInfo("", identName, columns, dbCache)
 		}
 	case parentTypeTable:
-		tableName := ctx.parent.Name
-		realName, ok := hoverEnv.getTableRealName(tableName)
-		if ok {
-			tableName = realName
-		}
-		if colDesc, ok := dbCache.Column(tableName, identName); ok {
-			return columnHoverInfo(tableName, identName, colDesc)
+		schemaName, tableName := hoverEnv.resolveTable(ctx.parent.Schema, ctx.parent.Name)
+		if colDesc, ok := dbCache.ColumnBySchema(schemaName, tableName, identName); ok {
+			return columnHoverInfo(schemaName, tableName, colDesc, dbCache)
 		}
 		return nil
 	case parentTypeSubQuery:
@@ -322,17 +365,50 @@ func hoverContentFromChildIdent(ctx *hoverContext, identName string, dbCache *da
 	return nil
 }
 
-func columnHoverInfo(tableName, colName string, colDesc *database.ColumnDesc) *lsp.MarkupContent {
+func columnHoverInfo(schemaName, tableName string, colDesc *database.ColumnDesc, dbCache *database.DBCache) *lsp.MarkupContent {
+	if schemaName == "" {
+		schemaName = dbCache.TableSchema(tableName)
+	}
 	return &lsp.MarkupContent{
 		Kind:  lsp.Markdown,
-		Value: database.ColumnDoc(tableName, colDesc),
+		Value: database.ColumnDetailDoc(tableName, colDesc, dbCache.IndexesDatabase(schemaName, tableName)),
+	}
+}
+
+func tableHoverInfo(schemaName, tableName string, cols []*database.ColumnDesc, dbCache *database.DBCache) *lsp.MarkupContent {
+	if schemaName == "" {
+		schemaName = dbCache.TableSchema(tableName)
+	}
+	return &lsp.MarkupContent{
+		Kind: lsp.Markdown,
+		Value: database.TableDetailDoc(
+			tableName,
+			dbCache.TableCommentDatabase(schemaName, tableName),
+			cols,
+			dbCache.IndexesDatabase(schemaName, tableName),
+			dbCache.ConstraintsDatabase(schemaName, tableName),
+		),
 	}
 }
 
-func tableHoverInfo(tableName string, cols []*database.ColumnDesc) *lsp.MarkupContent {
+func functionHoverInfo(funcName string, dbCache *database.DBCache, driver dialect.DatabaseDriver) *lsp.MarkupContent {
+	docs := []string{}
+	if dbCache != nil {
+		for _, fd := range dbCache.Functions("", funcName) {
+			docs = append(docs, database.FunctionDoc(fd))
+		}
+	}
+	if len(docs) == 0 {
+		for _, f := range dialect.LookupFunctions(driver, funcName) {
+			docs = append(docs, database.BuiltinFunctionDoc(f))
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
 	return &lsp.MarkupContent{
 		Kind:  lsp.Markdown,
-		Value: database.TableDoc(tableName, cols),
+		Value: strings.Join(docs, "\n"),
 	}
 }
 
@@ -388,6 +464,8 @@ const (
 type hoverParent struct {
 	Type parentType
 	Name string
+	// Schema is set for schema.table.column
+	Schema string
 }
 
 var noneParent = &hoverParent{Type: parentTypeNone}
@@ -418,8 +496,9 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 			}
 			name := mi.Parent.String()
 			p = &hoverParent{
-				Type: parentTypeTable,
-				Name: name,
+				Type:   parentTypeTable,
+				Name:   name,
+				Schema: mi.SchemaName(),
 			}
 			if hoverEnv.isSubQuery(name) {
 				p = &hoverParent{
@@ -453,8 +532,9 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 			}
 			name := mi.Parent.String()
 			p = &hoverParent{
-				Type: parentTypeTable,
-				Name: name,
+				Type:   parentTypeTable,
+				Name:   name,
+				Schema: mi.SchemaName(),
 			}
 			if hoverEnv.isSubQuery(name) {
 				p = &hoverParent{
@@ -511,8 +591,9 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 				hoverTypeFunction,
 			}
 			p = &hoverParent{
-				Type: parentTypeTable,
-				Name: mi.Parent.String(),
+				Type:   parentTypeTable,
+				Name:   mi.Parent.String(),
+				Schema: mi.SchemaName(),
 			}
 		} else {
 			t = []hoverType{
@@ -199,6 +199,69 @@ FROM city
 		line:   2,
 		col:    6,
 	},
+	{
+		name:   "table ident with indexes and constraints",
+		input:  "SELECT Language FROM countrylanguage",
+		output: "countrylanguage table\n\n- CountryCode: char(3) PRI\n- Language: char(30) PRI\n- IsOfficial: enum('T','F') F\n- Percentage: decimal(4,1)\n\nindexes\n\n- PRIMARY: (CountryCode, Language) PRIMARY BTREE\n- CountryCode: (CountryCode) BTREE\n\nconstraints\n\n- PRIMARY: PRIMARY KEY (CountryCode, Language)\n- countrylanguage_chk_1: CHECK (`Percentage` >= 0)\n",
+		line:   0,
+		col:    23,
+	},
+	{
+		name:   "select ident with indexes",
+		input:  "SELECT CountryCode FROM countrylanguage",
+		output: "countrylanguage.CountryCode column\n\nchar(3) PRI\n\nindexes\n\n- PRIMARY: (CountryCode, Language) PRIMARY BTREE\n- CountryCode: (CountryCode) BTREE\n",
+		line:   0,
+		col:    8,
+	},
+	{
+		name:   "select ident with comment",
+		input:  "SELECT Code FROM country",
+		output: "country.Code column\n\nchar(3) PRI auto_increment\n\nISO 3166-1 alpha-3 code\n",
+		line:   0,
+		col:    8,
+	},
+	{
+		name:   "built-in function name",
+		input:  "SELECT count(ID) FROM city",
+		output: "count built-in function\n\ncount(X) RETURNS INTEGER\n\nReturns a count of the number of times that X is not NULL in a group. count(*) returns the total number of rows in the group.\n",
+		line:   0,
+		col:    9,
+	},
+	{
+		name:   "built-in function argument",
+		input:  "SELECT count(ID) FROM city",
+		output: "city.ID column\n\nint(11) PRI auto_increment\n",
+		line:   0,
+		col:    14,
+	},
+	{
+		name:   "stored function name",
+		input:  "SELECT city_population(ID, 2020) FROM city",
+		output: "world.city_population function\n\ncity_population(city_id int, year int) RETURNS bigint\n",
+		line:   0,
+		col:    9,
+	},
+	{
+		name:   "schema qualified member ident child",
+		input:  "SELECT world.city.Name FROM world.city",
+		output: "city.Name column\n\nchar(35)\n",
+		line:   0,
+		col:    19,
+	},
+	{
+		name:   "schema qualified member ident parent",
+		input:  "SELECT world.city.Name FROM world.city",
+		output: "city table\n\n- ID: int(11) PRI auto_increment\n- Name: char(35)\n- CountryCode: char(3) MUL\n- District: char(20)\n- Population: int(11)\n",
+		line:   0,
+		col:    14,
+	},
+	{
+		name:   "schema qualified table reference ident",
+		input:  "SELECT Code FROM world.country",
+		output: "country.Code column\n\nchar(3) PRI auto_increment\n\nISO 3166-1 alpha-3 code\n",
+		line:   0,
+		col:    8,
+	},
 }
 
 func TestHoverMain(t *testing.T) {
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+// pick asks the user to choose one of the items by window/showMessageRequest, and calls fn with the index of the chosen item.
+// Requests are handled one by one, so the response is waited outside of the handler and fn runs holding the lock of the server.
+func (s *Server) pick(conn *jsonrpc2.Conn, message string, items []string, fn func(ctx context.Context, index int) error) {
+	params := lsp.ShowMessageRequestParams{
+		Type:    lsp.Info,
+		Message: message,
+		Actions: make([]lsp.MessageActionItem, len(items)),
+	}
+	for i, item := range items {
+		params.Actions[i] = lsp.MessageActionItem{Title: item}
+	}
+
+	go func() {
+		ctx := context.Background()
+		var picked *lsp.MessageActionItem
+		if err := conn.Call(ctx, "window/showMessageRequest", params, &picked); err != nil {
+			log.Println("cannot show message request,", err)
+			return
+		}
+		if picked == nil {
+			// dismissed
+			return
+		}
+		index := -1
+		for i, item := range items {
+			if item == picked.Title {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			log.Printf("unknown item %q is picked", picked.Title)
+			return
+		}
+
+		s.mu.Lock()
+		err := fn(ctx, index)
+		s.mu.Unlock()
+		messenger := lsp.NewLspMessenger(conn)
+		if err != nil {
+			err = messenger.ShowError(ctx, err.Error())
+		} else {
+			err = messenger.ShowInfo(ctx, "switched to "+items[index])
+		}
+		if err != nil {
+			log.Println("cannot show message,", err)
+		}
+	}()
+}
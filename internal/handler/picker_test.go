@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestSwitchWithPicker(t *testing.T) {
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "dev", Driver: "mock"},
+			{Alias: "staging", Driver: "mock"},
+		},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	waitFor := func(t *testing.T, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			tx.server.mu.Lock()
+			ok := cond()
+			tx.server.mu.Unlock()
+			if ok {
+				return
+			}
+		}
+		t.Fatal("timed out")
+	}
+
+	var requested lsp.ShowMessageRequestParams
+	tx.client.pick = func(params lsp.ShowMessageRequestParams) *lsp.MessageActionItem {
+		requested = params
+		for _, action := range params.Actions {
+			if strings.Contains(action.Title, "staging") || action.Title == "sys" {
+				return &action
+			}
+		}
+		return nil
+	}
+
+	params := lsp.ExecuteCommandParams{Command: CommandSwitchConnection}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	waitFor(t, func() bool { return tx.server.curConnectionIndex == 1 })
+	if want := []lsp.MessageActionItem{{Title: "1 mock dev "}, {Title: "2 mock staging "}}; len(requested.Actions) != len(want) || requested.Actions[0] != want[0] || requested.Actions[1] != want[1] {
+		t.Errorf("got actions %+v, want %+v", requested.Actions, want)
+	}
+
+	params = lsp.ExecuteCommandParams{Command: CommandSwitchDatabase}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	waitFor(t, func() bool { return tx.server.curDBName == "sys" })
+	if got := len(requested.Actions); got != 5 {
+		t.Errorf("got %d databases, want 5", got)
+	}
+
+	// dismissing the request keeps the database
+	tx.client.pick = func(params lsp.ShowMessageRequestParams) *lsp.MessageActionItem { return nil }
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil); err != nil {
+		t.Fatal("conn.Call workspace/executeCommand:", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	tx.server.mu.Lock()
+	defer tx.server.mu.Unlock()
+	if tx.server.curDBName != "sys" {
+		t.Errorf("got database %q, want sys", tx.server.curDBName)
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"log"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+	"golang.org/x/xerrors"
+)
+
+func rootPath(params lsp.InitializeParams) string {
+	if params.RootURI != "" {
+		return uriToPath(params.RootURI)
+	}
+	return params.RootPath
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+// loadProjectConfig replaces the project config with the one found from the workspace root, and reports whether it was replaced.
+func (s *Server) loadProjectConfig() (bool, error) {
+	if s.SpecificFileCfg != nil || s.rootPath == "" {
+		return false, nil
+	}
+	fp, ok := s.findProjectConfig(s.rootPath)
+	if !ok {
+		changed := s.projectCfgPath != ""
+		s.ProjectFileCfg = nil
+		s.projectCfgPath = ""
+		return changed, nil
+	}
+	if fp == s.projectCfgPath {
+		return false, nil
+	}
+	cfg, err := config.GetConfig(fp)
+	if err != nil {
+		return false, xerrors.Errorf("cannot read project config %s, %+v", fp, err)
+	}
+	logUntrustedCommands(s.DefaultFileCfg, fp, cfg)
+	s.ProjectFileCfg = cfg
+	s.projectCfgPath = fp
+	return true, nil
+}
+
+func logUntrustedCommands(userCfg *config.Config, fp string, cfg *config.Config) {
+	if cfg.HasSecretCommands() && !userCfg.IsTrustedProject(fp) {
+		log.Printf("ignore passwdCommand and passPhraseCommand of project config %s, add its directory to trustedProjects of the user config to run them", fp)
+	}
+}
+
+// setDocumentProject records the nearest project config of the document below the workspace root.
+// The project config of the workspace stays in use, documents of another project use its connection by projectBinding.
+func (s *Server) setDocumentProject(uri string) {
+	f, ok := s.files[uri]
+	if !ok {
+		return
+	}
+	f.projectCfgPath = ""
+	path := uriToPath(uri)
+	if path == "" {
+		return
+	}
+	if fp, ok := s.findProjectConfig(filepath.Dir(path)); ok {
+		f.projectCfgPath = fp
+	}
+}
+
+// findProjectConfig looks for the nearest project config of dir.
+// Any parent directory may have one, so a project config outside of the workspace root is used only when the user config trusts it.
+func (s *Server) findProjectConfig(dir string) (string, bool) {
+	fp, ok := config.FindProjectConfig(dir, "")
+	if !ok {
+		return "", false
+	}
+	if s.rootPath != "" && config.InDirectory(s.rootPath, fp) {
+		return fp, true
+	}
+	if s.DefaultFileCfg.IsTrustedProject(fp) {
+		return fp, true
+	}
+	log.Printf("ignore project config %s outside of the workspace, add its directory to trustedProjects of the user config to use it", fp)
+	return "", false
+}
+
+// trustProjectConfig returns the project config without passwdCommand and passPhraseCommand unless the user config trusts it,
+// so that opening a repository never runs the commands it comes with.
+func (s *Server) trustProjectConfig(fp string, cfg *config.Config) *config.Config {
+	if cfg == nil || s.DefaultFileCfg.IsTrustedProject(fp) {
+		return cfg
+	}
+	return cfg.WithoutSecretCommands()
+}
+
+// projectBinding binds the document under another project config than the workspace to the first connection of that project.
+// It returns nil when the document uses the default connection.
+func (s *Server) projectBinding(f *File) (*connectionBinding, *database.DBConfig) {
+	if s.SpecificFileCfg != nil || f.projectCfgPath == "" || f.projectCfgPath == s.projectCfgPath {
+		return nil, nil
+	}
+	cfg := s.projectConnection(f.projectCfgPath)
+	if cfg == nil || reflect.DeepEqual(cfg, s.topConnection()) {
+		return nil, nil
+	}
+	return &connectionBinding{Project: f.projectCfgPath, Connection: cfg.Alias}, cfg
+}
+
+// projectConnection returns the first connection of the settings merged with the project config instead of the workspace one.
+// The project configs are read once, until the file is changed.
+func (s *Server) projectConnection(fp string) *database.DBConfig {
+	projectCfg, ok := s.projectCfgs[fp]
+	if !ok {
+		var err error
+		if projectCfg, err = config.GetConfig(fp); err != nil {
+			log.Printf("cannot read project config %s, %+v", fp, err)
+			projectCfg = nil
+		} else {
+			logUntrustedCommands(s.DefaultFileCfg, fp, projectCfg)
+		}
+		s.projectCfgs[fp] = projectCfg
+	}
+	if projectCfg == nil {
+		return nil
+	}
+	cfg := config.Merge(s.DefaultFileCfg, s.trustProjectConfig(fp, projectCfg), s.WSCfg)
+	if len(cfg.Connections) == 0 {
+		return nil
+	}
+	return cfg.Connections[0]
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestProjectConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "reporting")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectCfg := "lowercaseKeywords: true\nconnections:\n  - alias: project\n    driver: mock\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, config.ProjectConfigFileName), []byte(projectCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	subCfg := "connections:\n  - alias: reporting\n    driver: mock\n"
+	if err := ioutil.WriteFile(filepath.Join(sub, config.ProjectConfigFileName), []byte(subCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	defer tx.tearDown()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Alias: "user", Driver: "mock"}},
+	}
+	client, server := net.Pipe()
+	tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(tx.client.handle))
+	params := lsp.InitializeParams{
+		RootURI: "file://" + filepath.ToSlash(dir),
+	}
+	if err := tx.conn.Call(tx.ctx, "initialize", params, nil); err != nil {
+		t.Fatal("conn.Call initialize:", err)
+	}
+
+	assertConnections := func(t *testing.T, want ...string) {
+		t.Helper()
+		got := []string{}
+		for _, conn := range tx.server.getConfig().Connections {
+			got = append(got, conn.Alias)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	}
+	didOpen := func(t *testing.T, path string) {
+		t.Helper()
+		params := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:        "file://" + filepath.ToSlash(path),
+				LanguageID: "sql",
+				Text:       "SELECT 1",
+			},
+		}
+		if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", params, nil); err != nil {
+			t.Fatal("conn.Call textDocument/didOpen:", err)
+		}
+	}
+
+	// the project config of the workspace root precedes the user config
+	assertConnections(t, "project", "user")
+	if tx.server.curDBCfg == nil || tx.server.curDBCfg.Alias != "project" {
+		t.Fatalf("connected to %+v, want project", tx.server.curDBCfg)
+	}
+	if !tx.server.getConfig().LowercaseKeywords {
+		t.Error("lowercaseKeywords of the project config is not used")
+	}
+
+	// a document of another project uses the connection of its project config, the workspace one is kept
+	dailyURI := "file://" + filepath.ToSlash(filepath.Join(sub, "daily.sql"))
+	didOpen(t, filepath.Join(sub, "daily.sql"))
+	assertConnections(t, "project", "user")
+	if tx.server.curDBCfg.Alias != "project" {
+		t.Errorf("connected to %s, want project", tx.server.curDBCfg.Alias)
+	}
+	tx.server.mu.Lock()
+	sess, err := tx.server.documentSession(tx.ctx, dailyURI)
+	tx.server.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.cfg.Alias != "reporting" || sess.worker == tx.server.worker {
+		t.Errorf("got session of %s, want reporting", sess.name())
+	}
+
+	// editor settings precede the project config
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{{Alias: "editor", Driver: "mock"}},
+	})
+	assertConnections(t, "editor", "project", "user")
+	tx.server.mu.Lock()
+	sess, err = tx.server.documentSession(tx.ctx, dailyURI)
+	tx.server.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.worker != tx.server.worker {
+		t.Errorf("got session of %s, want the default connection", sess.name())
+	}
+	if len(tx.server.sessions) != 0 {
+		t.Errorf("unused sessions are open, %v", tx.server.sessions)
+	}
+}
+
+func TestProjectConfigCreated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tx := newTestContext()
+	defer tx.tearDown()
+	tx.server.DefaultFileCfg = &config.Config{
+		Connections: []*database.DBConfig{{Alias: "user", Driver: "mock"}},
+	}
+	client, server := net.Pipe()
+	tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(tx.client.handle))
+	params := lsp.InitializeParams{
+		RootURI: "file://" + filepath.ToSlash(dir),
+	}
+	if err := tx.conn.Call(tx.ctx, "initialize", params, nil); err != nil {
+		t.Fatal("conn.Call initialize:", err)
+	}
+	if tx.server.curDBCfg == nil || tx.server.curDBCfg.Alias != "user" {
+		t.Fatalf("connected to %+v, want user", tx.server.curDBCfg)
+	}
+
+	fp := filepath.Join(dir, config.ProjectConfigFileName)
+	notify := func(t *testing.T, typ lsp.FileChangeType) {
+		t.Helper()
+		params := lsp.DidChangeWatchedFilesParams{
+			Changes: []lsp.FileEvent{{URI: "file://" + filepath.ToSlash(fp), Type: typ}},
+		}
+		if err := tx.conn.Call(tx.ctx, "workspace/didChangeWatchedFiles", params, nil); err != nil {
+			t.Fatal("conn.Call workspace/didChangeWatchedFiles:", err)
+		}
+	}
+
+	// a project config created after initialize is used
+	projectCfg := "connections:\n  - alias: project\n    driver: mock\n"
+	if err := ioutil.WriteFile(fp, []byte(projectCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	notify(t, lsp.Created)
+	if tx.server.projectCfgPath != fp {
+		t.Fatalf("project config %q is not used", fp)
+	}
+	if tx.server.curDBCfg.Alias != "project" {
+		t.Errorf("connected to %s, want project", tx.server.curDBCfg.Alias)
+	}
+
+	if err := os.Remove(fp); err != nil {
+		t.Fatal(err)
+	}
+	notify(t, lsp.Deleted)
+	if tx.server.ProjectFileCfg != nil || tx.server.projectCfgPath != "" {
+		t.Error("the deleted project config is used")
+	}
+	if tx.server.curDBCfg.Alias != "user" {
+		t.Errorf("connected to %s, want user", tx.server.curDBCfg.Alias)
+	}
+}
+
+func TestProjectConfigTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "marker")
+	writeProjectConfig := func(t *testing.T, d, alias string) {
+		t.Helper()
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		cfg := "connections:\n  - alias: " + alias + "\n    driver: mock\n    passwdCommand: touch " + marker + "\n"
+		if err := ioutil.WriteFile(filepath.Join(d, config.ProjectConfigFileName), []byte(cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProjectConfig(t, dir, "parent")
+	writeProjectConfig(t, filepath.Join(dir, "repo"), "repo")
+	if err := os.Mkdir(filepath.Join(dir, "workspace"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		root       string
+		trusted    []string
+		want       string
+		wantMarker bool
+	}{
+		{name: "above the workspace root", root: filepath.Join(dir, "workspace"), want: "user"},
+		{name: "trusted above the workspace root", root: filepath.Join(dir, "workspace"), trusted: []string{dir}, want: "parent", wantMarker: true},
+		{name: "command of the workspace", root: filepath.Join(dir, "repo"), want: "repo"},
+		{name: "trusted command of the workspace", root: filepath.Join(dir, "repo"), trusted: []string{filepath.Join(dir, "repo")}, want: "repo", wantMarker: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(marker)
+			tx := newTestContext()
+			defer tx.tearDown()
+			tx.server.DefaultFileCfg = &config.Config{
+				Connections:     []*database.DBConfig{{Alias: "user", Driver: "mock"}},
+				TrustedProjects: tt.trusted,
+			}
+			client, server := net.Pipe()
+			tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+			tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(tx.client.handle))
+			params := lsp.InitializeParams{
+				RootURI: "file://" + filepath.ToSlash(tt.root),
+			}
+			if err := tx.conn.Call(tx.ctx, "initialize", params, nil); err != nil {
+				t.Fatal("conn.Call initialize:", err)
+			}
+			if tx.server.curDBCfg == nil || tx.server.curDBCfg.Alias != tt.want {
+				t.Errorf("connected to %+v, want %s", tx.server.curDBCfg, tt.want)
+			}
+			if _, err := os.Stat(marker); (err == nil) != tt.wantMarker {
+				t.Errorf("passwdCommand run %v, want %v", err == nil, tt.wantMarker)
+			}
+		})
+	}
+}
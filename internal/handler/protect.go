@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/parser/parseutil"
+)
+
+// pendingExecution is the statements held until confirmExecuteQuery is run
+type pendingExecution struct {
+	uri     string
+	queries []string
+}
+
+func (p *pendingExecution) match(uri string, queries []string) bool {
+	if p == nil || p.uri != uri || len(p.queries) != len(queries) {
+		return false
+	}
+	for i := range queries {
+		if p.queries[i] != queries[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *dbSession) name() string {
+	if d.cfg == nil {
+		return ""
+	}
+	if d.cfg.Alias != "" {
+		return d.cfg.Alias
+	}
+	return string(d.cfg.Driver)
+}
+
+func execQueries(queries []string) []string {
+	execs := []string{}
+	for _, query := range queries {
+		if execType, isQuery := database.QueryExecType(query, query); !isQuery || (execType == "WITH" && withModifiesData(query)) {
+			execs = append(execs, query)
+		}
+	}
+	return execs
+}
+
+// withModifiesData reports whether the query following a WITH clause or one of its CTEs modifies data
+func withModifiesData(query string) bool {
+	stmts, err := getStatements(query)
+	if err != nil {
+		// cannot tell, so treat it as modifying
+		return true
+	}
+	for _, stmt := range stmts {
+		if parseutil.WithModifiesData(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectReadOnly rejects statements other than queries on a read-only connection
+func rejectReadOnly(sess *dbSession, queries []string) error {
+	if sess.cfg == nil || !sess.cfg.ReadOnly {
+		return nil
+	}
+	if execs := execQueries(queries); len(execs) > 0 {
+		return fmt.Errorf("connection %s is read-only, cannot execute %q", sess.name(), execs[0])
+	}
+	return nil
+}
+
+// refuseDangerous refuses the statements which change or remove every row of a table, with the estimated number of the rows.
+func refuseDangerous(ctx context.Context, repo database.DBRepository, stmts []*ast.Statement) error {
+	for _, stmt := range stmts {
+		d, ok := parseutil.CheckDangerousStatement(stmt)
+		if !ok {
+			continue
+		}
+		estimate := ""
+		if d.CountQuery != "" {
+			if n, err := estimateRows(ctx, repo, d.CountQuery); err != nil {
+				log.Println("cannot estimate affected rows,", err)
+			} else {
+				estimate = fmt.Sprintf(", estimated %s rows", n)
+			}
+		}
+		return fmt.Errorf("%q is refused, %s%s. Pass \"-force\" to executeQuery to execute it", strings.TrimSpace(stmt.String()), d.Reason, estimate)
+	}
+	return nil
+}
+
+func estimateRows(ctx context.Context, repo database.DBRepository, query string) (string, error) {
+	rows, err := repo.Query(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	columns, err := database.Columns(rows)
+	if err != nil {
+		return "", err
+	}
+	stringRows, err := database.ScanRows(rows, len(columns))
+	if err != nil {
+		return "", err
+	}
+	if len(stringRows) == 0 || len(stringRows[0]) == 0 {
+		return "", errors.New("no rows counted")
+	}
+	return stringRows[0][0], nil
+}
+
+// confirmExecution holds statements other than queries on a connection of the confirm mode, and returns the message asking for confirmExecuteQuery, unless confirmed.
+func (s *Server) confirmExecution(sess *dbSession, uri string, queries []string, confirmed bool) (string, error) {
+	if sess.cfg == nil || !sess.cfg.Confirm {
+		return "", nil
+	}
+	execs := execQueries(queries)
+	if len(execs) == 0 {
+		return "", nil
+	}
+
+	if confirmed {
+		if !s.pendingExecution.match(uri, queries) {
+			return "", errors.New("no statements are waiting for confirmation, run executeQuery first")
+		}
+		s.pendingExecution = nil
+		return "", nil
+	}
+	s.pendingExecution = &pendingExecution{uri: uri, queries: queries}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "The following statements modify connection %s and need confirmation.\n", sess.name())
+	for _, query := range execs {
+		fmt.Fprintf(buf, "  %s\n", query)
+	}
+	fmt.Fprintf(buf, "Run %s with the same arguments to execute them.\n", CommandConfirmExecuteQuery)
+	return buf.String(), nil
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExecQueries(t *testing.T) {
+	queries := []string{
+		"SELECT 1",
+		"PRAGMA table_info(city)",
+		"PRAGMA query_only = 0",
+		"DELETE FROM city WHERE ID = 1",
+		"WITH c AS (SELECT ID FROM city) SELECT * FROM c",
+		"WITH c AS (SELECT ID FROM city) DELETE FROM city WHERE ID IN (SELECT ID FROM c)",
+		"WITH d AS (DELETE FROM city WHERE ID = 1 RETURNING *) SELECT * FROM d",
+	}
+	want := []string{
+		"PRAGMA query_only = 0",
+		"DELETE FROM city WHERE ID = 1",
+		"WITH c AS (SELECT ID FROM city) DELETE FROM city WHERE ID IN (SELECT ID FROM c)",
+		"WITH d AS (DELETE FROM city WHERE ID = 1 RETURNING *) SELECT * FROM d",
+	}
+	if got := execQueries(queries); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"reflect"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"golang.org/x/xerrors"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+const watchConfigRegistrationID = "sqls-watch-config"
+
+// registerConfigWatchers asks the client to notify changes of the config files by workspace/didChangeWatchedFiles.
+// The user config files are watched by absolute paths, which some clients report only for files in the workspace.
+func (s *Server) registerConfigWatchers(ctx context.Context, conn *jsonrpc2.Conn) {
+	watchers := []lsp.FileSystemWatcher{
+		{GlobPattern: "**/" + config.ProjectConfigFileName},
+	}
+	for _, fp := range []string{s.SpecificFilePath, s.DefaultFilePath} {
+		if fp != "" {
+			watchers = append(watchers, lsp.FileSystemWatcher{GlobPattern: filepath.ToSlash(fp)})
+		}
+	}
+	params := lsp.RegistrationParams{
+		Registrations: []lsp.Registration{
+			{
+				ID:              watchConfigRegistrationID,
+				Method:          "workspace/didChangeWatchedFiles",
+				RegisterOptions: lsp.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+			},
+		},
+	}
+	// requests are handled one by one, so wait for the response outside of the handler
+	go func() {
+		if err := conn.Call(ctx, "client/registerCapability", params, nil); err != nil {
+			log.Println("cannot register config watchers,", err)
+		}
+	}()
+}
+
+func (s *Server) handleWorkspaceDidChangeWatchedFiles(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DidChangeWatchedFilesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	messenger := lsp.NewLspMessenger(conn)
+	changed := false
+	for _, change := range params.Changes {
+		ok, err := s.reloadConfigFile(uriToPath(change.URI), change.Type)
+		if err != nil {
+			if err := messenger.ShowError(ctx, err.Error()); err != nil {
+				return nil, err
+			}
+		}
+		changed = changed || ok
+	}
+	if !changed {
+		return nil, nil
+	}
+	if err := s.applyConfig(ctx); err != nil {
+		if err := messenger.ShowError(ctx, err.Error()); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// reloadConfigFile reads the config file again if it is in use, and reports whether the config is replaced.
+// The config in use is kept when the file is invalid.
+func (s *Server) reloadConfigFile(fp string, typ lsp.FileChangeType) (bool, error) {
+	if fp == "" {
+		return false, nil
+	}
+	if filepath.Base(fp) == config.ProjectConfigFileName {
+		// the documents of other projects read it again
+		delete(s.projectCfgs, fp)
+		if typ != lsp.Changed {
+			for uri := range s.files {
+				s.setDocumentProject(uri)
+			}
+		}
+	}
+
+	var target **config.Config
+	switch fp {
+	case s.SpecificFilePath:
+		target = &s.SpecificFileCfg
+	case s.DefaultFilePath:
+		target = &s.DefaultFileCfg
+	case s.projectCfgPath:
+		target = &s.ProjectFileCfg
+	default:
+		if filepath.Base(fp) != config.ProjectConfigFileName {
+			return false, nil
+		}
+		// the project config of the workspace may be created, and the documents of other projects are reconnected if needed
+		if typ != lsp.Deleted {
+			if _, err := s.loadProjectConfig(); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	}
+
+	if typ == lsp.Deleted {
+		*target = nil
+		if fp == s.projectCfgPath {
+			// fall back to the project config of a parent directory
+			s.projectCfgPath = ""
+			if _, err := s.loadProjectConfig(); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	}
+	cfg, errs, err := config.CheckFile(fp)
+	if err != nil {
+		return false, xerrors.Errorf("cannot reload config %s, %+v", fp, err)
+	}
+	if len(errs) > 0 {
+		return false, xerrors.Errorf("invalid config %s\n%s", fp, errs.Error())
+	}
+	*target = cfg
+	if fp == s.DefaultFilePath {
+		// trustedProjects may be changed
+		if _, err := s.loadProjectConfig(); err != nil {
+			return true, err
+		}
+		for uri := range s.files {
+			s.setDocumentProject(uri)
+		}
+	}
+	return true, nil
+}
+
+// applyConfig reconnects the connections whose settings are changed by the reloaded config.
+func (s *Server) applyConfig(ctx context.Context) error {
+	for key, sess := range s.sessions {
+		if cfg := s.findConnection(sess.binding); cfg != nil && reflect.DeepEqual(cfg, sess.cfg) {
+			continue
+		}
+		sess.close()
+		delete(s.sessions, key)
+		// the documents reconnect on the next use
+		for _, f := range s.files {
+			if f.binding == key {
+				f.binding = ""
+			}
+		}
+	}
+	// the documents whose project connection is now the default one
+	for uri := range s.files {
+		s.unbindDocument(uri)
+	}
+
+	cfg := s.getConnection(s.curConnectionIndex)
+	if cfg == nil {
+		s.curConnectionIndex = 0
+		s.curDBName = ""
+		cfg = s.topConnection()
+	}
+	if cfg == nil {
+		return nil
+	}
+	if s.curDBName != "" {
+		dbCfg := *cfg
+		dbCfg.DBName = s.curDBName
+		cfg = &dbCfg
+	}
+	if s.dbConn != nil && reflect.DeepEqual(cfg, s.curDBCfg) {
+		return nil
+	}
+	return s.reconnectionDB(ctx)
+}
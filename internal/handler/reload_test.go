@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestReloadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqls-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fp := filepath.Join(dir, "config.yml")
+	writeConfig := func(t *testing.T, s string) {
+		t.Helper()
+		if err := ioutil.WriteFile(fp, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(t, "connections:\n  - alias: dev\n    driver: mock\n")
+	cfg, err := config.GetConfig(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestContext()
+	tx.server.DefaultFileCfg = cfg
+	tx.server.DefaultFilePath = fp
+	tx.setup(t)
+	defer tx.tearDown()
+
+	notify := func(t *testing.T, typ lsp.FileChangeType) {
+		t.Helper()
+		params := lsp.DidChangeWatchedFilesParams{
+			Changes: []lsp.FileEvent{{URI: "file://" + filepath.ToSlash(fp), Type: typ}},
+		}
+		if err := tx.conn.Call(tx.ctx, "workspace/didChangeWatchedFiles", params, nil); err != nil {
+			t.Fatal("conn.Call workspace/didChangeWatchedFiles:", err)
+		}
+	}
+
+	conn := tx.server.dbConn
+	if conn == nil {
+		t.Fatal("not connected")
+	}
+
+	// adding a connection keeps the active one
+	writeConfig(t, "connections:\n  - alias: dev\n    driver: mock\n  - alias: staging\n    driver: mock\n")
+	notify(t, lsp.Changed)
+	if got := len(tx.server.getConfig().Connections); got != 2 {
+		t.Errorf("got %d connections, want 2", got)
+	}
+	if tx.server.dbConn != conn {
+		t.Error("reconnected though the active connection is not changed")
+	}
+
+	// an invalid edit keeps the config in use
+	writeConfig(t, "connections:\n  - alias: dev\n    driver: [mock\n")
+	notify(t, lsp.Changed)
+	if got := len(tx.server.getConfig().Connections); got != 2 {
+		t.Errorf("got %d connections, want 2", got)
+	}
+	if tx.server.dbConn != conn {
+		t.Error("the connection is dropped by an invalid config")
+	}
+
+	// changing the active connection reconnects
+	writeConfig(t, "connections:\n  - alias: dev\n    driver: mock\n    dbName: other\n")
+	notify(t, lsp.Changed)
+	if tx.server.dbConn == conn {
+		t.Error("not reconnected")
+	}
+	if tx.server.curDBCfg.DBName != "other" {
+		t.Errorf("connected to %q, want other", tx.server.curDBCfg.DBName)
+	}
+
+	notify(t, lsp.Deleted)
+	if tx.server.DefaultFileCfg != nil {
+		t.Error("the deleted config is used")
+	}
+}
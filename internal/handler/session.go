@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lighttiger2505/sqls/dialect"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"golang.org/x/xerrors"
+)
+
+// dbSession is a database connection and the schema cache of it
+type dbSession struct {
+	// binding is nil for the default connection
+	binding       *connectionBinding
+	cfg           *database.DBConfig
+	conn          *database.DBConnection
+	worker        *database.Worker
+	serverVersion string
+}
+
+func (d *dbSession) driver() dialect.DatabaseDriver {
+	if d.cfg == nil {
+		return ""
+	}
+	return d.cfg.Driver
+}
+
+func (d *dbSession) repository() (database.DBRepository, error) {
+	if d.conn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	return database.CreateRepository(d.cfg.Driver, d.conn.Conn)
+}
+
+// cache returns the schema cache, nil while the connection is being opened
+func (d *dbSession) cache() *database.DBCache {
+	if d.worker == nil {
+		return nil
+	}
+	return d.worker.Cache()
+}
+
+func (d *dbSession) close() {
+	if err := d.conn.Close(); err != nil {
+		log.Println("cannot close database connection,", err)
+	}
+	d.worker.Stop()
+}
+
+// connectionBinding binds a document to a connection by a header comment like "-- sqls: connection=reporting database=sales",
+// or to the first connection of its project config
+type connectionBinding struct {
+	Connection string
+	Database   string
+	// Project is the project config of a document bound by its project
+	Project string
+}
+
+func (b *connectionBinding) key() string {
+	if b.Project != "" {
+		return "project:" + b.Project
+	}
+	if b.Database == "" {
+		return b.Connection
+	}
+	return b.Connection + "/" + b.Database
+}
+
+var bindingCommentPattern = regexp.MustCompile(`^--\s*sqls:(.*)$`)
+
+// parseConnectionBinding reads the binding from the comments at the head of the document
+func parseConnectionBinding(text string) (*connectionBinding, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		m := bindingCommentPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		b := &connectionBinding{}
+		for _, field := range strings.Fields(m[1]) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "connection":
+				b.Connection = kv[1]
+			case "database":
+				b.Database = kv[1]
+			}
+		}
+		if b.Connection != "" {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// defaultSession is the connection selected by switchConnections and switchDatabase, used by documents without a binding
+func (s *Server) defaultSession() *dbSession {
+	cfg := s.curDBCfg
+	if cfg == nil {
+		cfg = s.topConnection()
+	}
+	return &dbSession{
+		cfg:           cfg,
+		conn:          s.dbConn,
+		worker:        s.worker,
+		serverVersion: s.serverVersion,
+	}
+}
+
+// documentSession returns the session the document is bound to, or the default session.
+// The bound connection is opened on the first use, not while the binding comment is being typed.
+func (s *Server) documentSession(ctx context.Context, uri string) (*dbSession, error) {
+	f, ok := s.files[uri]
+	if !ok {
+		return s.defaultSession(), nil
+	}
+	b, cfg := s.documentBinding(f)
+	if b == nil {
+		return s.defaultSession(), nil
+	}
+	key := b.key()
+	sess, ok := s.sessions[key]
+	if !ok {
+		var err error
+		if sess, err = s.openSession(ctx, b, cfg); err != nil {
+			// the binding is kept unset to retry on the next use
+			return nil, err
+		}
+		s.sessions[key] = sess
+		delete(s.openings, key)
+	}
+	s.bindDocument(f, key)
+	return sess, nil
+}
+
+func (s *Server) bindDocument(f *File, key string) {
+	if f.binding != key {
+		f.binding = key
+		s.closeUnusedSessions()
+	}
+}
+
+// cachedSession returns the session for the requests answered from the schema cache such as completion, which must not wait for connecting.
+// A bound connection not open yet is opened in the background, and the document is served without the cache until then.
+func (s *Server) cachedSession(uri string) *dbSession {
+	f, ok := s.files[uri]
+	if !ok {
+		return s.defaultSession()
+	}
+	b, cfg := s.documentBinding(f)
+	if b == nil {
+		return s.defaultSession()
+	}
+	key := b.key()
+	if sess, ok := s.sessions[key]; ok {
+		s.bindDocument(f, key)
+		return sess
+	}
+	s.openSessionInBackground(b, cfg)
+	return &dbSession{binding: b, cfg: cfg}
+}
+
+// sessionOpening is the state of a bound connection opened in the background
+type sessionOpening struct {
+	cfg        *database.DBConfig
+	connecting bool
+	// retryAt is when a connection failed to open is tried again
+	retryAt time.Time
+	backoff time.Duration
+}
+
+// openSessionInBackground opens the bound connection unless it is being opened, or failed a moment ago.
+// A connection failed to open is tried again with backoff, or as soon as its config is changed.
+func (s *Server) openSessionInBackground(b *connectionBinding, cfg *database.DBConfig) {
+	key := b.key()
+	op, ok := s.openings[key]
+	if !ok || !reflect.DeepEqual(op.cfg, cfg) {
+		op = &sessionOpening{cfg: cfg}
+		s.openings[key] = op
+	}
+	if op.connecting || time.Now().Before(op.retryAt) {
+		return
+	}
+	op.connecting = true
+
+	go func() {
+		ctx := context.Background()
+		sess, repo, err := s.connectSession(ctx, b, cfg)
+
+		s.mu.Lock()
+		if s.openings[key] == op {
+			op.connecting = false
+		}
+		if err != nil {
+			if op.backoff *= 2; op.backoff == 0 {
+				op.backoff = s.reconnectBackoff
+			} else if op.backoff > maxReconnectBackoff {
+				op.backoff = maxReconnectBackoff
+			}
+			op.retryAt = time.Now().Add(op.backoff)
+			s.mu.Unlock()
+			log.Printf("%+v, retrying in %s", err, op.backoff)
+			return
+		}
+		if s.openings[key] == op {
+			delete(s.openings, key)
+		}
+		if _, ok := s.sessions[key]; ok || !s.bindSessionDocuments(key, cfg) {
+			// opened by another request, or the documents are not bound to it anymore
+			s.mu.Unlock()
+			sess.close()
+			return
+		}
+		s.sessions[key] = sess
+		gen := sess.worker.Switch(repo, cfg)
+		s.mu.Unlock()
+
+		if err := sess.worker.Load(ctx, gen); err != nil {
+			log.Println("cannot load schema cache,", err)
+		}
+	}()
+}
+
+// bindSessionDocuments binds the documents using the connection of key to it, and reports whether any document uses it
+func (s *Server) bindSessionDocuments(key string, cfg *database.DBConfig) bool {
+	used := false
+	for _, f := range s.files {
+		if b, c := s.documentBinding(f); b != nil && b.key() == key && reflect.DeepEqual(c, cfg) {
+			f.binding = key
+			used = true
+		}
+	}
+	return used
+}
+
+// openedSession returns the session the document uses without connecting, nil if the bound connection is not open yet
+func (s *Server) openedSession(uri string) *dbSession {
+	f, ok := s.files[uri]
+	if !ok {
+		return s.defaultSession()
+	}
+	b, _ := s.documentBinding(f)
+	if b == nil {
+		return s.defaultSession()
+	}
+	return s.sessions[b.key()]
+}
+
+// documentBinding returns the binding of the document and the config of its connection.
+// It returns nil for a document using the default connection, or with an alias no connection has such as one being typed.
+func (s *Server) documentBinding(f *File) (*connectionBinding, *database.DBConfig) {
+	b, ok := parseConnectionBinding(f.Text)
+	if !ok {
+		return s.projectBinding(f)
+	}
+	cfg := s.findConnection(b)
+	if cfg == nil {
+		log.Printf("connection %q of the binding comment is not found, using the default connection", b.Connection)
+		return nil, nil
+	}
+	return b, cfg
+}
+
+// unbindDocument releases the session of the document when the binding comment is changed.
+func (s *Server) unbindDocument(uri string) {
+	f, ok := s.files[uri]
+	if !ok || f.binding == "" {
+		return
+	}
+	if b, _ := s.documentBinding(f); b != nil && b.key() == f.binding {
+		return
+	}
+	f.binding = ""
+	s.closeUnusedSessions()
+}
+
+func (s *Server) openSession(ctx context.Context, b *connectionBinding, cfg *database.DBConfig) (*dbSession, error) {
+	sess, repo, err := s.connectSession(ctx, b, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.worker.ReCache(ctx, repo, cfg); err != nil {
+		sess.close()
+		return nil, err
+	}
+	return sess, nil
+}
+
+// connectSession opens the connection of the session without loading the schema cache.
+// It does not touch the server, so that it can run without the lock.
+func (s *Server) connectSession(ctx context.Context, b *connectionBinding, cfg *database.DBConfig) (*dbSession, database.DBRepository, error) {
+	conn, err := database.Open(cfg)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("cannot connect to %s, %+v", b.key(), err)
+	}
+	sess := &dbSession{
+		binding: b,
+		cfg:     cfg,
+		conn:    conn,
+		worker:  database.NewWorker(),
+	}
+	if s.cacheStore != nil {
+		sess.worker.SetCacheStore(s.cacheStore)
+	}
+	sess.worker.Start()
+
+	repo, err := sess.repository()
+	if err != nil {
+		sess.close()
+		return nil, nil, err
+	}
+	if sess.serverVersion, err = repo.ServerVersion(ctx); err != nil {
+		log.Println("cannot get server version,", err)
+	}
+	return sess, repo, nil
+}
+
+// findConnection returns the config of the connection the binding refers to
+func (s *Server) findConnection(b *connectionBinding) *database.DBConfig {
+	if b.Project != "" {
+		return s.projectConnection(b.Project)
+	}
+	for _, conn := range s.getConfig().Connections {
+		if conn.Alias != b.Connection {
+			continue
+		}
+		if b.Database == "" {
+			return conn
+		}
+		dbCfg := *conn
+		dbCfg.DBName = b.Database
+		return &dbCfg
+	}
+	return nil
+}
+
+// closeUnusedSessions closes the sessions no open document is bound to
+func (s *Server) closeUnusedSessions() {
+	used := map[string]bool{}
+	for _, f := range s.files {
+		used[f.binding] = true
+	}
+	for key, sess := range s.sessions {
+		if !used[key] {
+			sess.close()
+			delete(s.sessions, key)
+		}
+	}
+}
+
+func (s *Server) closeSessions() {
+	for key, sess := range s.sessions {
+		sess.close()
+		delete(s.sessions, key)
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
+	"github.com/lighttiger2505/sqls/internal/lsp"
+)
+
+func TestParseConnectionBinding(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   connectionBinding
+		wantOK bool
+	}{
+		{
+			name:   "connection and database",
+			input:  "-- sqls: connection=reporting database=sales\nSELECT 1",
+			want:   connectionBinding{Connection: "reporting", Database: "sales"},
+			wantOK: true,
+		},
+		{
+			name:   "after other comments",
+			input:  "\n-- daily report\n--sqls:connection=reporting\nSELECT 1",
+			want:   connectionBinding{Connection: "reporting"},
+			wantOK: true,
+		},
+		{
+			name:   "after statement",
+			input:  "SELECT 1;\n-- sqls: connection=reporting",
+			wantOK: false,
+		},
+		{
+			name:   "without connection",
+			input:  "-- sqls: database=sales\nSELECT 1",
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseConnectionBinding(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("got %v, want %v", ok, tt.wantOK)
+			}
+			if ok && *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentBinding(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "default", Driver: "mock"},
+			{Alias: "reporting", Driver: "mock"},
+		},
+	})
+
+	const reportURI = "file:///report.sql"
+	tx.textDocumentDidOpen(t, testFileURI, "SELECT 1")
+	tx.textDocumentDidOpen(t, reportURI, "-- sqls: connection=reporting database=sales\nSELECT  FROM city")
+
+	// the connection is opened on the first use, not by opening the document
+	if len(tx.server.sessions) != 0 {
+		t.Fatalf("sessions are opened by didOpen, %v", tx.server.sessions)
+	}
+	if sess := tx.server.openedSession(reportURI); sess != nil {
+		t.Errorf("unexpected opened session %+v", sess)
+	}
+
+	got, err := tx.server.documentSession(tx.ctx, reportURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, ok := tx.server.sessions["reporting/sales"]
+	if !ok || len(tx.server.sessions) != 1 {
+		t.Fatalf("unexpected sessions %v", tx.server.sessions)
+	}
+	if got != sess {
+		t.Error("the bound document does not use its session")
+	}
+	if sess.cfg.Alias != "reporting" || sess.cfg.DBName != "sales" {
+		t.Errorf("unexpected config %+v", sess.cfg)
+	}
+	if tx.server.curDBCfg.DBName != "" {
+		t.Errorf("default connection is changed to %q", tx.server.curDBCfg.DBName)
+	}
+	got, err = tx.server.documentSession(tx.ctx, testFileURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.worker != tx.server.worker {
+		t.Error("the unbound document does not use the default connection")
+	}
+
+	// completion uses the schema cache of the bound connection
+	var items []lsp.CompletionItem
+	params := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: reportURI},
+			Position:     lsp.Position{Line: 1, Character: 7},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", params, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	testCompletionItem(t, []string{"ID", "Name"}, []string{}, items)
+
+	// removing the comment closes the session nobody uses
+	didChangeParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{URI: reportURI, Version: 1},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Text: "SELECT 1"},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didChange", didChangeParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didChange:", err)
+	}
+	if len(tx.server.sessions) != 0 {
+		t.Errorf("unused sessions are open, %v", tx.server.sessions)
+	}
+
+	// an alias being typed uses the default connection without error
+	shown := len(tx.client.notified("window/showMessage"))
+	didChangeParams.ContentChanges[0].Text = "-- sqls: connection=r\nSELECT 1"
+	if err := tx.conn.Call(tx.ctx, "textDocument/didChange", didChangeParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didChange:", err)
+	}
+	got, err = tx.server.documentSession(tx.ctx, reportURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.worker != tx.server.worker || len(tx.server.sessions) != 0 {
+		t.Error("the document with an unknown alias does not use the default connection")
+	}
+	if msgs := tx.client.notified("window/showMessage"); len(msgs) != shown {
+		t.Errorf("unexpected message %s", *msgs[len(msgs)-1].Params)
+	}
+}
+
+var (
+	registerFailingDriver sync.Once
+	failingDials          int32
+)
+
+func TestCachedSession(t *testing.T) {
+	registerFailingDriver.Do(func() {
+		database.RegisterOpen("failing", func(*database.DBConfig) (*database.DBConnection, error) {
+			atomic.AddInt32(&failingDials, 1)
+			return nil, errors.New("connection refused")
+		})
+	})
+	atomic.StoreInt32(&failingDials, 0)
+
+	tx := newTestContext()
+	tx.server.reconnectBackoff = time.Minute
+	tx.setup(t)
+	defer tx.tearDown()
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "default", Driver: "mock"},
+			{Alias: "reporting", Driver: "mock"},
+			{Alias: "broken", Driver: "failing"},
+		},
+	})
+
+	const (
+		reportURI = "file:///report.sql"
+		brokenURI = "file:///broken.sql"
+	)
+	tx.textDocumentDidOpen(t, reportURI, "-- sqls: connection=reporting\nSELECT  FROM city")
+	tx.textDocumentDidOpen(t, brokenURI, "-- sqls: connection=broken\nSELECT  FROM city")
+	complete := func(t *testing.T, uri string) []lsp.CompletionItem {
+		t.Helper()
+		var items []lsp.CompletionItem
+		params := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Position:     lsp.Position{Line: 1, Character: 7},
+			},
+		}
+		if err := tx.conn.Call(tx.ctx, "textDocument/completion", params, &items); err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		return items
+	}
+	waitFor := func(t *testing.T, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			tx.server.mu.Lock()
+			ok := cond()
+			tx.server.mu.Unlock()
+			if ok {
+				return
+			}
+		}
+		t.Fatal("timed out")
+	}
+
+	// the connection is opened in the background, and completion is served without the cache meanwhile
+	complete(t, reportURI)
+	waitFor(t, func() bool {
+		sess, ok := tx.server.sessions["reporting"]
+		return ok && sess.cache() != nil
+	})
+	testCompletionItem(t, []string{"ID", "Name"}, []string{}, complete(t, reportURI))
+
+	// a connection failed to open is not dialed on every request
+	complete(t, brokenURI)
+	waitFor(t, func() bool {
+		op, ok := tx.server.openings["broken"]
+		return ok && !op.connecting
+	})
+	complete(t, brokenURI)
+	if got := atomic.LoadInt32(&failingDials); got != 1 {
+		t.Errorf("dialed %d times, want 1", got)
+	}
+	if _, ok := tx.server.sessions["broken"]; ok {
+		t.Error("the broken connection is open")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/lighttiger2505/sqls/dialect"
 	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/lsp"
 	"github.com/lighttiger2505/sqls/parser"
@@ -28,18 +30,15 @@ func (s *Server) handleTextDocumentSignatureHelp(ctx context.Context, conn *json
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := SignatureHelp(f.Text, params, s.worker.Cache())
+	sess := s.cachedSession(params.TextDocument.URI)
+	res, err := SignatureHelp(f.Text, params, sess.cache(), sess.driver())
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
-func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *database.DBCache) (*lsp.SignatureHelp, error) {
-	if dbCache == nil {
-		return nil, nil
-	}
-
+func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *database.DBCache, driver dialect.DatabaseDriver) (*lsp.SignatureHelp, error) {
 	parsed, err := parser.Parse(text)
 	if err != nil {
 		return nil, err
@@ -49,6 +48,16 @@ func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *databas
 		Line: params.Position.Line,
 		Col:  params.Position.Character,
 	}
+	if call := parseutil.ExtractFunctionCall(parsed, pos); call != nil {
+		if sh := functionSignatureHelp(call, dbCache, driver); sh != nil {
+			return sh, nil
+		}
+	}
+
+	if dbCache == nil {
+		return nil, nil
+	}
+
 	nodeWalker := parseutil.NewNodeWalker(parsed, pos)
 	types := getSignatureHelpTypes(nodeWalker)
 
@@ -101,6 +110,78 @@ func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *databas
 	}
 }
 
+type functionSignature struct {
+	info     lsp.SignatureInformation
+	variadic bool
+}
+
+func functionSignatureHelp(call *parseutil.FunctionCall, dbCache *database.DBCache, driver dialect.DatabaseDriver) *lsp.SignatureHelp {
+	signatures := []*functionSignature{}
+	if dbCache != nil {
+		for _, fd := range dbCache.Functions(call.Schema, call.Name) {
+			params := []lsp.ParameterInformation{}
+			for _, arg := range fd.InputArgs() {
+				params = append(params, lsp.ParameterInformation{
+					Label: arg.Label(),
+				})
+			}
+			signatures = append(signatures, &functionSignature{
+				info: lsp.SignatureInformation{
+					Label:         fd.Signature(),
+					Documentation: fmt.Sprintf("%s.%s %s", fd.Schema, fd.Name, strings.ToLower(fd.Kind)),
+					Parameters:    params,
+				},
+			})
+		}
+	}
+	// stored functions take precedence over built-in functions
+	if len(signatures) == 0 && call.Schema == "" {
+		for _, f := range dialect.LookupFunctions(driver, call.Name) {
+			params := []lsp.ParameterInformation{}
+			for _, p := range f.Params {
+				params = append(params, lsp.ParameterInformation{
+					Label: p,
+				})
+			}
+			signatures = append(signatures, &functionSignature{
+				info: lsp.SignatureInformation{
+					Label:         f.Signature(),
+					Documentation: f.Description,
+					Parameters:    params,
+				},
+				variadic: f.Variadic,
+			})
+		}
+	}
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	// select the first overload that accepts the current argument
+	activeSignature := 0
+	for i, sig := range signatures {
+		if call.ArgIndex < len(sig.info.Parameters) || sig.variadic {
+			activeSignature = i
+			break
+		}
+	}
+	activeParameter := call.ArgIndex
+	active := signatures[activeSignature]
+	if paramLen := len(active.info.Parameters); active.variadic && paramLen > 0 && activeParameter >= paramLen {
+		activeParameter = paramLen - 1
+	}
+
+	infos := []lsp.SignatureInformation{}
+	for _, sig := range signatures {
+		infos = append(infos, sig.info)
+	}
+	return &lsp.SignatureHelp{
+		Signatures:      infos,
+		ActiveSignature: float64(activeSignature),
+		ActiveParameter: float64(activeParameter),
+	}
+}
+
 type signatureHelpType int
 
 const (
@@ -27,6 +27,40 @@ var signatureHelpTestCases = []signatureHelpTestCase{
 	genInsertPositionTest(67, 2),
 }
 
+var functionSignatureHelpTestCases = []signatureHelpTestCase{
+	genFunctionPositionTest("select city_population(", 23, 0),
+	genFunctionPositionTest("select city_population(ID, ", 27, 1),
+	genFunctionPositionTest("select world.city_population(ID, 2020) from city", 30, 0),
+	genFunctionPositionTest("select world.city_population(ID, 2020) from city", 33, 1),
+}
+
+func genFunctionPositionTest(input string, col int, wantActiveParameter int) signatureHelpTestCase {
+	return signatureHelpTestCase{
+		name:  "",
+		input: input,
+		line:  0,
+		col:   col,
+		want: lsp.SignatureHelp{
+			Signatures: []lsp.SignatureInformation{
+				{
+					Label:         "city_population(city_id int, year int) RETURNS bigint",
+					Documentation: "world.city_population function",
+					Parameters: []lsp.ParameterInformation{
+						{
+							Label: "city_id int",
+						},
+						{
+							Label: "year int",
+						},
+					},
+				},
+			},
+			ActiveSignature: 0.0,
+			ActiveParameter: float64(wantActiveParameter),
+		},
+	}
+}
+
 func genInsertPositionTest(col int, wantActiveParameter int) signatureHelpTestCase {
 	return signatureHelpTestCase{
 		name:  "",
@@ -72,7 +106,81 @@ func TestSignatureHelpMain(t *testing.T) {
 	}
 	tx.addWorkspaceConfig(t, cfg)
 
-	for _, tt := range signatureHelpTestCases {
+	testCases := append(signatureHelpTestCases, functionSignatureHelpTestCases...)
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.textDocumentDidOpen(t, testFileURI, tt.input)
+
+			params := lsp.SignatureHelpParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: testFileURI,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+			var got lsp.SignatureHelp
+			if err := tx.conn.Call(tx.ctx, "textDocument/signatureHelp", params, &got); err != nil {
+				t.Fatal("conn.Call textDocument/signatureHelp:", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSignatureHelpBuiltinFunction(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	coalesceSignature := lsp.SignatureInformation{
+		Label:         "coalesce(X, Y, ...) RETURNS same as input",
+		Documentation: "Returns a copy of its first non-NULL argument, or NULL if all arguments are NULL. Coalesce() must have at least 2 arguments.",
+		Parameters: []lsp.ParameterInformation{
+			{Label: "X"},
+			{Label: "Y"},
+		},
+	}
+	testCases := []signatureHelpTestCase{
+		{
+			name:  "first argument",
+			input: "select coalesce(Name, CountryCode) from city",
+			col:   16,
+			want: lsp.SignatureHelp{
+				Signatures:      []lsp.SignatureInformation{coalesceSignature},
+				ActiveParameter: 0,
+			},
+		},
+		{
+			name:  "second argument",
+			input: "select coalesce(Name, CountryCode) from city",
+			col:   22,
+			want: lsp.SignatureHelp{
+				Signatures:      []lsp.SignatureInformation{coalesceSignature},
+				ActiveParameter: 1,
+			},
+		},
+		{
+			name:  "variadic argument",
+			input: "select COALESCE(Name, CountryCode, ",
+			col:   35,
+			want: lsp.SignatureHelp{
+				Signatures:      []lsp.SignatureInformation{coalesceSignature},
+				ActiveParameter: 1,
+			},
+		},
+	}
+	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			tx.textDocumentDidOpen(t, testFileURI, tt.input)
 
@@ -17,6 +17,31 @@ type InitializeOptions struct {
 }
 
 type ClientCapabilities struct {
+	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+}
+
+type TextDocumentClientCapabilities struct {
+	CodeAction CodeActionClientCapabilities `json:"codeAction,omitempty"`
+}
+
+type CodeActionClientCapabilities struct {
+	// CodeActionLiteralSupport is set when the client accepts CodeAction in addition to Command
+	CodeActionLiteralSupport *CodeActionLiteralSupport `json:"codeActionLiteralSupport,omitempty"`
+}
+
+type CodeActionLiteralSupport struct {
+	CodeActionKind struct {
+		ValueSet []CodeActionKind `json:"valueSet"`
+	} `json:"codeActionKind"`
+}
+
+type WorkspaceClientCapabilities struct {
+	DidChangeWatchedFiles DidChangeWatchedFilesClientCapabilities `json:"didChangeWatchedFiles,omitempty"`
+}
+
+type DidChangeWatchedFilesClientCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
 type InitializeResult struct {
@@ -77,7 +102,9 @@ type DocumentOnTypeFormattingOptions struct{}
 
 type DocumentLinkOptions struct{}
 
-type ExecuteCommandOptions struct{}
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
 
 // https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#textDocument_didOpen
 
@@ -246,6 +273,25 @@ type TextDocumentPositionParams struct {
 
 type CodeActionKind string
 
+const (
+	QuickFix        CodeActionKind = "quickfix"
+	Refactor        CodeActionKind = "refactor"
+	RefactorRewrite CodeActionKind = "refactor.rewrite"
+)
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        CodeActionKind `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
+
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
 type Location struct {
 	URI   string `json:"uri"`
 	Range Range  `json:"range"`
@@ -256,15 +302,30 @@ type DiagnosticRelatedInformation struct {
 	Message  string   `json:"message"`
 }
 
+type DiagnosticSeverity int
+
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
 type Diagnostic struct {
 	Range              Range                          `json:"range"`
-	Severity           int                            `json:"severity,omitempty"`
+	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
 	Code               *string                        `json:"code,omitempty"`
 	Source             *string                        `json:"source,omitempty"`
 	Message            string                         `json:"message"`
 	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
 }
 
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#textDocument_publishDiagnostics
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
 type WorkDoneProgressParams struct {
 	WorkDoneToken interface{} `json:"workDoneToken"`
 }
@@ -306,6 +367,43 @@ type DidChangeConfigurationParams struct {
 	} `json:"settings"`
 }
 
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#workspace_didChangeWatchedFiles
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+type FileEvent struct {
+	URI  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type FileChangeType int
+
+const (
+	Created FileChangeType = 1
+	Changed FileChangeType = 2
+	Deleted FileChangeType = 3
+)
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#client_registerCapability
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type Registration struct {
+	ID              string      `json:"id"`
+	Method          string      `json:"method"`
+	RegisterOptions interface{} `json:"registerOptions,omitempty"`
+}
+
 type MarkupKind string
 
 const (
@@ -7,10 +7,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/sourcegraph/jsonrpc2"
 
 	"github.com/lighttiger2505/sqls/internal/config"
+	"github.com/lighttiger2505/sqls/internal/database"
 	"github.com/lighttiger2505/sqls/internal/handler"
 )
 
@@ -26,6 +28,8 @@ var (
 	logfile    string
 	trace      bool
 	configFile string
+	cacheDir   string
+	noCache    bool
 )
 
 func main() {
@@ -34,10 +38,12 @@ func main() {
 	flag.StringVar(&logfile, "log", "", "Also log to this file. (in addition to stderr)")
 	flag.StringVar(&configFile, "config", "", "Specifies an alternative per-user configuration file. If a configuration file is given on the command line, the workspace option (initializationOptions) will be ignored.")
 	flag.BoolVar(&trace, "trace", false, "Print all requests and responses.")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Directory to store the database schema cache. (default: $XDG_CACHE_HOME/sqls/schema)")
+	flag.BoolVar(&noCache, "no-cache", false, "Do not store the database schema cache.")
 	flag.Parse()
 
 	if help {
-		fmt.Fprintf(os.Stderr, "usage: sqls [flags]\n")
+		fmt.Fprintf(os.Stderr, "usage: sqls [flags]\n       sqls [flags] config check [file...]\n")
 		flag.PrintDefaults()
 		return
 	}
@@ -61,6 +67,9 @@ func main() {
 	log.SetOutput(logWriter)
 
 	if flag.NArg() != 0 {
+		if flag.NArg() >= 2 && flag.Arg(0) == "config" && flag.Arg(1) == "check" {
+			os.Exit(checkConfig(flag.Args()[2:]))
+		}
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -74,6 +83,20 @@ func main() {
 	}()
 	h := jsonrpc2.HandlerWithError(server.Handle)
 
+	// Persist the schema cache so that completion works right after startup
+	if !noCache {
+		if cacheDir == "" {
+			dir, err := database.DefaultCacheDir()
+			if err != nil {
+				log.Printf("cannot find schema cache directory, %+v", err)
+			}
+			cacheDir = dir
+		}
+		if cacheDir != "" {
+			server.SetSchemaCacheDir(cacheDir)
+		}
+	}
+
 	// Load specific config
 	if configFile != "" {
 		cfg, err := config.GetConfig(configFile)
@@ -81,6 +104,9 @@ func main() {
 			log.Printf("cannot read specificed config, %+v", err)
 		}
 		server.SpecificFileCfg = cfg
+		if fp, err := filepath.Abs(configFile); err == nil {
+			server.SpecificFilePath = fp
+		}
 	} else {
 		// Load default config
 		cfg, err := config.GetDefaultConfig()
@@ -88,6 +114,7 @@ func main() {
 			log.Printf("cannot read default config, %+v", err)
 		}
 		server.DefaultFileCfg = cfg
+		server.DefaultFilePath = config.DefaultConfigPath()
 	}
 
 	// Set connect option
@@ -107,6 +134,52 @@ func main() {
 	log.Println("sqls: connections closed")
 }
 
+// checkConfig validates the config files, or the config files in use when none is given, and returns the exit status
+func checkConfig(files []string) int {
+	if len(files) == 0 {
+		if configFile != "" {
+			files = append(files, configFile)
+		} else {
+			if fp := config.DefaultConfigPath(); config.IsFileExist(fp) {
+				files = append(files, fp)
+			}
+			if wd, err := os.Getwd(); err == nil {
+				if fp, ok := config.FindProjectConfig(wd, ""); ok {
+					files = append(files, fp)
+				}
+			}
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "no config file found")
+		return 1
+	}
+
+	status := 0
+	for _, fp := range files {
+		_, errs, err := config.CheckFile(fp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", fp, err)
+			status = 1
+			continue
+		}
+		for _, e := range errs {
+			if e.Line == 0 {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", fp, e.Message)
+			} else if e.Column > 0 {
+				fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", fp, e.Line, e.Column, e.Message)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s:%d: %s\n", fp, e.Line, e.Message)
+			}
+			status = 1
+		}
+		if len(errs) == 0 {
+			fmt.Printf("%s: ok\n", fp)
+		}
+	}
+	return status
+}
+
 type stdrwc struct{}
 
 func (stdrwc) Read(p []byte) (int, error) {
@@ -203,6 +203,8 @@ func parseFunctions(reader *astutil.NodeReader) ast.Node {
 	funcName := reader.CurNode
 	if reader.PeekNodeIs(false, functionArgsMatcher) {
 		_, funcArgs := reader.PeekNode(false)
+		// parse nested function calls in arguments
+		funcArgs = parsePrefixGroup(astutil.NewNodeReader(funcArgs.(ast.TokenList)), functionPrefixMatcher, parseFunctions)
 		function := &ast.FunctionLiteral{Toks: []ast.Node{funcName, funcArgs}}
 		reader.NextNode(false)
 		return function
@@ -247,6 +249,29 @@ func parseMemberIdentifier(reader *astutil.NodeReader) ast.Node {
 		child,
 	)
 
+	reader.NextNode(false)
+
+	// schema.table.column
+	if _, ok := child.(*ast.Identifer); !ok || !reader.PeekNodeIs(false, memberIdentifierInfixMatcher) {
+		return memberIdentifier
+	}
+	schema, table := parent, child
+	reader.NextNode(false)
+	if !reader.PeekNodeIs(true, memberIdentifierTargetMatcher) {
+		return ast.NewMemberIdentiferSchema(
+			reader.NodesWithRange(startIndex, reader.Index),
+			schema,
+			table,
+			nil,
+		)
+	}
+	endIndex, column := reader.PeekNode(true)
+	memberIdentifier = ast.NewMemberIdentiferSchema(
+		reader.NodesWithRange(startIndex, endIndex+1),
+		schema,
+		table,
+		column,
+	)
 	reader.NextNode(false)
 	return memberIdentifier
 }
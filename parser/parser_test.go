@@ -492,6 +492,40 @@ func TestMemberIdentifier(t *testing.T) {
 				testMemberIdentifier(t, list[6], "myschema.abc", "myschema", "abc")
 			},
 		},
+		{
+			name:  "schema member identifier",
+			input: "select myschema.abc.foo from myschema.abc",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMemberIdentifier(t, list[2], "myschema.abc.foo", "abc", "foo")
+				testMemberIdentifierSchema(t, list[2], "myschema")
+				testMemberIdentifier(t, list[6], "myschema.abc", "myschema", "abc")
+				testMemberIdentifierSchema(t, list[6], "")
+			},
+		},
+		{
+			name:  "invalid schema member identifier",
+			input: "select myschema.abc. from abc",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMemberIdentifier(t, list[2], "myschema.abc.", "abc", "")
+				testMemberIdentifierSchema(t, list[2], "myschema")
+				testPos(t, list[2], genPosOneline(7), genPosOneline(20))
+				testItem(t, list[3], " ")
+			},
+		},
+		{
+			name:  "schema member identifier wildcard",
+			input: "myschema.abc.*",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				testMemberIdentifier(t, list[0], input, "abc", "*")
+				testMemberIdentifierSchema(t, list[0], "myschema")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1259,6 +1293,18 @@ func testMemberIdentifier(t *testing.T, node ast.Node, expect, parent, child str
 	}
 }
 
+func testMemberIdentifierSchema(t *testing.T, node ast.Node, schema string) {
+	t.Helper()
+	mi := node.(*ast.MemberIdentifer)
+	var got string
+	if mi.Schema != nil {
+		got = mi.Schema.String()
+	}
+	if schema != got {
+		t.Errorf("schema expected %q , got %q", schema, got)
+	}
+}
+
 func testIdentifier(t *testing.T, node ast.Node, expect string) {
 	t.Helper()
 	_, ok := node.(*ast.Identifer)
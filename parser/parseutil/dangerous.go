@@ -0,0 +1,195 @@
+package parseutil
+
+import (
+	"strings"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/token"
+)
+
+// DangerousStatement is a statement which changes or removes every row of a table
+type DangerousStatement struct {
+	Reason string
+	// CountQuery counts the rows affected by the statement, empty if they cannot be counted
+	CountQuery string
+}
+
+// CheckDangerousStatement finds UPDATE and DELETE without WHERE, TRUNCATE and DROP.
+// The statement following a WITH clause and the data-modifying CTEs of the clause are checked as well.
+func CheckDangerousStatement(stmt *ast.Statement) (*DangerousStatement, bool) {
+	return checkDangerousNodes(meaningfulNodes(stmt.GetTokens()))
+}
+
+func checkDangerousNodes(nodes []ast.Node) (*DangerousStatement, bool) {
+	nodes, ctes := skipWith(nodes)
+	for _, cte := range ctes {
+		if d, ok := checkDangerousNodes(meaningfulNodes(cte.Inner().GetTokens())); ok {
+			return d, true
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, false
+	}
+
+	switch keywordString(nodes[0]) {
+	case "UPDATE":
+		target := nodesUntilKeyword(nodes[1:], "SET")
+		if hasKeyword(nodes, "WHERE") {
+			return nil, false
+		}
+		return &DangerousStatement{
+			Reason:     "UPDATE without WHERE updates every row",
+			CountQuery: countQuery(target),
+		}, true
+	case "DELETE FROM", "DELETE":
+		rest := nodes[1:]
+		if len(rest) > 0 && keywordString(rest[0]) == "FROM" {
+			rest = rest[1:]
+		}
+		target := nodesUntilKeyword(rest, "WHERE", "USING", "RETURNING", "ORDER", "LIMIT")
+		if hasKeyword(nodes, "WHERE") {
+			return nil, false
+		}
+		return &DangerousStatement{
+			Reason:     "DELETE without WHERE deletes every row",
+			CountQuery: countQuery(target),
+		}, true
+	case "TRUNCATE":
+		target := nodes[1:]
+		if len(target) > 0 && keywordString(target[0]) == "TABLE" {
+			target = target[1:]
+		}
+		d := &DangerousStatement{Reason: "TRUNCATE deletes every row"}
+		if len(target) == 1 && target[0].Type() != ast.TypeIdentiferList {
+			d.CountQuery = countQuery(target)
+		}
+		return d, true
+	case "DROP":
+		return &DangerousStatement{Reason: "DROP cannot be undone"}, true
+	}
+	return nil, false
+}
+
+// WithModifiesData reports whether the statement starting with a WITH clause modifies data,
+// by the statement following the clause or by a data-modifying CTE like "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d".
+func WithModifiesData(stmt *ast.Statement) bool {
+	return withModifiesData(meaningfulNodes(stmt.GetTokens()))
+}
+
+func withModifiesData(nodes []ast.Node) bool {
+	nodes, ctes := skipWith(nodes)
+	for _, cte := range ctes {
+		if withModifiesData(meaningfulNodes(cte.Inner().GetTokens())) {
+			return true
+		}
+	}
+	if len(nodes) == 0 {
+		return false
+	}
+	words := strings.Fields(keywordString(nodes[0]))
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE":
+		return true
+	}
+	return false
+}
+
+// skipWith returns the nodes following the leading WITH clause and the bodies of its CTEs,
+// "WITH [RECURSIVE] name [(columns)] AS [[NOT] MATERIALIZED] (body) [, ...]"
+func skipWith(nodes []ast.Node) ([]ast.Node, []*ast.Parenthesis) {
+	if len(nodes) == 0 || !isKeyword(nodes[0], "WITH") {
+		return nodes, nil
+	}
+	rest := nodes[1:]
+	if len(rest) > 0 && isKeyword(rest[0], "RECURSIVE") {
+		rest = rest[1:]
+	}
+	ctes := []*ast.Parenthesis{}
+	for {
+		// the name and the columns of the CTE
+		for len(rest) > 0 && !isKeyword(rest[0], "AS") {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return rest, ctes
+		}
+		rest = rest[1:]
+		for len(rest) > 0 && (isKeyword(rest[0], "NOT") || isKeyword(rest[0], "MATERIALIZED")) {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return rest, ctes
+		}
+		body, ok := rest[0].(*ast.Parenthesis)
+		if !ok {
+			return rest, ctes
+		}
+		ctes = append(ctes, body)
+		rest = rest[1:]
+		if len(rest) == 0 || keywordString(rest[0]) != "," {
+			return rest, ctes
+		}
+		rest = rest[1:]
+	}
+}
+
+// meaningfulNodes returns the nodes without whitespaces, comments and the semicolon
+func meaningfulNodes(tokens []ast.Node) []ast.Node {
+	nodes := []ast.Node{}
+	for _, node := range tokens {
+		if tok, ok := node.(ast.Token); ok {
+			sqlTok := tok.GetToken()
+			if sqlTok.MatchKind(token.Whitespace) || sqlTok.MatchKind(token.Comment) || sqlTok.MatchKind(token.Semicolon) {
+				continue
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func keywordString(node ast.Node) string {
+	return strings.ToUpper(strings.Join(strings.Fields(node.String()), " "))
+}
+
+func isKeyword(node ast.Node, keyword string) bool {
+	tok, ok := node.(ast.Token)
+	return ok && tok.GetToken().MatchSQLKeyword(keyword)
+}
+
+func hasKeyword(nodes []ast.Node, keyword string) bool {
+	for _, node := range nodes {
+		if isKeyword(node, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodesUntilKeyword compares the first word of the nodes as well,
+// since keywords unknown to the parser like RETURNING are grouped with the following tokens, as in "RETURNING *"
+func nodesUntilKeyword(nodes []ast.Node, keywords ...string) []ast.Node {
+	for i, node := range nodes {
+		words := strings.Fields(keywordString(node))
+		for _, keyword := range keywords {
+			if isKeyword(node, keyword) || (len(words) > 0 && words[0] == keyword) {
+				return nodes[:i]
+			}
+		}
+	}
+	return nodes
+}
+
+func countQuery(target []ast.Node) string {
+	if len(target) == 0 {
+		return ""
+	}
+	strs := make([]string, len(target))
+	for i, node := range target {
+		strs[i] = node.String()
+	}
+	return "SELECT count(*) FROM " + strings.Join(strs, " ")
+}
@@ -0,0 +1,82 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/parser"
+)
+
+func TestCheckDangerousStatement(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		want   bool
+		reason string
+		count  string
+	}{
+		{name: "delete with where", input: "DELETE FROM city WHERE ID = 1", want: false},
+		{name: "delete without where", input: "DELETE FROM city;", want: true, reason: "DELETE without WHERE deletes every row", count: "SELECT count(*) FROM city"},
+		{name: "delete with schema", input: "delete from world.city", want: true, reason: "DELETE without WHERE deletes every row", count: "SELECT count(*) FROM world.city"},
+		{name: "update with where", input: "UPDATE city SET Name = 'x' WHERE ID = 1", want: false},
+		{name: "update without where", input: "UPDATE city c SET c.Name = 'x', Population = 0", want: true, reason: "UPDATE without WHERE updates every row", count: "SELECT count(*) FROM city c"},
+		{name: "where in subquery", input: "UPDATE city SET Population = (SELECT 1 FROM country WHERE Code = 'JPN')", want: true, reason: "UPDATE without WHERE updates every row", count: "SELECT count(*) FROM city"},
+		{name: "truncate", input: "TRUNCATE TABLE city", want: true, reason: "TRUNCATE deletes every row", count: "SELECT count(*) FROM city"},
+		{name: "drop", input: "DROP TABLE IF EXISTS city", want: true, reason: "DROP cannot be undone"},
+		{name: "select", input: "SELECT * FROM city", want: false},
+		{name: "insert", input: "INSERT INTO city (ID) VALUES (1)", want: false},
+		{name: "with select", input: "WITH c AS (SELECT * FROM city) SELECT * FROM c", want: false},
+		{name: "with delete without where", input: "WITH c AS (SELECT ID FROM city) DELETE FROM city", want: true, reason: "DELETE without WHERE deletes every row", count: "SELECT count(*) FROM city"},
+		{name: "with delete with where", input: "WITH c AS (SELECT ID FROM city) DELETE FROM city WHERE ID IN (SELECT ID FROM c)", want: false},
+		{name: "with update without where", input: "WITH RECURSIVE c (n) AS NOT MATERIALIZED (SELECT 1), d AS (SELECT 2) UPDATE city SET Population = 0", want: true, reason: "UPDATE without WHERE updates every row", count: "SELECT count(*) FROM city"},
+		{name: "delete in with", input: "WITH d AS (DELETE FROM city RETURNING *) SELECT * FROM d", want: true, reason: "DELETE without WHERE deletes every row", count: "SELECT count(*) FROM city"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stmt := parsed.GetTokens()[0].(*ast.Statement)
+			got, ok := CheckDangerousStatement(stmt)
+			if ok != tt.want {
+				t.Fatalf("got %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if got.Reason != tt.reason {
+				t.Errorf("reason got %q, want %q", got.Reason, tt.reason)
+			}
+			if got.CountQuery != tt.count {
+				t.Errorf("count query got %q, want %q", got.CountQuery, tt.count)
+			}
+		})
+	}
+}
+
+func TestWithModifiesData(t *testing.T) {
+	cases := []struct {
+		input string
+		want  bool
+	}{
+		{input: "WITH c AS (SELECT * FROM city) SELECT * FROM c", want: false},
+		{input: "WITH RECURSIVE c (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM c) SELECT * FROM c", want: false},
+		{input: "WITH c AS (SELECT ID FROM city) DELETE FROM city WHERE ID IN (SELECT ID FROM c)", want: true},
+		{input: "with c as (select 1) update city set Population = 0", want: true},
+		{input: "WITH c AS (SELECT 1) INSERT INTO city (ID) SELECT * FROM c", want: true},
+		{input: "WITH d AS (DELETE FROM city WHERE ID = 1 RETURNING *) SELECT * FROM d", want: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stmt := parsed.GetTokens()[0].(*ast.Statement)
+			if got := WithModifiesData(stmt); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package parseutil
+
+import (
+	"github.com/lighttiger2505/sqls/ast"
+	"github.com/lighttiger2505/sqls/ast/astutil"
+	"github.com/lighttiger2505/sqls/token"
+)
+
+type FunctionCall struct {
+	Schema   string
+	Name     string
+	Literal  *ast.FunctionLiteral
+	ArgIndex int
+}
+
+var functionLiteralMatcher = astutil.NodeMatcher{
+	NodeTypes: []ast.NodeType{ast.TypeFunctionLiteral},
+}
+
+// ExtractFunctionCall returns the innermost function call whose argument list encloses the position.
+func ExtractFunctionCall(parsed ast.TokenList, pos token.Pos) *FunctionCall {
+	nw := NewNodeWalker(parsed, pos)
+	for i := len(nw.Paths) - 1; i >= 0; i-- {
+		reader := nw.Paths[i]
+		if !reader.CurNodeIs(functionLiteralMatcher) {
+			continue
+		}
+		fl := reader.CurNode.(*ast.FunctionLiteral)
+		name, args, ok := splitFunctionLiteral(fl)
+		if !ok || !inArguments(args, pos) {
+			continue
+		}
+
+		call := &FunctionCall{
+			Name:     name,
+			Literal:  fl,
+			ArgIndex: countArgIndex(args, pos),
+		}
+		// schema qualified function, example "app.my_func("
+		if _, prev := reader.PrevNode(false); prev != nil {
+			if mi, ok := prev.(*ast.MemberIdentifer); ok && mi.Child == nil && mi.ParentTok != nil {
+				call.Schema = mi.ParentTok.NoQuateString()
+			}
+		}
+		return call
+	}
+	return nil
+}
+
+// FunctionName returns the unquoted name of the function literal.
+func FunctionName(fl *ast.FunctionLiteral) string {
+	name, _, _ := splitFunctionLiteral(fl)
+	return name
+}
+
+func splitFunctionLiteral(fl *ast.FunctionLiteral) (string, *ast.Parenthesis, bool) {
+	toks := fl.GetTokens()
+	if len(toks) != 2 {
+		return "", nil, false
+	}
+	args, ok := toks[1].(*ast.Parenthesis)
+	if !ok {
+		return "", nil, false
+	}
+	var name string
+	switch v := toks[0].(type) {
+	case *ast.Identifer:
+		name = v.NoQuateString()
+	case *ast.Item:
+		name = v.NoQuateString()
+	default:
+		name = v.String()
+	}
+	return name, args, true
+}
+
+func inArguments(args *ast.Parenthesis, pos token.Pos) bool {
+	if token.ComparePos(pos, args.Pos()) <= 0 {
+		return false
+	}
+	toks := args.GetTokens()
+	closed := false
+	if last, ok := toks[len(toks)-1].(ast.Token); ok && len(toks) > 1 {
+		closed = last.GetToken().MatchKind(token.RParen)
+	}
+	if closed && token.ComparePos(pos, args.End()) >= 0 {
+		return false
+	}
+	return true
+}
+
+func countArgIndex(list ast.TokenList, pos token.Pos) int {
+	var idx int
+	for _, node := range list.GetTokens() {
+		if token.ComparePos(node.Pos(), pos) >= 0 {
+			break
+		}
+		switch v := node.(type) {
+		case *ast.Parenthesis, *ast.FunctionLiteral:
+			// commas in nested calls belong to the nested arguments
+		case ast.TokenList:
+			idx += countArgIndex(v, pos)
+		case ast.Token:
+			if v.GetToken().MatchKind(token.Comma) {
+				idx++
+			}
+		}
+	}
+	return idx
+}
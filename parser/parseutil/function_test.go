@@ -0,0 +1,106 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/lighttiger2505/sqls/token"
+)
+
+func TestExtractFunctionCall(t *testing.T) {
+	testcases := []struct {
+		name     string
+		input    string
+		pos      token.Pos
+		want     bool
+		schema   string
+		funcName string
+		argIndex int
+	}{
+		{
+			name:     "first argument",
+			input:    "SELECT my_func(a, b) FROM t",
+			pos:      token.Pos{Line: 0, Col: 15},
+			want:     true,
+			funcName: "my_func",
+			argIndex: 0,
+		},
+		{
+			name:     "second argument",
+			input:    "SELECT my_func(a, b) FROM t",
+			pos:      token.Pos{Line: 0, Col: 18},
+			want:     true,
+			funcName: "my_func",
+			argIndex: 1,
+		},
+		{
+			name:     "unclosed keyword function",
+			input:    "SELECT coalesce(",
+			pos:      token.Pos{Line: 0, Col: 16},
+			want:     true,
+			funcName: "coalesce",
+			argIndex: 0,
+		},
+		{
+			name:     "schema qualified",
+			input:    "SELECT app.my_func(1, ",
+			pos:      token.Pos{Line: 0, Col: 22},
+			want:     true,
+			schema:   "app",
+			funcName: "my_func",
+			argIndex: 1,
+		},
+		{
+			name:     "nested function",
+			input:    "SELECT outer_func(1, inner_func(2, 3), 4)",
+			pos:      token.Pos{Line: 0, Col: 35},
+			want:     true,
+			funcName: "inner_func",
+			argIndex: 1,
+		},
+		{
+			name:     "after nested function",
+			input:    "SELECT outer_func(1, inner_func(2, 3), 4)",
+			pos:      token.Pos{Line: 0, Col: 39},
+			want:     true,
+			funcName: "outer_func",
+			argIndex: 2,
+		},
+		{
+			name:  "function name",
+			input: "SELECT my_func(a, b) FROM t",
+			pos:   token.Pos{Line: 0, Col: 10},
+			want:  false,
+		},
+		{
+			name:  "after closed",
+			input: "SELECT my_func(a, b) FROM t",
+			pos:   token.Pos{Line: 0, Col: 20},
+			want:  false,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := initExtractTable(t, tt.input)
+			got := ExtractFunctionCall(stmt, tt.pos)
+			if !tt.want {
+				if got != nil {
+					t.Fatalf("unexpected function call, %q", got.Name)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("function call not found")
+			}
+			if got.Schema != tt.schema {
+				t.Errorf("schema got %q, want %q", got.Schema, tt.schema)
+			}
+			if got.Name != tt.funcName {
+				t.Errorf("name got %q, want %q", got.Name, tt.funcName)
+			}
+			if got.ArgIndex != tt.argIndex {
+				t.Errorf("arg index got %d, want %d", got.ArgIndex, tt.argIndex)
+			}
+		})
+	}
+}